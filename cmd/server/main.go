@@ -3,39 +3,84 @@ package main
 import (
 	"context"
 	_ "embed"
-	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
 	"log"
 
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
 	"github.com/robryanx/mcp-temporal-server/internal/config"
 	"github.com/robryanx/mcp-temporal-server/internal/handler"
+	"github.com/robryanx/mcp-temporal-server/internal/redact"
 	"github.com/robryanx/mcp-temporal-server/internal/temporal"
+	"github.com/robryanx/mcp-temporal-server/internal/watch"
 )
 
 //go:embed instructions.txt
 var instructions []byte
 
 func main() {
-	cfg := config.Load()
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
+	}
+	transport := flag.String("transport", cfg.Transport, "MCP transport to serve: 'stdio' or 'http' (Streamable HTTP and SSE on PORT)")
+	writeMode := flag.Bool("write-mode", cfg.WriteMode, "Enable tools that change workflows, such as signal_workflow and terminate_workflow")
+	flag.Parse()
+
 	tClient, err := temporal.NewTemporalClient(cfg)
 	if err != nil {
-		panic(err)
+		log.Fatalf("Failed to create Temporal client: %v", err)
+	}
+
+	redactor, err := redact.New(cfg.Redaction)
+	if err != nil {
+		log.Fatalf("Failed to load redaction rules: %v", err)
 	}
 
+	hooks := &server.Hooks{}
 	s := server.NewMCPServer(
 		"Temporal MCP Server",
 		"1.0.0",
 		server.WithToolCapabilities(true),
 		server.WithResourceCapabilities(true, false),
 		server.WithInstructions(string(instructions)),
+		server.WithHooks(hooks),
 	)
 
+	// Watch subscribed workflows and tell clients when their history grows
+	watchers := watch.NewManager(tClient, func(sessionID, uri string) error {
+		err := s.SendNotificationToSpecificClient(sessionID, mcp.MethodNotificationResourceUpdated, map[string]any{"uri": uri})
+		if errors.Is(err, server.ErrSessionNotFound) {
+			return fmt.Errorf("%w: %v", watch.ErrSessionClosed, err)
+		}
+		return err
+	}, watch.Limits{MaxWatchers: cfg.Watch.MaxWatchers, MaxPerSession: cfg.Watch.MaxPerSession})
+	sessions := newSessionRegistry(hooks)
+	hooks.AddOnUnregisterSession(func(_ context.Context, session server.ClientSession) {
+		watchers.CloseSession(session.SessionID())
+	})
+
 	// Define the workflow_history tool schema
 	tool := mcp.NewTool("workflow_history",
-		mcp.WithDescription("Retrieve a workflow history"),
+		mcp.WithDescription("Retrieve a workflow history, one budgeted page at a time. When events are elided the summary says so and next_cursor/prev_cursor page forward and backward."),
 		mcp.WithString("workflow_id", mcp.Required(), mcp.Description("The ID of the workflow to retrieve")),
 		mcp.WithString("run_id", mcp.Description("Optional run ID of the workflow")),
+		mcp.WithNumber("max_events", mcp.Description("Maximum number of events to return (default 200)")),
+		mcp.WithNumber("max_bytes", mcp.Description("Maximum size in bytes of the returned events (default 100000)")),
+		mcp.WithString("cursor", mcp.Description("next_cursor or prev_cursor from a previous workflow_history response")),
+		mcp.WithNumber("start_event_id", mcp.Description("Only return events with this ID or later")),
+		mcp.WithNumber("end_event_id", mcp.Description("Only return events with this ID or earlier")),
+		mcp.WithArray("include_event_types", mcp.Items(map[string]any{"type": "string"}), mcp.Description("Only return these event types (e.g. ActivityTaskFailed) or categories: activity, signal, timer, child_workflow, workflow_task, update, marker")),
+		mcp.WithArray("exclude_event_types", mcp.Items(map[string]any{"type": "string"}), mcp.Description("Leave out these event types or categories")),
+		mcp.WithString("start_time", mcp.Description("RFC3339 time; only return events at or after it")),
+		mcp.WithString("end_time", mcp.Description("RFC3339 time; only return events at or before it")),
+		mcp.WithString("activity_type", mcp.Description("Only return lifecycle events of activities of this type")),
+		mcp.WithString("signal_name", mcp.Description("Only return events for signals with this name")),
+		mcp.WithString("view", mcp.Description("'events' (default) returns raw events; 'activities' folds each activity invocation into one record with input, result or failure, attempts, queued and run time, its events and a retry timeline of the latest attempts with their failures and retry state"), mcp.Enum(handler.HistoryViewEvents, handler.HistoryViewActivities)),
+		mcp.WithBoolean("follow_continue_as_new", mcp.Description("Read every run of the continue-as-new chain, returning a summary per run and merged events tagged with their run_id")),
+		mcp.WithNumber("max_runs", mcp.Description("Maximum number of runs to read when following continue-as-new (default 10)")),
 	)
 
 	s.AddTool(tool, func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
@@ -43,14 +88,32 @@ func main() {
 		if err != nil {
 			return mcp.NewToolResultError("Missing required workflow_id"), nil
 		}
-		runID := req.GetString("run_id", "")
-		args := handler.WorkflowHistoryArgs{WorkflowID: workflowID, RunID: runID}
+		args := handler.WorkflowHistoryArgs{
+			WorkflowID:   workflowID,
+			RunID:        req.GetString("run_id", ""),
+			MaxEvents:    req.GetInt("max_events", 0),
+			MaxBytes:     req.GetInt("max_bytes", 0),
+			Cursor:       req.GetString("cursor", ""),
+			StartEventID: int64(req.GetInt("start_event_id", 0)),
+			EndEventID:   int64(req.GetInt("end_event_id", 0)),
+
+			IncludeEventTypes: req.GetStringSlice("include_event_types", nil),
+			ExcludeEventTypes: req.GetStringSlice("exclude_event_types", nil),
+			StartTime:         req.GetString("start_time", ""),
+			EndTime:           req.GetString("end_time", ""),
+			ActivityType:      req.GetString("activity_type", ""),
+			SignalName:        req.GetString("signal_name", ""),
+			View:              req.GetString("view", ""),
+
+			FollowContinueAsNew: req.GetBool("follow_continue_as_new", false),
+			MaxRuns:             req.GetInt("max_runs", 0),
+		}
 		history, err := handler.GetWorkflowHistoryHandler(ctx, tClient, args)
 		if err != nil {
 			return mcp.NewToolResultError(err.Error()), nil
 		}
 		history.Instructions = string(instructions)
-		jsonData, err := json.Marshal(history)
+		jsonData, err := redactor.Marshal(history)
 		if err != nil {
 			return mcp.NewToolResultError("Failed to marshal history"), nil
 		}
@@ -59,22 +122,557 @@ func main() {
 
 	// Define the failed_workflows tool schema
 	failedWorkflowsTool := mcp.NewTool("failed_workflows",
-		mcp.WithDescription("Retrieve a list of workflows that are currently failing"),
+		mcp.WithDescription("Retrieve workflows that are currently failing: running workflows with error events in their history, and closed workflows that failed or timed out"),
+		mcp.WithString("workflow_type", mcp.Description("Only consider workflows of this type")),
+		mcp.WithString("start_time", mcp.Description("RFC3339 start of the time window. Applies to close time for closed workflows and to error events for running ones")),
+		mcp.WithString("end_time", mcp.Description("RFC3339 end of the time window")),
+		mcp.WithString("lookback", mcp.Description("Duration such as '24h' used as the window start when start_time is not set")),
+		mcp.WithString("scope", mcp.Description("Which workflows to scan"), mcp.Enum(handler.FailedScopeAll, handler.FailedScopeRunning, handler.FailedScopeClosed)),
+		mcp.WithNumber("max_workflows", mcp.Description("Maximum number of workflows to scan per scope (default 200)")),
+		mcp.WithBoolean("group", mcp.Description("Cluster failures by workflow type, activity type and normalized error message, returning counts, first/last seen and sample workflow IDs instead of individual workflows")),
 	)
 
 	s.AddTool(failedWorkflowsTool, func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-		failedWorkflows, err := handler.GetFailedWorkflowsHandler(ctx, tClient)
+		args := handler.FailedWorkflowsArgs{
+			WorkflowType: req.GetString("workflow_type", ""),
+			StartTime:    req.GetString("start_time", ""),
+			EndTime:      req.GetString("end_time", ""),
+			Lookback:     req.GetString("lookback", ""),
+			Scope:        req.GetString("scope", ""),
+			MaxWorkflows: req.GetInt("max_workflows", 0),
+			Group:        req.GetBool("group", false),
+		}
+		failedWorkflows, err := handler.GetFailedWorkflowsHandler(ctx, tClient, args)
 		if err != nil {
 			return mcp.NewToolResultError(err.Error()), nil
 		}
 
-		jsonData, err := json.Marshal(failedWorkflows)
+		jsonData, err := redactor.Marshal(failedWorkflows)
 		if err != nil {
 			return mcp.NewToolResultError("Failed to marshal failed workflows"), nil
 		}
 		return mcp.NewToolResultText(string(jsonData)), nil
 	})
 
+	// Define the describe_workflow tool schema
+	describeWorkflowTool := mcp.NewTool("describe_workflow",
+		mcp.WithDescription("Describe a workflow's current state: status, type, task queue, start/close times, memo, search attributes, pending activities (attempts, last failure, heartbeat details, next retry) and pending child workflows. Use this first to find out why a workflow is stuck."),
+		mcp.WithString("workflow_id", mcp.Required(), mcp.Description("The ID of the workflow to describe")),
+		mcp.WithString("run_id", mcp.Description("Optional run ID of the workflow")),
+	)
+
+	s.AddTool(describeWorkflowTool, func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		workflowID, err := req.RequireString("workflow_id")
+		if err != nil {
+			return mcp.NewToolResultError("Missing required workflow_id"), nil
+		}
+		args := handler.DescribeWorkflowArgs{WorkflowID: workflowID, RunID: req.GetString("run_id", "")}
+		description, err := handler.DescribeWorkflowHandler(ctx, tClient, args)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		jsonData, err := redactor.Marshal(description)
+		if err != nil {
+			return mcp.NewToolResultError("Failed to marshal workflow description"), nil
+		}
+		return mcp.NewToolResultText(string(jsonData)), nil
+	})
+
+	// Define the list_workflows tool schema
+	listWorkflowsTool := mcp.NewTool("list_workflows",
+		mcp.WithDescription("List workflows matching a Temporal visibility query. Returns workflow IDs, run IDs, types, status, start/close times and search attributes, one page at a time."),
+		mcp.WithString("query", mcp.Description("Visibility query, e.g. \"WorkflowType='Order' AND ExecutionStatus='Running'\". Omit to list all workflows.")),
+		mcp.WithNumber("page_size", mcp.Description("Maximum number of workflows to return (default 50, max 1000)")),
+		mcp.WithString("next_page_token", mcp.Description("Token from a previous list_workflows response to fetch the next page")),
+	)
+
+	s.AddTool(listWorkflowsTool, func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args := handler.ListWorkflowsArgs{
+			Query:         req.GetString("query", ""),
+			PageSize:      req.GetInt("page_size", 0),
+			NextPageToken: req.GetString("next_page_token", ""),
+		}
+		workflows, err := handler.ListWorkflowsHandler(ctx, tClient, args)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		jsonData, err := redactor.Marshal(workflows)
+		if err != nil {
+			return mcp.NewToolResultError("Failed to marshal workflows"), nil
+		}
+		return mcp.NewToolResultText(string(jsonData)), nil
+	})
+
+	// Define the workflow_tree tool schema
+	workflowTreeTool := mcp.NewTool("workflow_tree",
+		mcp.WithDescription("Explore a workflow's child workflows recursively, returning a tree with the status and failure of each node and the failed workflows where failures originate. Use this to find which child in a fan-out actually broke."),
+		mcp.WithString("workflow_id", mcp.Required(), mcp.Description("The ID of the workflow at the root of the tree")),
+		mcp.WithString("run_id", mcp.Description("Optional run ID of the root workflow")),
+		mcp.WithNumber("max_depth", mcp.Description("How many levels of children to fetch (default 3, max 10)")),
+		mcp.WithNumber("max_nodes", mcp.Description("Maximum number of workflows to fetch (default 200)")),
+	)
+
+	s.AddTool(workflowTreeTool, func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		workflowID, err := req.RequireString("workflow_id")
+		if err != nil {
+			return mcp.NewToolResultError("Missing required workflow_id"), nil
+		}
+		args := handler.WorkflowTreeArgs{
+			WorkflowID: workflowID,
+			RunID:      req.GetString("run_id", ""),
+			MaxDepth:   req.GetInt("max_depth", 0),
+			MaxNodes:   req.GetInt("max_nodes", 0),
+		}
+		tree, err := handler.WorkflowTreeHandler(ctx, tClient, args)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		jsonData, err := redactor.Marshal(tree)
+		if err != nil {
+			return mcp.NewToolResultError("Failed to marshal workflow tree"), nil
+		}
+		return mcp.NewToolResultText(string(jsonData)), nil
+	})
+
+	// Define the workflow_stack_trace tool schema
+	stackTraceTool := mcp.NewTool("workflow_stack_trace",
+		mcp.WithDescription("Get the current stack trace of a workflow's coroutines using the built-in __stack_trace query. Use this to see where a stuck running workflow is blocked. Reports when no worker is polling the workflow's task queue."),
+		mcp.WithString("workflow_id", mcp.Required(), mcp.Description("The ID of the workflow")),
+		mcp.WithString("run_id", mcp.Description("Optional run ID of the workflow")),
+	)
+
+	s.AddTool(stackTraceTool, func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		workflowID, err := req.RequireString("workflow_id")
+		if err != nil {
+			return mcp.NewToolResultError("Missing required workflow_id"), nil
+		}
+		args := handler.WorkflowStackTraceArgs{WorkflowID: workflowID, RunID: req.GetString("run_id", "")}
+		stackTrace, err := handler.WorkflowStackTraceHandler(ctx, tClient, args)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		jsonData, err := redactor.Marshal(stackTrace)
+		if err != nil {
+			return mcp.NewToolResultError("Failed to marshal stack trace"), nil
+		}
+		return mcp.NewToolResultText(string(jsonData)), nil
+	})
+
+	// Define the query_workflow tool schema
+	queryWorkflowTool := mcp.NewTool("query_workflow",
+		mcp.WithDescription("Run a query handler defined by a workflow to read its business state. Leave query_type empty to list the workflow's query, signal and update handlers with their descriptions."),
+		mcp.WithString("workflow_id", mcp.Required(), mcp.Description("The ID of the workflow to query")),
+		mcp.WithString("run_id", mcp.Description("Optional run ID of the workflow")),
+		mcp.WithString("query_type", mcp.Description("The query to run. Leave empty to list the available handlers")),
+		mcp.WithString("args", mcp.Description("JSON array of arguments to pass to the query, e.g. [\"order-1\", 2]")),
+		mcp.WithString("reject_condition", mcp.Description("Reject the query instead of running it when the workflow is not open or did not complete cleanly (default none)"),
+			mcp.Enum(handler.QueryRejectNone, handler.QueryRejectNotOpen, handler.QueryRejectNotCompletedCleanly)),
+	)
+
+	s.AddTool(queryWorkflowTool, func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		workflowID, err := req.RequireString("workflow_id")
+		if err != nil {
+			return mcp.NewToolResultError("Missing required workflow_id"), nil
+		}
+		args := handler.QueryWorkflowArgs{
+			WorkflowID:      workflowID,
+			RunID:           req.GetString("run_id", ""),
+			QueryType:       req.GetString("query_type", ""),
+			Args:            req.GetString("args", ""),
+			RejectCondition: req.GetString("reject_condition", ""),
+		}
+		queryResult, err := handler.QueryWorkflowHandler(ctx, tClient, args)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		jsonData, err := redactor.Marshal(queryResult)
+		if err != nil {
+			return mcp.NewToolResultError("Failed to marshal query result"), nil
+		}
+		return mcp.NewToolResultText(string(jsonData)), nil
+	})
+
+	// Define the list_schedules tool schema
+	listSchedulesTool := mcp.NewTool("list_schedules",
+		mcp.WithDescription("List the schedules in the namespace with their spec, paused state and next action time."),
+		mcp.WithNumber("page_size", mcp.Description("Maximum number of schedules to return (default 50)")),
+	)
+
+	s.AddTool(listSchedulesTool, func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args := handler.ListSchedulesArgs{
+			PageSize: req.GetInt("page_size", 0),
+		}
+		result, err := handler.ListSchedulesHandler(ctx, tClient, args)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		jsonData, err := redactor.Marshal(result)
+		if err != nil {
+			return mcp.NewToolResultError("Failed to marshal schedules"), nil
+		}
+		return mcp.NewToolResultText(string(jsonData)), nil
+	})
+
+	// Define the describe_schedule tool schema
+	describeScheduleTool := mcp.NewTool("describe_schedule",
+		mcp.WithDescription("Describe a schedule: its spec, policies, state, recent actions, next run times and the workflows it started that are still running."),
+		mcp.WithString("schedule_id", mcp.Required(), mcp.Description("The ID of the schedule to describe")),
+	)
+
+	s.AddTool(describeScheduleTool, func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		scheduleID, err := req.RequireString("schedule_id")
+		if err != nil {
+			return mcp.NewToolResultError("Missing required schedule_id"), nil
+		}
+		result, err := handler.DescribeScheduleHandler(ctx, tClient, handler.DescribeScheduleArgs{ScheduleID: scheduleID})
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		jsonData, err := redactor.Marshal(result)
+		if err != nil {
+			return mcp.NewToolResultError("Failed to marshal schedule"), nil
+		}
+		return mcp.NewToolResultText(string(jsonData)), nil
+	})
+
+	// Define the task_queue_status tool schema
+	taskQueueStatusTool := mcp.NewTool("task_queue_status",
+		mcp.WithDescription("Check whether workers are polling a task queue. Describes its workflow and activity queues with each poller's identity, last access time and rate, the backlog where the server reports it, and flags queues with no recent pollers. Give a workflow_id to check that workflow's task queue."),
+		mcp.WithString("task_queue", mcp.Description("The task queue to check")),
+		mcp.WithString("workflow_id", mcp.Description("Check the task queue of this workflow instead of naming one")),
+		mcp.WithString("run_id", mcp.Description("Optional run ID of the workflow")),
+	)
+
+	s.AddTool(taskQueueStatusTool, func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args := handler.TaskQueueStatusArgs{
+			TaskQueue:  req.GetString("task_queue", ""),
+			WorkflowID: req.GetString("workflow_id", ""),
+			RunID:      req.GetString("run_id", ""),
+			Namespace:  cfg.Namespace,
+		}
+		result, err := handler.TaskQueueStatusHandler(ctx, tClient, args)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		jsonData, err := redactor.Marshal(result)
+		if err != nil {
+			return mcp.NewToolResultError("Failed to marshal task queue status"), nil
+		}
+		return mcp.NewToolResultText(string(jsonData)), nil
+	})
+
+	// Define the stuck_workflows tool schema
+	stuckWorkflowsTool := mcp.NewTool("stuck_workflows",
+		mcp.WithDescription("Find running workflows that are stuck without having failed: activities retrying many times, workflow tasks failing repeatedly, workflow tasks waiting with no worker polling, no new events for a long time, or timers set far in the future. Each hit lists the reasons."),
+		mcp.WithString("workflow_type", mcp.Description("Only consider workflows of this type")),
+		mcp.WithString("query", mcp.Description("Additional visibility query clause to narrow the running workflows scanned")),
+		mcp.WithNumber("max_workflows", mcp.Description("Maximum number of running workflows to scan (default 200)")),
+		mcp.WithString("idle_for", mcp.Description("Duration such as '1h' without new history events after which a workflow counts as making no progress (default 1h)")),
+		mcp.WithString("timer_horizon", mcp.Description("Duration such as '24h'; open timers firing later than this are reported (default 24h)")),
+		mcp.WithNumber("min_activity_attempts", mcp.Description("Report pending activities on this attempt or later (default 5)")),
+		mcp.WithNumber("min_workflow_task_failures", mcp.Description("Report workflows whose workflow task has failed this many times in a row (default 3)")),
+	)
+
+	s.AddTool(stuckWorkflowsTool, func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args := handler.StuckWorkflowsArgs{
+			WorkflowType:            req.GetString("workflow_type", ""),
+			Query:                   req.GetString("query", ""),
+			MaxWorkflows:            req.GetInt("max_workflows", 0),
+			IdleFor:                 req.GetString("idle_for", ""),
+			TimerHorizon:            req.GetString("timer_horizon", ""),
+			MinActivityAttempts:     req.GetInt("min_activity_attempts", 0),
+			MinWorkflowTaskFailures: req.GetInt("min_workflow_task_failures", 0),
+			Namespace:               cfg.Namespace,
+		}
+		result, err := handler.StuckWorkflowsHandler(ctx, tClient, args)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		jsonData, err := redactor.Marshal(result)
+		if err != nil {
+			return mcp.NewToolResultError("Failed to marshal stuck workflows"), nil
+		}
+		return mcp.NewToolResultText(string(jsonData)), nil
+	})
+
+	// Tools that change workflows are only offered in write mode
+	if *writeMode {
+		// Define the signal_workflow tool schema
+		signalWorkflowTool := mcp.NewTool("signal_workflow",
+			mcp.WithDescription("Send a signal to a workflow. Returns the workflow's state afterwards."),
+			mcp.WithDestructiveHintAnnotation(true),
+			mcp.WithString("workflow_id", mcp.Required(), mcp.Description("The ID of the workflow to signal")),
+			mcp.WithString("run_id", mcp.Description("Optional run ID of the workflow")),
+			mcp.WithString("signal_name", mcp.Required(), mcp.Description("The name of the signal to send")),
+			mcp.WithString("args", mcp.Description("JSON array of arguments to send with the signal")),
+			mcp.WithString("reason", mcp.Required(), mcp.Description("Why the signal is being sent, recorded as the identity in the workflow history")),
+		)
+
+		s.AddTool(signalWorkflowTool, func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			workflowID, err := req.RequireString("workflow_id")
+			if err != nil {
+				return mcp.NewToolResultError("Missing required workflow_id"), nil
+			}
+			args := handler.SignalWorkflowArgs{
+				WorkflowID: workflowID,
+				RunID:      req.GetString("run_id", ""),
+				SignalName: req.GetString("signal_name", ""),
+				Args:       req.GetString("args", ""),
+				Reason:     req.GetString("reason", ""),
+				Namespace:  cfg.Namespace,
+			}
+			result, err := handler.SignalWorkflowHandler(ctx, tClient, args)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			jsonData, err := redactor.Marshal(result)
+			if err != nil {
+				return mcp.NewToolResultError("Failed to marshal signal result"), nil
+			}
+			return mcp.NewToolResultText(string(jsonData)), nil
+		})
+
+		// Define the cancel_workflow tool schema
+		cancelWorkflowTool := mcp.NewTool("cancel_workflow",
+			mcp.WithDescription("Request cancellation of a workflow, letting its code clean up. Returns the workflow's state afterwards."),
+			mcp.WithDestructiveHintAnnotation(true),
+			mcp.WithString("workflow_id", mcp.Required(), mcp.Description("The ID of the workflow to cancel")),
+			mcp.WithString("run_id", mcp.Description("Optional run ID of the workflow")),
+			mcp.WithString("reason", mcp.Required(), mcp.Description("Why the workflow is being canceled, recorded in the workflow history")),
+		)
+
+		s.AddTool(cancelWorkflowTool, func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			workflowID, err := req.RequireString("workflow_id")
+			if err != nil {
+				return mcp.NewToolResultError("Missing required workflow_id"), nil
+			}
+			args := handler.CancelWorkflowArgs{
+				WorkflowID: workflowID,
+				RunID:      req.GetString("run_id", ""),
+				Reason:     req.GetString("reason", ""),
+				Namespace:  cfg.Namespace,
+			}
+			result, err := handler.CancelWorkflowHandler(ctx, tClient, args)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			jsonData, err := redactor.Marshal(result)
+			if err != nil {
+				return mcp.NewToolResultError("Failed to marshal cancel result"), nil
+			}
+			return mcp.NewToolResultText(string(jsonData)), nil
+		})
+
+		// Define the terminate_workflow tool schema
+		terminateWorkflowTool := mcp.NewTool("terminate_workflow",
+			mcp.WithDescription("Terminate a workflow immediately without running any of its cleanup code. Prefer cancel_workflow unless the workflow cannot handle cancellation. Returns the workflow's state afterwards."),
+			mcp.WithDestructiveHintAnnotation(true),
+			mcp.WithString("workflow_id", mcp.Required(), mcp.Description("The ID of the workflow to terminate")),
+			mcp.WithString("run_id", mcp.Description("Optional run ID of the workflow")),
+			mcp.WithString("reason", mcp.Required(), mcp.Description("Why the workflow is being terminated, recorded in the workflow history")),
+		)
+
+		s.AddTool(terminateWorkflowTool, func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			workflowID, err := req.RequireString("workflow_id")
+			if err != nil {
+				return mcp.NewToolResultError("Missing required workflow_id"), nil
+			}
+			args := handler.TerminateWorkflowArgs{
+				WorkflowID: workflowID,
+				RunID:      req.GetString("run_id", ""),
+				Reason:     req.GetString("reason", ""),
+				Namespace:  cfg.Namespace,
+			}
+			result, err := handler.TerminateWorkflowHandler(ctx, tClient, args)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			jsonData, err := redactor.Marshal(result)
+			if err != nil {
+				return mcp.NewToolResultError("Failed to marshal terminate result"), nil
+			}
+			return mcp.NewToolResultText(string(jsonData)), nil
+		})
+
+		// Define the reset_workflow tool schema
+		resetWorkflowTool := mcp.NewTool("reset_workflow",
+			mcp.WithDescription("Reset a workflow to an earlier workflow task, discarding the events after it and running the workflow from there in a new run. Leave reset_type empty to list the reset points, and use dry_run to see which events a reset would discard. Returns the new run's state."),
+			mcp.WithDestructiveHintAnnotation(true),
+			mcp.WithString("workflow_id", mcp.Required(), mcp.Description("The ID of the workflow to reset")),
+			mcp.WithString("run_id", mcp.Description("Optional run ID of the workflow")),
+			mcp.WithString("reset_type", mcp.Description("Where to reset to. Leave empty to list the reset points"),
+				mcp.Enum(handler.ResetTypeFirstWorkflowTask, handler.ResetTypeLastWorkflowTask, handler.ResetTypeBuildID, handler.ResetTypeEventID)),
+			mcp.WithNumber("event_id", mcp.Description("With reset_type event_id, reset to before this event (to the last workflow task completed at or before it)")),
+			mcp.WithString("build_id", mcp.Description("With reset_type build_id, reset to before this build first completed a workflow task")),
+			mcp.WithBoolean("skip_signal_reapply", mcp.Description("Drop signals received after the reset point instead of reapplying them to the new run")),
+			mcp.WithBoolean("dry_run", mcp.Description("Explain what the reset would discard without resetting")),
+			mcp.WithString("reason", mcp.Description("Why the workflow is being reset, recorded in the workflow history. Required unless listing reset points or doing a dry run")),
+		)
+
+		s.AddTool(resetWorkflowTool, func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			workflowID, err := req.RequireString("workflow_id")
+			if err != nil {
+				return mcp.NewToolResultError("Missing required workflow_id"), nil
+			}
+			args := handler.ResetWorkflowArgs{
+				WorkflowID:        workflowID,
+				RunID:             req.GetString("run_id", ""),
+				ResetType:         req.GetString("reset_type", ""),
+				EventID:           int64(req.GetInt("event_id", 0)),
+				BuildID:           req.GetString("build_id", ""),
+				SkipSignalReapply: req.GetBool("skip_signal_reapply", false),
+				DryRun:            req.GetBool("dry_run", false),
+				Reason:            req.GetString("reason", ""),
+				Namespace:         cfg.Namespace,
+			}
+			result, err := handler.ResetWorkflowHandler(ctx, tClient, args)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			jsonData, err := redactor.Marshal(result)
+			if err != nil {
+				return mcp.NewToolResultError("Failed to marshal reset result"), nil
+			}
+			return mcp.NewToolResultText(string(jsonData)), nil
+		})
+
+		// Define the batch_operation tool schema
+		batchOperationTool := mcp.NewTool("batch_operation",
+			mcp.WithDescription("Signal, cancel or terminate every workflow matching a visibility query with a server-side batch job, or reset each of them (at most 100) in turn. Always run with dry_run first to see how many workflows match and a sample of them."),
+			mcp.WithDestructiveHintAnnotation(true),
+			mcp.WithString("query", mcp.Required(), mcp.Description("Visibility query selecting the workflows, e.g. WorkflowType='Order' AND ExecutionStatus='Running'")),
+			mcp.WithString("operation", mcp.Required(), mcp.Description("What to do to each matched workflow"),
+				mcp.Enum(handler.BatchOperationSignal, handler.BatchOperationCancel, handler.BatchOperationTerminate, handler.BatchOperationReset)),
+			mcp.WithString("signal_name", mcp.Description("With operation signal, the signal to send")),
+			mcp.WithString("args", mcp.Description("With operation signal, JSON array of arguments to send with the signal")),
+			mcp.WithString("reset_type", mcp.Description("With operation reset, where to reset each workflow to"),
+				mcp.Enum(handler.ResetTypeFirstWorkflowTask, handler.ResetTypeLastWorkflowTask, handler.ResetTypeBuildID)),
+			mcp.WithString("build_id", mcp.Description("With reset_type build_id, reset to before this build first completed a workflow task")),
+			mcp.WithBoolean("skip_signal_reapply", mcp.Description("With operation reset, drop signals received after the reset point instead of reapplying them")),
+			mcp.WithString("reason", mcp.Description("Why the operation is being run, recorded with the batch job and in each workflow's history. Required unless doing a dry run")),
+			mcp.WithBoolean("dry_run", mcp.Description("Only count the matched workflows and show a sample, without starting the batch job")),
+			mcp.WithNumber("max_workflows", mcp.Description("Refuse to start the job if more workflows than this match the query")),
+		)
+
+		s.AddTool(batchOperationTool, func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			query, err := req.RequireString("query")
+			if err != nil {
+				return mcp.NewToolResultError("Missing required query"), nil
+			}
+			args := handler.BatchOperationArgs{
+				Query:             query,
+				Operation:         req.GetString("operation", ""),
+				SignalName:        req.GetString("signal_name", ""),
+				Args:              req.GetString("args", ""),
+				ResetType:         req.GetString("reset_type", ""),
+				BuildID:           req.GetString("build_id", ""),
+				SkipSignalReapply: req.GetBool("skip_signal_reapply", false),
+				Reason:            req.GetString("reason", ""),
+				DryRun:            req.GetBool("dry_run", false),
+				MaxWorkflows:      int64(req.GetInt("max_workflows", 0)),
+				Namespace:         cfg.Namespace,
+			}
+			result, err := handler.BatchOperationHandler(ctx, tClient, args)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			jsonData, err := redactor.Marshal(result)
+			if err != nil {
+				return mcp.NewToolResultError("Failed to marshal batch operation"), nil
+			}
+			return mcp.NewToolResultText(string(jsonData)), nil
+		})
+
+		// Define the batch_jobs tool schema
+		batchJobsTool := mcp.NewTool("batch_jobs",
+			mcp.WithDescription("List batch jobs, describe one's progress, or stop one. Only stop changes anything; list and describe are read-only."),
+			mcp.WithDestructiveHintAnnotation(true),
+			mcp.WithString("action", mcp.Required(), mcp.Description("What to do"), mcp.Enum(handler.BatchJobsList, handler.BatchJobsDescribe, handler.BatchJobsStop)),
+			mcp.WithString("job_id", mcp.Description("The batch job to describe or stop")),
+			mcp.WithString("reason", mcp.Description("Why the job is being stopped; required to stop a job")),
+			mcp.WithNumber("page_size", mcp.Description("Maximum number of jobs to list")),
+			mcp.WithString("next_page_token", mcp.Description("Token from a previous list to fetch the next page")),
+		)
+
+		s.AddTool(batchJobsTool, func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			action, err := req.RequireString("action")
+			if err != nil {
+				return mcp.NewToolResultError("Missing required action"), nil
+			}
+			args := handler.BatchJobsArgs{
+				Action:        action,
+				JobID:         req.GetString("job_id", ""),
+				Reason:        req.GetString("reason", ""),
+				PageSize:      req.GetInt("page_size", 0),
+				NextPageToken: req.GetString("next_page_token", ""),
+				Namespace:     cfg.Namespace,
+			}
+			result, err := handler.BatchJobsHandler(ctx, tClient, args)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			jsonData, err := redactor.Marshal(result)
+			if err != nil {
+				return mcp.NewToolResultError("Failed to marshal batch jobs"), nil
+			}
+			return mcp.NewToolResultText(string(jsonData)), nil
+		})
+
+		// Define the schedule_action tool schema
+		scheduleActionTool := mcp.NewTool("schedule_action",
+			mcp.WithDescription("Pause or unpause a schedule, trigger its action immediately, or backfill the actions it would have taken over a time range. Returns the schedule's state afterwards."),
+			mcp.WithDestructiveHintAnnotation(true),
+			mcp.WithString("schedule_id", mcp.Required(), mcp.Description("The ID of the schedule")),
+			mcp.WithString("action", mcp.Required(), mcp.Description("What to do to the schedule"),
+				mcp.Enum(handler.ScheduleActionPause, handler.ScheduleActionUnpause, handler.ScheduleActionTrigger, handler.ScheduleActionBackfill)),
+			mcp.WithString("reason", mcp.Required(), mcp.Description("Why the change is being made, recorded as the schedule's note when pausing or unpausing")),
+			mcp.WithString("overlap", mcp.Description("With trigger or backfill, the overlap policy to use instead of the schedule's own"),
+				mcp.Enum("skip", "buffer_one", "buffer_all", "cancel_other", "terminate_other", "allow_all")),
+			mcp.WithString("start_time", mcp.Description("With backfill, the start of the time range in RFC3339 format")),
+			mcp.WithString("end_time", mcp.Description("With backfill, the end of the time range in RFC3339 format")),
+		)
+
+		s.AddTool(scheduleActionTool, func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			scheduleID, err := req.RequireString("schedule_id")
+			if err != nil {
+				return mcp.NewToolResultError("Missing required schedule_id"), nil
+			}
+			args := handler.ScheduleActionArgs{
+				ScheduleID: scheduleID,
+				Action:     req.GetString("action", ""),
+				Reason:     req.GetString("reason", ""),
+				Overlap:    req.GetString("overlap", ""),
+				StartTime:  req.GetString("start_time", ""),
+				EndTime:    req.GetString("end_time", ""),
+			}
+			result, err := handler.ScheduleActionHandler(ctx, tClient, args)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			jsonData, err := redactor.Marshal(result)
+			if err != nil {
+				return mcp.NewToolResultError("Failed to marshal schedule action result"), nil
+			}
+			return mcp.NewToolResultText(string(jsonData)), nil
+		})
+	}
+
 	// Add instructions as a resource
 	resource := mcp.NewResource("file://instructions", "instructions", mcp.WithMIMEType("text/plain"))
 	s.AddResource(resource, func(ctx context.Context, req mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
@@ -87,7 +685,13 @@ func main() {
 		}, nil
 	})
 
-	if err := server.ServeStdio(s); err != nil {
+	// Add workflows and their histories as resource templates
+	addWorkflowResources(s, tClient, redactor, cfg.Namespace, string(instructions))
+
+	router := &subscriptionRouter{watchers: watchers, sessions: sessions, namespace: cfg.Namespace}
+	err = serve(s, router, *transport, cfg.Port)
+	watchers.Close()
+	if err != nil {
 		log.Fatalf("Server error: %v", err)
 	}
 }
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/robryanx/mcp-temporal-server/internal/handler"
+	"github.com/robryanx/mcp-temporal-server/internal/redact"
+	"github.com/robryanx/mcp-temporal-server/internal/watch"
+	"go.temporal.io/sdk/client"
+)
+
+const (
+	resourceScheme = "temporal://"
+
+	workflowResourceTemplate = "temporal://{namespace}/workflows/{workflow_id}"
+	historyResourceTemplate  = "temporal://{namespace}/workflows/{workflow_id}/runs/{run_id}/history"
+)
+
+// addWorkflowResources registers resource templates that let clients pin a
+// workflow's description or a run's history into their context. The contents
+// are the same JSON the describe_workflow and workflow_history tools return.
+func addWorkflowResources(s *server.MCPServer, tClient client.Client, redactor *redact.Redactor, namespace, instructions string) {
+	workflowTemplate := mcp.NewResourceTemplate(workflowResourceTemplate, "workflow",
+		mcp.WithTemplateDescription("A workflow's current state, as returned by describe_workflow"),
+		mcp.WithTemplateMIMEType("application/json"),
+	)
+	s.AddResourceTemplate(workflowTemplate, func(ctx context.Context, req mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+		if err := checkResourceNamespace(req, namespace); err != nil {
+			return nil, err
+		}
+		args := handler.DescribeWorkflowArgs{WorkflowID: resourceArgument(req, "workflow_id")}
+		description, err := handler.DescribeWorkflowHandler(ctx, tClient, args)
+		if err != nil {
+			return nil, err
+		}
+		return jsonResource(req, redactor, description)
+	})
+
+	historyTemplate := mcp.NewResourceTemplate(historyResourceTemplate, "workflow_history",
+		mcp.WithTemplateDescription("The first page of a workflow run's history, as returned by workflow_history"),
+		mcp.WithTemplateMIMEType("application/json"),
+	)
+	s.AddResourceTemplate(historyTemplate, func(ctx context.Context, req mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+		if err := checkResourceNamespace(req, namespace); err != nil {
+			return nil, err
+		}
+		args := handler.WorkflowHistoryArgs{
+			WorkflowID: resourceArgument(req, "workflow_id"),
+			RunID:      resourceArgument(req, "run_id"),
+		}
+		history, err := handler.GetWorkflowHistoryHandler(ctx, tClient, args)
+		if err != nil {
+			return nil, err
+		}
+		history.Instructions = instructions
+		return jsonResource(req, redactor, history)
+	})
+}
+
+// checkResourceNamespace rejects resources in namespaces other than the one
+// the Temporal client is connected to.
+func checkResourceNamespace(req mcp.ReadResourceRequest, namespace string) error {
+	if requested := resourceArgument(req, "namespace"); requested != namespace {
+		return fmt.Errorf("namespace %q is not served; this server is connected to %q", requested, namespace)
+	}
+	return nil
+}
+
+// parseWorkflowResourceURI returns the workflow run a URI matching either
+// resource template refers to. Workflow URIs follow the current run.
+func parseWorkflowResourceURI(uri, namespace string) (watch.Target, error) {
+	rest, ok := strings.CutPrefix(uri, resourceScheme)
+	if !ok {
+		return watch.Target{}, fmt.Errorf("unknown resource %q", uri)
+	}
+	parts := strings.Split(rest, "/")
+	for i, part := range parts {
+		unescaped, err := url.PathUnescape(part)
+		if err != nil {
+			return watch.Target{}, fmt.Errorf("invalid resource %q: %w", uri, err)
+		}
+		parts[i] = unescaped
+	}
+
+	var target watch.Target
+	switch {
+	case len(parts) == 3 && parts[1] == "workflows":
+		target.WorkflowID = parts[2]
+	case len(parts) == 6 && parts[1] == "workflows" && parts[3] == "runs" && parts[5] == "history":
+		target.WorkflowID = parts[2]
+		target.RunID = parts[4]
+	default:
+		return watch.Target{}, fmt.Errorf("unknown resource %q", uri)
+	}
+	if target.WorkflowID == "" {
+		return watch.Target{}, fmt.Errorf("resource %q has no workflow ID", uri)
+	}
+	if parts[0] != namespace {
+		return watch.Target{}, fmt.Errorf("namespace %q is not served; this server is connected to %q", parts[0], namespace)
+	}
+	return target, nil
+}
+
+// resourceArgument returns a variable matched from the resource URI. The
+// server passes template variables as string slices.
+func resourceArgument(req mcp.ReadResourceRequest, name string) string {
+	switch v := req.Params.Arguments[name].(type) {
+	case string:
+		return v
+	case []string:
+		if len(v) > 0 {
+			return v[0]
+		}
+	}
+	return ""
+}
+
+func jsonResource(req mcp.ReadResourceRequest, redactor *redact.Redactor, v interface{}) ([]mcp.ResourceContents, error) {
+	jsonData, err := redactor.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal resource: %w", err)
+	}
+	return []mcp.ResourceContents{
+		mcp.TextResourceContents{
+			URI:      req.Params.URI,
+			MIMEType: "application/json",
+			Text:     string(jsonData),
+		},
+	}, nil
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/robryanx/mcp-temporal-server/internal/config"
+	"github.com/robryanx/mcp-temporal-server/internal/redact"
+	"github.com/robryanx/mcp-temporal-server/internal/watch"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.temporal.io/api/common/v1"
+	"go.temporal.io/api/enums/v1"
+	"go.temporal.io/api/history/v1"
+	"go.temporal.io/api/workflow/v1"
+	"go.temporal.io/api/workflowservice/v1"
+	"go.temporal.io/sdk/client"
+	"go.temporal.io/sdk/mocks"
+)
+
+// describeRunning describes any workflow as running.
+func describeRunning(_ context.Context, workflowID, runID string) *workflowservice.DescribeWorkflowExecutionResponse {
+	if runID == "" {
+		runID = "run-current"
+	}
+	return &workflowservice.DescribeWorkflowExecutionResponse{
+		WorkflowExecutionInfo: &workflow.WorkflowExecutionInfo{
+			Execution: &common.WorkflowExecution{WorkflowId: workflowID, RunId: runID},
+			Type:      &common.WorkflowType{Name: "Order"},
+			Status:    enums.WORKFLOW_EXECUTION_STATUS_RUNNING,
+		},
+	}
+}
+
+// resourceClient serves running workflows with empty histories.
+func resourceClient(t *testing.T) *mocks.Client {
+	c := &mocks.Client{}
+	c.Test(t)
+	c.On("DescribeWorkflowExecution", mock.Anything, mock.Anything, mock.Anything).Return(describeRunning, nil)
+	c.On("GetWorkflowHistory", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(emptyIterator{})
+	return c
+}
+
+type emptyIterator struct{}
+
+func (emptyIterator) HasNext() bool                        { return false }
+func (emptyIterator) Next() (*history.HistoryEvent, error) { return nil, nil }
+
+func newResourceServer(t *testing.T, c client.Client) *server.MCPServer {
+	t.Helper()
+	redactor, err := redact.New(config.RedactionConfig{})
+	require.NoError(t, err)
+	s := server.NewMCPServer("test", "0.0.0", server.WithResourceCapabilities(true, false))
+	addWorkflowResources(s, c, redactor, "default", "")
+	return s
+}
+
+// readResource reads uri through the server and returns the resource text or
+// the error message.
+func readResource(t *testing.T, s *server.MCPServer, uri string) (string, string) {
+	t.Helper()
+	raw := fmt.Sprintf(`{"jsonrpc":"2.0","id":1,"method":"resources/read","params":{"uri":%q}}`, uri)
+	switch resp := s.HandleMessage(context.Background(), json.RawMessage(raw)).(type) {
+	case mcp.JSONRPCResponse:
+		result, ok := resp.Result.(mcp.ReadResourceResult)
+		require.True(t, ok, "unexpected result %T", resp.Result)
+		require.Len(t, result.Contents, 1)
+		text, ok := result.Contents[0].(mcp.TextResourceContents)
+		require.True(t, ok)
+		assert.Equal(t, uri, text.URI)
+		return text.Text, ""
+	case mcp.JSONRPCError:
+		return "", resp.Error.Message
+	default:
+		t.Fatalf("unexpected response %T", resp)
+		return "", ""
+	}
+}
+
+func TestWorkflowResources(t *testing.T) {
+	t.Run("Workflow template describes the workflow", func(t *testing.T) {
+		c := resourceClient(t)
+		text, errMsg := readResource(t, newResourceServer(t, c), "temporal://default/workflows/order-1")
+
+		require.Empty(t, errMsg)
+		assert.Contains(t, text, `"order-1"`)
+		c.AssertNumberOfCalls(t, "DescribeWorkflowExecution", 1)
+		c.AssertCalled(t, "DescribeWorkflowExecution", mock.Anything, "order-1", "")
+	})
+
+	t.Run("History template reads the run in the URI", func(t *testing.T) {
+		c := resourceClient(t)
+		_, errMsg := readResource(t, newResourceServer(t, c), "temporal://default/workflows/order-1/runs/run-7/history")
+
+		require.Empty(t, errMsg)
+		c.AssertNumberOfCalls(t, "GetWorkflowHistory", 1)
+		c.AssertCalled(t, "GetWorkflowHistory", mock.Anything, "order-1", "run-7", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Decodes percent-encoded workflow IDs", func(t *testing.T) {
+		c := resourceClient(t)
+		_, errMsg := readResource(t, newResourceServer(t, c), "temporal://default/workflows/orders%2F2024%2F1")
+
+		require.Empty(t, errMsg)
+		c.AssertCalled(t, "DescribeWorkflowExecution", mock.Anything, "orders/2024/1", "")
+	})
+
+	t.Run("Rejects other namespaces", func(t *testing.T) {
+		c := resourceClient(t)
+		_, errMsg := readResource(t, newResourceServer(t, c), "temporal://billing/workflows/order-1")
+
+		assert.Contains(t, errMsg, `namespace "billing" is not served; this server is connected to "default"`)
+		c.AssertNotCalled(t, "DescribeWorkflowExecution", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestResourceArgument(t *testing.T) {
+	var req mcp.ReadResourceRequest
+	req.Params.Arguments = map[string]interface{}{
+		"workflow_id": []string{"order-1"},
+		"run_id":      "run-1",
+		"namespace":   []string{},
+	}
+
+	assert.Equal(t, "order-1", resourceArgument(req, "workflow_id"))
+	assert.Equal(t, "run-1", resourceArgument(req, "run_id"))
+	assert.Equal(t, "", resourceArgument(req, "namespace"))
+	assert.Equal(t, "", resourceArgument(req, "missing"))
+}
+
+func TestCheckResourceNamespace(t *testing.T) {
+	var req mcp.ReadResourceRequest
+	req.Params.Arguments = map[string]interface{}{"namespace": []string{"default"}}
+	assert.NoError(t, checkResourceNamespace(req, "default"))
+
+	req.Params.Arguments = map[string]interface{}{"namespace": []string{"billing"}}
+	assert.EqualError(t, checkResourceNamespace(req, "default"), `namespace "billing" is not served; this server is connected to "default"`)
+}
+
+func TestParseWorkflowResourceURI(t *testing.T) {
+	tests := []struct {
+		uri    string
+		target watch.Target
+		err    string
+	}{
+		{uri: "temporal://default/workflows/order-1", target: watch.Target{WorkflowID: "order-1"}},
+		{uri: "temporal://default/workflows/order-1/runs/run-1/history", target: watch.Target{WorkflowID: "order-1", RunID: "run-1"}},
+		{uri: "temporal://default/workflows/orders%2F2024%2F1", target: watch.Target{WorkflowID: "orders/2024/1"}},
+		{uri: "temporal://default/workflows/order%201/runs/run-1/history", target: watch.Target{WorkflowID: "order 1", RunID: "run-1"}},
+		{uri: "temporal://billing/workflows/order-1", err: `namespace "billing" is not served; this server is connected to "default"`},
+		{uri: "temporal://default/workflows/", err: "has no workflow ID"},
+		{uri: "temporal://default/workflows/order%zz", err: "invalid resource"},
+		{uri: "temporal://default/schedules/nightly", err: "unknown resource"},
+		{uri: "https://default/workflows/order-1", err: "unknown resource"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.uri, func(t *testing.T) {
+			target, err := parseWorkflowResourceURI(tt.uri, "default")
+			if tt.err != "" {
+				assert.ErrorContains(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.target, target)
+		})
+	}
+}
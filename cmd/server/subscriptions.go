@@ -0,0 +1,226 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sync"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/robryanx/mcp-temporal-server/internal/watch"
+)
+
+const (
+	methodResourcesSubscribe   = "resources/subscribe"
+	methodResourcesUnsubscribe = "resources/unsubscribe"
+
+	// stdioSessionID is the session mcp-go's stdio server registers for its
+	// only client.
+	stdioSessionID = "stdio"
+
+	sessionIDHeader = "Mcp-Session-Id"
+)
+
+// subscriptionRouter answers resources/subscribe and resources/unsubscribe.
+// mcp-go advertises resource subscriptions but does not route these methods,
+// so each transport passes incoming messages through the router before the
+// MCP server sees them.
+type subscriptionRouter struct {
+	watchers  *watch.Manager
+	sessions  *sessionRegistry
+	namespace string
+}
+
+// sessionRegistry tracks the sessions registered with the MCP server. Only
+// they can be sent notifications: a Streamable HTTP session is registered
+// while its GET stream is open, an SSE session while its event stream is.
+type sessionRegistry struct {
+	mu  sync.Mutex
+	ids map[string]bool
+}
+
+func newSessionRegistry(hooks *server.Hooks) *sessionRegistry {
+	r := &sessionRegistry{ids: make(map[string]bool)}
+	hooks.AddOnRegisterSession(func(_ context.Context, session server.ClientSession) {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.ids[session.SessionID()] = true
+	})
+	hooks.AddOnUnregisterSession(func(_ context.Context, session server.ClientSession) {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		delete(r.ids, session.SessionID())
+	})
+	return r
+}
+
+func (r *sessionRegistry) registered(sessionID string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.ids[sessionID]
+}
+
+type subscriptionRequest struct {
+	ID     *mcp.RequestId `json:"id"`
+	Method string         `json:"method"`
+	Params struct {
+		URI string `json:"uri"`
+	} `json:"params"`
+}
+
+// handle answers raw if it is a subscription request from the session. It
+// reports false for every other message, which the MCP server should handle.
+func (r *subscriptionRouter) handle(ctx context.Context, sessionID string, raw []byte) (mcp.JSONRPCMessage, bool) {
+	var req subscriptionRequest
+	if err := json.Unmarshal(raw, &req); err != nil || req.ID == nil {
+		return nil, false
+	}
+	if req.Method != methodResourcesSubscribe && req.Method != methodResourcesUnsubscribe {
+		return nil, false
+	}
+
+	if sessionID == "" {
+		return mcp.NewJSONRPCError(*req.ID, mcp.INVALID_REQUEST, "subscriptions need a session", nil), true
+	}
+	if req.Method == methodResourcesUnsubscribe {
+		r.watchers.Unsubscribe(sessionID, req.Params.URI)
+		return emptyResponse(*req.ID), true
+	}
+	if !r.sessions.registered(sessionID) {
+		return mcp.NewJSONRPCError(*req.ID, mcp.INVALID_REQUEST,
+			fmt.Sprintf("session %s has no open stream to receive resource updates on", sessionID), nil), true
+	}
+
+	target, err := parseWorkflowResourceURI(req.Params.URI, r.namespace)
+	if err == nil {
+		err = r.watchers.Subscribe(ctx, sessionID, req.Params.URI, target)
+	}
+	if err != nil {
+		return mcp.NewJSONRPCError(*req.ID, mcp.INVALID_PARAMS, fmt.Sprintf("failed to subscribe to %s: %v", req.Params.URI, err), nil), true
+	}
+	return emptyResponse(*req.ID), true
+}
+
+// filterStdio reads newline-delimited messages from in, answers subscription
+// requests on out and passes everything else through the returned reader.
+func (r *subscriptionRouter) filterStdio(ctx context.Context, in io.Reader, out io.Writer) io.Reader {
+	pr, pw := io.Pipe()
+	go func() {
+		reader := bufio.NewReader(in)
+		for {
+			line, err := reader.ReadBytes('\n')
+			if len(line) > 0 {
+				if resp, ok := r.handle(ctx, stdioSessionID, line); ok {
+					if writeErr := writeMessage(out, resp); writeErr != nil {
+						log.Printf("Failed to write subscription response: %v", writeErr)
+					}
+				} else if _, writeErr := pw.Write(line); writeErr != nil {
+					return
+				}
+			}
+			if err != nil {
+				pw.CloseWithError(err)
+				return
+			}
+		}
+	}()
+	return pr
+}
+
+func writeMessage(w io.Writer, msg mcp.JSONRPCMessage) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("failed to marshal message: %w", err)
+	}
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
+
+// lockedWriter serializes writes so that subscription responses and the
+// server's own messages are never interleaved on stdout.
+type lockedWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (l *lockedWriter) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.w.Write(p)
+}
+
+// wrapStreamable answers subscription requests posted to the Streamable HTTP
+// endpoint and stops a session's watchers when the client deletes it.
+func (r *subscriptionRouter) wrapStreamable(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		sessionID := req.Header.Get(sessionIDHeader)
+		switch req.Method {
+		case http.MethodPost:
+			body, ok := readBody(w, req)
+			if !ok {
+				return
+			}
+			if resp, handled := r.handle(req.Context(), sessionID, body); handled {
+				w.Header().Set("Content-Type", "application/json")
+				if err := json.NewEncoder(w).Encode(resp); err != nil {
+					log.Printf("Failed to write subscription response: %v", err)
+				}
+				return
+			}
+		case http.MethodDelete:
+			defer r.watchers.CloseSession(sessionID)
+		}
+		next.ServeHTTP(w, req)
+	})
+}
+
+// wrapSSE answers subscription requests posted to the SSE message endpoint.
+// As with other requests, the response goes out on the session's event
+// stream.
+func (r *subscriptionRouter) wrapSSE(sse *server.SSEServer, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodPost {
+			next.ServeHTTP(w, req)
+			return
+		}
+		body, ok := readBody(w, req)
+		if !ok {
+			return
+		}
+		sessionID := req.URL.Query().Get("sessionId")
+		resp, handled := r.handle(req.Context(), sessionID, body)
+		if !handled {
+			next.ServeHTTP(w, req)
+			return
+		}
+		if err := sse.SendEventToSession(sessionID, resp); err != nil {
+			// The session is gone, so nothing it subscribed to can be
+			// delivered.
+			r.watchers.CloseSession(sessionID)
+			http.Error(w, fmt.Sprintf("Failed to send response: %v", err), http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusAccepted)
+	})
+}
+
+// readBody reads the request body and puts it back for the next handler.
+func readBody(w http.ResponseWriter, req *http.Request) ([]byte, bool) {
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		http.Error(w, "Failed to read request body", http.StatusBadRequest)
+		return nil, false
+	}
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	return body, true
+}
+
+func emptyResponse(id mcp.RequestId) mcp.JSONRPCResponse {
+	return mcp.JSONRPCResponse{JSONRPC: mcp.JSONRPC_VERSION, ID: id, Result: mcp.EmptyResult{}}
+}
@@ -0,0 +1,215 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/robryanx/mcp-temporal-server/internal/watch"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.temporal.io/api/enums/v1"
+	"go.temporal.io/api/history/v1"
+	"go.temporal.io/sdk/client"
+	"go.temporal.io/sdk/mocks"
+)
+
+const (
+	subscribeRequest   = `{"jsonrpc":"2.0","id":7,"method":"resources/subscribe","params":{"uri":"temporal://default/workflows/order-1"}}`
+	unsubscribeRequest = `{"jsonrpc":"2.0","id":8,"method":"resources/unsubscribe","params":{"uri":"temporal://default/workflows/order-1"}}`
+	pingRequest        = `{"jsonrpc":"2.0","id":9,"method":"ping"}`
+)
+
+// watchClient serves running workflows whose history never grows, so their
+// watchers run until stopped.
+func watchClient(t *testing.T) *mocks.Client {
+	c := &mocks.Client{}
+	c.Test(t)
+	c.On("DescribeWorkflowExecution", mock.Anything, mock.Anything, mock.Anything).Return(describeRunning, nil)
+	c.On("GetWorkflowHistory", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Return(func(ctx context.Context, _, _ string, _ bool, _ enums.HistoryEventFilterType) client.HistoryEventIterator {
+			return &blockingIterator{ctx: ctx}
+		})
+	return c
+}
+
+type blockingIterator struct {
+	ctx context.Context
+	err error
+}
+
+func (it *blockingIterator) HasNext() bool {
+	if it.err == nil {
+		<-it.ctx.Done()
+		it.err = it.ctx.Err()
+	}
+	return true
+}
+
+func (it *blockingIterator) Next() (*history.HistoryEvent, error) {
+	return nil, it.err
+}
+
+// newTestRouter returns a router whose manager runs at most one watcher, so
+// a second session can only subscribe once the first one's watcher stops.
+func newTestRouter(t *testing.T) (*subscriptionRouter, *server.MCPServer) {
+	t.Helper()
+	hooks := &server.Hooks{}
+	s := server.NewMCPServer("test", "0.0.0", server.WithResourceCapabilities(true, false), server.WithHooks(hooks))
+	watchers := watch.NewManager(watchClient(t), func(string, string) error { return nil }, watch.Limits{MaxWatchers: 1})
+	t.Cleanup(watchers.Close)
+	return &subscriptionRouter{watchers: watchers, sessions: newSessionRegistry(hooks), namespace: "default"}, s
+}
+
+// register marks sessions as registered with the MCP server.
+func register(r *subscriptionRouter, sessionIDs ...string) {
+	for _, id := range sessionIDs {
+		r.sessions.ids[id] = true
+	}
+}
+
+// rpcError returns the error message of a JSON-RPC response, or "" if it
+// succeeded.
+func rpcError(t *testing.T, data []byte) string {
+	t.Helper()
+	var resp struct {
+		ID     int             `json:"id"`
+		Result json.RawMessage `json:"result"`
+		Error  *struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	require.NoError(t, json.Unmarshal(data, &resp), string(data))
+	if resp.Error != nil {
+		return resp.Error.Message
+	}
+	return ""
+}
+
+func handled(t *testing.T, r *subscriptionRouter, sessionID, raw string) string {
+	t.Helper()
+	msg, ok := r.handle(context.Background(), sessionID, []byte(raw))
+	require.True(t, ok)
+	data, err := json.Marshal(msg)
+	require.NoError(t, err)
+	return rpcError(t, data)
+}
+
+func TestSubscriptionRouter(t *testing.T) {
+	r, _ := newTestRouter(t)
+	register(r, "s1")
+
+	_, ok := r.handle(context.Background(), "s1", []byte(pingRequest))
+	assert.False(t, ok, "other requests go to the MCP server")
+	_, ok = r.handle(context.Background(), "s1", []byte(`not json`))
+	assert.False(t, ok)
+
+	assert.Equal(t, "subscriptions need a session", handled(t, r, "", subscribeRequest))
+	assert.Equal(t, "session s2 has no open stream to receive resource updates on", handled(t, r, "s2", subscribeRequest))
+	assert.Contains(t, handled(t, r, "s1", strings.Replace(subscribeRequest, "default", "billing", 1)), `namespace "billing" is not served`)
+
+	assert.Empty(t, handled(t, r, "s1", subscribeRequest))
+	assert.Empty(t, handled(t, r, "s1", unsubscribeRequest))
+	assert.Empty(t, handled(t, r, "s2", unsubscribeRequest), "unsubscribing needs no stream")
+}
+
+func TestFilterStdio(t *testing.T) {
+	r, _ := newTestRouter(t)
+	register(r, stdioSessionID)
+	var out bytes.Buffer
+	in := strings.NewReader(pingRequest + "\n" + subscribeRequest + "\n" + pingRequest)
+
+	passed, err := io.ReadAll(r.filterStdio(context.Background(), in, &out))
+
+	require.NoError(t, err)
+	assert.Equal(t, pingRequest+"\n"+pingRequest, string(passed))
+	lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+	require.Len(t, lines, 1)
+	assert.Contains(t, lines[0], `"id":7`)
+	assert.Empty(t, rpcError(t, []byte(lines[0])))
+}
+
+func TestWrapStreamable(t *testing.T) {
+	r, _ := newTestRouter(t)
+	register(r, "s1", "s2")
+	var forwarded []string
+	h := r.wrapStreamable(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, _ := io.ReadAll(req.Body)
+		forwarded = append(forwarded, req.Method+" "+string(body))
+	}))
+	send := func(method, sessionID, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/mcp", strings.NewReader(body))
+		req.Header.Set(sessionIDHeader, sessionID)
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := send(http.MethodPost, "s1", subscribeRequest)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+	assert.Empty(t, rpcError(t, rec.Body.Bytes()))
+
+	send(http.MethodPost, "s1", pingRequest)
+	assert.Equal(t, []string{"POST " + pingRequest}, forwarded, "the body is passed on intact")
+
+	rec = send(http.MethodPost, "s2", subscribeRequest)
+	assert.Contains(t, rpcError(t, rec.Body.Bytes()), "the server watches at most 1")
+
+	send(http.MethodDelete, "s1", "")
+	assert.Equal(t, "DELETE ", forwarded[1])
+	rec = send(http.MethodPost, "s2", subscribeRequest)
+	assert.Empty(t, rpcError(t, rec.Body.Bytes()), "deleting s1 stopped its watcher")
+}
+
+func TestWrapSSE(t *testing.T) {
+	r, s := newTestRouter(t)
+	mux := http.NewServeMux()
+	sse := server.NewSSEServer(s, server.WithUseFullURLForMessageEndpoint(false))
+	mux.Handle("/sse", sse.SSEHandler())
+	mux.Handle("/message", r.wrapSSE(sse, sse.MessageHandler()))
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, ts.URL+"/sse", nil)
+	require.NoError(t, err)
+	stream, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer stream.Body.Close()
+	events := bufio.NewScanner(stream.Body)
+	nextData := func() string {
+		t.Helper()
+		for events.Scan() {
+			if data, ok := strings.CutPrefix(events.Text(), "data: "); ok {
+				return data
+			}
+		}
+		t.Fatal("event stream ended")
+		return ""
+	}
+	endpoint := nextData()
+	require.Contains(t, endpoint, "sessionId=")
+
+	resp, err := http.Post(ts.URL+endpoint, "application/json", strings.NewReader(subscribeRequest))
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusAccepted, resp.StatusCode)
+	reply := nextData()
+	assert.Contains(t, reply, `"id":7`)
+	assert.Empty(t, rpcError(t, []byte(reply)))
+
+	resp, err = http.Post(ts.URL+"/message?sessionId=gone", "application/json", strings.NewReader(subscribeRequest))
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode, "the response cannot be delivered to an unknown session")
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/robryanx/mcp-temporal-server/internal/config"
+)
+
+const shutdownTimeout = 10 * time.Second
+
+// serve runs the MCP server over the requested transport until it exits or
+// the process receives an interrupt.
+func serve(s *server.MCPServer, router *subscriptionRouter, transport, port string) error {
+	switch transport {
+	case config.TransportStdio:
+		return serveStdio(s, router)
+	case config.TransportHTTP:
+		return serveHTTP(s, router, port)
+	default:
+		return fmt.Errorf("unknown transport %q (expected %q or %q)", transport, config.TransportStdio, config.TransportHTTP)
+	}
+}
+
+// serveStdio serves the server's only client on stdin and stdout.
+func serveStdio(s *server.MCPServer, router *subscriptionRouter) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	out := &lockedWriter{w: os.Stdout}
+	in := router.filterStdio(ctx, os.Stdin, out)
+	return server.NewStdioServer(s).Listen(ctx, in, out)
+}
+
+// serveHTTP exposes the server over Streamable HTTP at /mcp and over the
+// legacy SSE transport at /sse and /message, all on the same port.
+func serveHTTP(s *server.MCPServer, router *subscriptionRouter, port string) error {
+	mux := http.NewServeMux()
+	httpServer := &http.Server{
+		Addr:    ":" + port,
+		Handler: mux,
+	}
+
+	streamable := server.NewStreamableHTTPServer(s)
+	sse := server.NewSSEServer(s,
+		server.WithHTTPServer(httpServer),
+		server.WithUseFullURLForMessageEndpoint(false),
+	)
+
+	mux.Handle("/mcp", router.wrapStreamable(streamable))
+	mux.Handle("/sse", sse.SSEHandler())
+	mux.Handle("/message", router.wrapSSE(sse, sse.MessageHandler()))
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	errCh := make(chan error, 1)
+	go func() {
+		log.Printf("Serving MCP over HTTP on :%s (streamable: /mcp, sse: /sse)", port)
+		errCh <- httpServer.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errCh:
+		if errors.Is(err, http.ErrServerClosed) {
+			return nil
+		}
+		return err
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	// The SSE server owns httpServer, so this closes the long-lived SSE
+	// streams before shutting the listener down.
+	return sse.Shutdown(shutdownCtx)
+}
@@ -22,7 +22,10 @@ func main() {
 	failures := flag.Float64("failures", 0.1, "Percentage (0.0-1.0) of workflows that should fail when input is 'random'")
 	flag.Parse()
 
-	cfg := config.Load()
+	cfg, err := config.Load()
+	if err != nil {
+		panic(err)
+	}
 
 	c, err := temporal.NewTemporalClient(cfg)
 	if err != nil {
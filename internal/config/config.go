@@ -1,19 +1,113 @@
 package config
 
-import "os"
+import (
+    "errors"
+    "fmt"
+    "os"
+    "strconv"
+    "strings"
+)
+
+const (
+    TransportStdio = "stdio"
+    TransportHTTP  = "http"
+)
 
 type Config struct {
     TemporalAddress string
     Namespace       string
     Port            string
+    Transport       string
+    TLS             TLSConfig
+    APIKey          string
+    APIKeyFile      string
+    Codec           CodecConfig
+    Redaction       RedactionConfig
+    WriteMode       bool
+    Watch           WatchConfig
+}
+
+// TLSConfig describes how to secure the connection to the Temporal frontend.
+// Each piece of PEM material can be supplied either as a file path or inline
+// via the matching *Data field; setting both is a startup error.
+type TLSConfig struct {
+    Enabled                 bool
+    CertFile                string
+    CertData                string
+    KeyFile                 string
+    KeyData                 string
+    CAFile                  string
+    CAData                  string
+    ServerName              string
+    DisableHostVerification bool
+}
+
+// CodecConfig points at a Temporal remote codec server used to decode
+// encrypted or compressed payloads before they are shown.
+type CodecConfig struct {
+    Endpoint string
+    Auth     string
+    Headers  map[string]string
+}
+
+// RedactionConfig lists the rules used to mask sensitive values in tool
+// responses. Paths are JSONPath expressions such as $..password, and Patterns
+// names built-in patterns (email, card, token). File points at a JSON file
+// with further paths and named custom regular expressions. REDACT_PATHS is
+// split on semicolons because JSONPath expressions may contain commas.
+type RedactionConfig struct {
+    Paths    []string
+    Patterns []string
+    File     string
 }
 
-func Load() Config {
-    return Config{
+// WatchConfig limits the history watchers started for resource
+// subscriptions, across the server and per client session.
+type WatchConfig struct {
+    MaxWatchers   int
+    MaxPerSession int
+}
+
+// Load reads the configuration from the environment. It fails when a boolean
+// or integer variable is set to a value that cannot be parsed, rather than
+// quietly using the default.
+func Load() (Config, error) {
+    var env envParser
+    cfg := Config{
         TemporalAddress: getenv("TEMPORAL_ADDRESS", "localhost:7233"),
         Namespace:       getenv("TEMPORAL_NAMESPACE", "default"),
         Port:            getenv("PORT", "8080"),
+        Transport:       getenv("MCP_TRANSPORT", TransportStdio),
+        TLS: TLSConfig{
+            Enabled:                 env.bool("TEMPORAL_TLS", false),
+            CertFile:                os.Getenv("TEMPORAL_TLS_CERT"),
+            CertData:                os.Getenv("TEMPORAL_TLS_CERT_DATA"),
+            KeyFile:                 os.Getenv("TEMPORAL_TLS_KEY"),
+            KeyData:                 os.Getenv("TEMPORAL_TLS_KEY_DATA"),
+            CAFile:                  os.Getenv("TEMPORAL_TLS_CA"),
+            CAData:                  os.Getenv("TEMPORAL_TLS_CA_DATA"),
+            ServerName:              os.Getenv("TEMPORAL_TLS_SERVER_NAME"),
+            DisableHostVerification: env.bool("TEMPORAL_TLS_DISABLE_HOST_VERIFICATION", false),
+        },
+        APIKey:     os.Getenv("TEMPORAL_API_KEY"),
+        APIKeyFile: os.Getenv("TEMPORAL_API_KEY_FILE"),
+        Codec: CodecConfig{
+            Endpoint: os.Getenv("TEMPORAL_CODEC_ENDPOINT"),
+            Auth:     os.Getenv("TEMPORAL_CODEC_AUTH"),
+            Headers:  getenvMap("TEMPORAL_CODEC_HEADERS"),
+        },
+        Redaction: RedactionConfig{
+            Paths:    getenvList("REDACT_PATHS", ";"),
+            Patterns: getenvList("REDACT_PATTERNS", ","),
+            File:     os.Getenv("REDACT_CONFIG_FILE"),
+        },
+        WriteMode: env.bool("MCP_WRITE_MODE", false),
+        Watch: WatchConfig{
+            MaxWatchers:   env.int("MCP_MAX_WATCHERS", 100),
+            MaxPerSession: env.int("MCP_MAX_WATCHERS_PER_SESSION", 10),
+        },
     }
+    return cfg, errors.Join(env.errs...)
 }
 
 func getenv(key, fallback string) string {
@@ -21,4 +115,64 @@ func getenv(key, fallback string) string {
         return v
     }
     return fallback
+}
+
+// envParser reads typed variables, collecting an error for each one that is
+// set but invalid.
+type envParser struct {
+    errs []error
+}
+
+func (p *envParser) bool(key string, fallback bool) bool {
+    v := os.Getenv(key)
+    if v == "" {
+        return fallback
+    }
+    b, err := strconv.ParseBool(v)
+    if err != nil {
+        p.errs = append(p.errs, fmt.Errorf("invalid %s %q: expected true or false", key, v))
+        return fallback
+    }
+    return b
+}
+
+// int parses a non-negative integer.
+func (p *envParser) int(key string, fallback int) int {
+    v := os.Getenv(key)
+    if v == "" {
+        return fallback
+    }
+    n, err := strconv.Atoi(v)
+    if err != nil || n < 0 {
+        p.errs = append(p.errs, fmt.Errorf("invalid %s %q: expected a non-negative integer", key, v))
+        return fallback
+    }
+    return n
+}
+
+// getenvList parses a list separated by sep, dropping empty entries.
+func getenvList(key, sep string) []string {
+    var out []string
+    for _, v := range strings.Split(os.Getenv(key), sep) {
+        if v = strings.TrimSpace(v); v != "" {
+            out = append(out, v)
+        }
+    }
+    return out
+}
+
+// getenvMap parses a comma-separated list of key=value pairs.
+func getenvMap(key string) map[string]string {
+    v := os.Getenv(key)
+    if v == "" {
+        return nil
+    }
+    out := make(map[string]string)
+    for _, pair := range strings.Split(v, ",") {
+        k, val, ok := strings.Cut(pair, "=")
+        if k = strings.TrimSpace(k); ok && k != "" {
+            out[k] = strings.TrimSpace(val)
+        }
+    }
+    return out
 }
\ No newline at end of file
@@ -0,0 +1,47 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoad(t *testing.T) {
+	t.Setenv("TEMPORAL_TLS", "1")
+	t.Setenv("MCP_MAX_WATCHERS", "5")
+
+	cfg, err := Load()
+
+	require.NoError(t, err)
+	assert.True(t, cfg.TLS.Enabled)
+	assert.False(t, cfg.WriteMode)
+	assert.Equal(t, 5, cfg.Watch.MaxWatchers)
+	assert.Equal(t, 10, cfg.Watch.MaxPerSession)
+}
+
+func TestLoad_InvalidValues(t *testing.T) {
+	t.Setenv("TEMPORAL_TLS", "ture")
+	t.Setenv("MCP_WRITE_MODE", "yes")
+	t.Setenv("MCP_MAX_WATCHERS", "-1")
+	t.Setenv("MCP_MAX_WATCHERS_PER_SESSION", "ten")
+
+	_, err := Load()
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `invalid TEMPORAL_TLS "ture": expected true or false`)
+	assert.Contains(t, err.Error(), `invalid MCP_WRITE_MODE "yes": expected true or false`)
+	assert.Contains(t, err.Error(), `invalid MCP_MAX_WATCHERS "-1": expected a non-negative integer`)
+	assert.Contains(t, err.Error(), `invalid MCP_MAX_WATCHERS_PER_SESSION "ten": expected a non-negative integer`)
+}
+
+func TestLoad_RedactPaths(t *testing.T) {
+	t.Setenv("REDACT_PATHS", "$..password; $['a,b'] ;")
+	t.Setenv("REDACT_PATTERNS", "email,card")
+
+	cfg, err := Load()
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"$..password", "$['a,b']"}, cfg.Redaction.Paths)
+	assert.Equal(t, []string{"email", "card"}, cfg.Redaction.Patterns)
+}
@@ -0,0 +1,369 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"go.temporal.io/api/enums/v1"
+	"go.temporal.io/api/history/v1"
+	"go.temporal.io/api/workflow/v1"
+	"go.temporal.io/sdk/client"
+)
+
+const (
+	HistoryViewEvents     = "events"
+	HistoryViewActivities = "activities"
+)
+
+// ActivityExecution folds the lifecycle events of one activity invocation
+// into a single record. Only the last attempt is recorded in history, so
+// QueuedTime runs from scheduling to the start of the last attempt and
+// includes earlier attempts and retry backoff when Attempts is above one.
+// For activities that are still running, the attempt count and last failure
+// come from the workflow's pending activities.
+type ActivityExecution struct {
+	ScheduledEventID int64                   `json:"scheduled_event_id"`
+	ActivityID       string                  `json:"activity_id"`
+	ActivityType     string                  `json:"activity_type"`
+	TaskQueue        string                  `json:"task_queue,omitempty"`
+	Status           string                  `json:"status"`
+	Attempts         int32                   `json:"attempts,omitempty"`
+	Input            map[string]interface{}  `json:"input,omitempty"`
+	Result           map[string]interface{}  `json:"result,omitempty"`
+	Failure          *Failure                `json:"failure,omitempty"`
+	LastFailure      *Failure                `json:"last_failure,omitempty"`
+	RetryPolicy      map[string]interface{}  `json:"retry_policy,omitempty"`
+	ScheduledTime    string                  `json:"scheduled_time,omitempty"`
+	StartedTime      string                  `json:"started_time,omitempty"`
+	ClosedTime       string                  `json:"closed_time,omitempty"`
+	QueuedTime       string                  `json:"queued_time,omitempty"`
+	RunTime          string                  `json:"run_time,omitempty"`
+	RetryState       string                  `json:"retry_state,omitempty"`
+	MaximumAttempts  int32                   `json:"maximum_attempts,omitempty"`
+	Timeline         []ActivityTimelineEntry `json:"timeline"`
+	RetryTimeline    []ActivityAttempt       `json:"retry_timeline,omitempty"`
+
+	scheduled *time.Time
+	started   *time.Time
+	matched   bool
+	pending   *workflow.PendingActivityInfo
+}
+
+// ActivityTimelineEntry is one lifecycle event of an activity execution.
+type ActivityTimelineEntry struct {
+	EventID int64  `json:"event_id"`
+	Type    string `json:"type"`
+	Time    string `json:"time,omitempty"`
+	Attempt int32  `json:"attempt,omitempty"`
+}
+
+// ActivityAttempt is one attempt in an activity's retry timeline. The server
+// keeps the latest attempt and the failure of the one before it; earlier
+// attempts are only counted in Attempts.
+type ActivityAttempt struct {
+	Attempt     int32    `json:"attempt"`
+	Outcome     string   `json:"outcome"`
+	StartedTime string   `json:"started_time,omitempty"`
+	Failure     *Failure `json:"failure,omitempty"`
+	RetryState  string   `json:"retry_state,omitempty"`
+}
+
+// activityFolder builds ActivityExecution records from a stream of history
+// events.
+type activityFolder struct {
+	filter  *historyFilter
+	first   int64
+	last    int64
+	records map[int64]*ActivityExecution
+}
+
+// add folds e into its activity's record. Records are created from the
+// scheduled event whatever the filter, so that later events can be paired
+// with them; a record is returned if any of its events matches the filter.
+func (f *activityFolder) add(e *history.HistoryEvent) {
+	if attrs := e.GetActivityTaskScheduledEventAttributes(); attrs != nil {
+		id := e.GetEventId()
+		if id < f.first || (f.last > 0 && id > f.last) {
+			return
+		}
+		record := &ActivityExecution{
+			ScheduledEventID: id,
+			ActivityID:       attrs.GetActivityId(),
+			ActivityType:     attrs.GetActivityType().GetName(),
+			TaskQueue:        attrs.GetTaskQueue().GetName(),
+			Status:           "Scheduled",
+			RetryPolicy:      formatRetryPolicy(attrs.GetRetryPolicy()),
+			ScheduledTime:    formatTime(e.GetEventTime()),
+			scheduled:        e.GetEventTime(),
+		}
+		if attrs.GetInput() != nil {
+			record.Input = make(map[string]interface{})
+			extractJSON(attrs.GetInput(), &record.Input)
+		}
+		record.addTimeline(e, 0)
+		record.matched = f.filter.match(e)
+		f.records[id] = record
+		return
+	}
+
+	record := f.records[activityScheduledEventID(e)]
+	if record == nil {
+		return
+	}
+	if f.filter.match(e) {
+		record.matched = true
+	}
+	switch {
+	case e.GetActivityTaskStartedEventAttributes() != nil:
+		attrs := e.GetActivityTaskStartedEventAttributes()
+		record.Status = "Started"
+		record.Attempts = attrs.GetAttempt()
+		record.LastFailure = formatFailure(attrs.GetLastFailure())
+		record.StartedTime = formatTime(e.GetEventTime())
+		record.started = e.GetEventTime()
+		record.QueuedTime = durationBetween(record.scheduled, record.started)
+		record.addTimeline(e, attrs.GetAttempt())
+	case e.GetActivityTaskCompletedEventAttributes() != nil:
+		attrs := e.GetActivityTaskCompletedEventAttributes()
+		if attrs.GetResult() != nil {
+			record.Result = make(map[string]interface{})
+			extractJSON(attrs.GetResult(), &record.Result)
+		}
+		record.close(e, "Completed")
+	case e.GetActivityTaskFailedEventAttributes() != nil:
+		attrs := e.GetActivityTaskFailedEventAttributes()
+		record.Failure = formatFailure(attrs.GetFailure())
+		record.RetryState = enumName(attrs.GetRetryState())
+		record.close(e, "Failed")
+	case e.GetActivityTaskTimedOutEventAttributes() != nil:
+		attrs := e.GetActivityTaskTimedOutEventAttributes()
+		record.Failure = formatFailure(attrs.GetFailure())
+		record.RetryState = enumName(attrs.GetRetryState())
+		record.close(e, "TimedOut")
+	case e.GetActivityTaskCancelRequestedEventAttributes() != nil:
+		record.Status = "CancelRequested"
+		record.addTimeline(e, 0)
+	case e.GetActivityTaskCanceledEventAttributes() != nil:
+		record.close(e, "Canceled")
+	}
+}
+
+// activityScheduledEventID returns the scheduled event ID an activity
+// lifecycle event refers to, or zero for other events.
+func activityScheduledEventID(e *history.HistoryEvent) int64 {
+	switch {
+	case e.GetActivityTaskStartedEventAttributes() != nil:
+		return e.GetActivityTaskStartedEventAttributes().GetScheduledEventId()
+	case e.GetActivityTaskCompletedEventAttributes() != nil:
+		return e.GetActivityTaskCompletedEventAttributes().GetScheduledEventId()
+	case e.GetActivityTaskFailedEventAttributes() != nil:
+		return e.GetActivityTaskFailedEventAttributes().GetScheduledEventId()
+	case e.GetActivityTaskTimedOutEventAttributes() != nil:
+		return e.GetActivityTaskTimedOutEventAttributes().GetScheduledEventId()
+	case e.GetActivityTaskCancelRequestedEventAttributes() != nil:
+		return e.GetActivityTaskCancelRequestedEventAttributes().GetScheduledEventId()
+	case e.GetActivityTaskCanceledEventAttributes() != nil:
+		return e.GetActivityTaskCanceledEventAttributes().GetScheduledEventId()
+	}
+	return 0
+}
+
+// addPending fills in the state and attempts of activities that are still
+// running from the workflow's pending activities. With a retry policy the
+// server only records the started event once the activity closes, so the
+// history alone shows a running activity as scheduled.
+func (f *activityFolder) addPending(pending []*workflow.PendingActivityInfo) {
+	byID := make(map[string]*workflow.PendingActivityInfo, len(pending))
+	for _, p := range pending {
+		byID[p.GetActivityId()] = p
+	}
+	for _, record := range f.records {
+		p := byID[record.ActivityID]
+		if p == nil || record.ClosedTime != "" {
+			continue
+		}
+		switch p.GetState() {
+		case enums.PENDING_ACTIVITY_STATE_SCHEDULED:
+			record.Status = "Scheduled"
+		case enums.PENDING_ACTIVITY_STATE_STARTED:
+			record.Status = "Started"
+			if record.StartedTime == "" {
+				record.StartedTime = formatTime(p.GetLastStartedTime())
+			}
+		case enums.PENDING_ACTIVITY_STATE_CANCEL_REQUESTED:
+			record.Status = "CancelRequested"
+		}
+		record.Attempts = p.GetAttempt()
+		record.MaximumAttempts = p.GetMaximumAttempts()
+		if failure := formatFailure(p.GetLastFailure()); failure != nil {
+			record.LastFailure = failure
+		}
+		record.pending = p
+	}
+}
+
+// sorted returns the matching records in scheduling order, each with its
+// retry timeline.
+func (f *activityFolder) sorted() []*ActivityExecution {
+	out := make([]*ActivityExecution, 0, len(f.records))
+	for _, r := range f.records {
+		if !r.matched {
+			continue
+		}
+		r.RetryTimeline = r.retryTimeline()
+		out = append(out, r)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].ScheduledEventID < out[j].ScheduledEventID
+	})
+	return out
+}
+
+// retryTimeline lists the attempts the server still knows about: the one
+// before the latest, with its failure, and the latest with its outcome.
+func (r *ActivityExecution) retryTimeline() []ActivityAttempt {
+	attempt := r.Attempts
+	if attempt == 0 {
+		attempt = 1
+	}
+	latest := ActivityAttempt{Attempt: attempt, Outcome: r.Status, StartedTime: r.StartedTime, Failure: r.Failure, RetryState: r.RetryState}
+	var previousStarted string
+	if p := r.pending; p != nil {
+		latest.Outcome = enumName(p.GetState())
+		if p.GetState() == enums.PENDING_ACTIVITY_STATE_STARTED {
+			latest.StartedTime = formatTime(p.GetLastStartedTime())
+		} else {
+			// Waiting for the next attempt: the last start was the
+			// previous attempt's.
+			latest.StartedTime = ""
+			previousStarted = formatTime(p.GetLastStartedTime())
+		}
+	}
+
+	var timeline []ActivityAttempt
+	if attempt > 1 && r.LastFailure != nil {
+		timeline = append(timeline, ActivityAttempt{Attempt: attempt - 1, Outcome: "Failed", StartedTime: previousStarted, Failure: r.LastFailure})
+	}
+	return append(timeline, latest)
+}
+
+func (r *ActivityExecution) addTimeline(e *history.HistoryEvent, attempt int32) {
+	r.Timeline = append(r.Timeline, ActivityTimelineEntry{
+		EventID: e.GetEventId(),
+		Type:    e.GetEventType().String(),
+		Time:    formatTime(e.GetEventTime()),
+		Attempt: attempt,
+	})
+}
+
+func (r *ActivityExecution) close(e *history.HistoryEvent, status string) {
+	r.Status = status
+	r.ClosedTime = formatTime(e.GetEventTime())
+	r.RunTime = durationBetween(r.started, e.GetEventTime())
+	r.addTimeline(e, 0)
+}
+
+func durationBetween(from, to *time.Time) string {
+	if from == nil || to == nil {
+		return ""
+	}
+	return to.Sub(*from).String()
+}
+
+// getActivityView folds the history into activity executions and returns one
+// budgeted page of them. Cursors and event ID bounds refer to the scheduled
+// event ID of each execution.
+func getActivityView(ctx context.Context, temporalClient client.Client, args WorkflowHistoryArgs, runID string, cursor historyCursor, filter *historyFilter) (HistoryResponse, error) {
+	folder := &activityFolder{filter: filter, first: args.StartEventID, last: args.EndEventID, records: make(map[int64]*ActivityExecution)}
+
+	iter := temporalClient.GetWorkflowHistory(ctx, args.WorkflowID, runID, false, enums.HISTORY_EVENT_FILTER_TYPE_ALL_EVENT)
+	read := 0
+	for iter.HasNext() {
+		evt, err := iter.Next()
+		if err != nil {
+			return HistoryResponse{}, fmt.Errorf("failed reading history: %w", err)
+		}
+		read++
+		filter.observe(evt)
+		folder.add(evt)
+	}
+	open := false
+	for _, r := range folder.records {
+		if r.ClosedTime == "" {
+			open = true
+			break
+		}
+	}
+	if open {
+		desc, err := temporalClient.DescribeWorkflowExecution(ctx, args.WorkflowID, runID)
+		if err != nil {
+			return HistoryResponse{}, fmt.Errorf("failed to describe workflow: %w", err)
+		}
+		folder.addPending(desc.GetPendingActivities())
+	}
+
+	records := folder.sorted()
+	page := newHistoryPage[ActivityExecution](args)
+	elidedBefore, elidedAfter := 0, 0
+	if cursor.Before > 0 {
+		for _, r := range records {
+			if r.ScheduledEventID >= cursor.Before {
+				elidedAfter++
+				continue
+			}
+			size := jsonSize(r)
+			for !page.fits(size) {
+				page.shift()
+				elidedBefore++
+			}
+			page.push(*r, size)
+		}
+	} else {
+		for _, r := range records {
+			if r.ScheduledEventID <= cursor.After {
+				elidedBefore++
+				continue
+			}
+			if size := jsonSize(r); elidedAfter == 0 && page.fits(size) {
+				page.push(*r, size)
+				continue
+			}
+			elidedAfter++
+		}
+	}
+
+	resp := HistoryResponse{
+		WorkflowID: args.WorkflowID,
+		RunID:      runID,
+		Events:     []Event{},
+		Activities: page.items,
+	}
+	if n := len(page.items); n > 0 {
+		if elidedBefore > 0 {
+			resp.PrevCursor = encodeCursor(historyCursor{WorkflowID: args.WorkflowID, RunID: runID, View: HistoryViewActivities, Before: page.items[0].ScheduledEventID})
+		}
+		if elidedAfter > 0 {
+			resp.NextCursor = encodeCursor(historyCursor{WorkflowID: args.WorkflowID, RunID: runID, View: HistoryViewActivities, After: page.items[n-1].ScheduledEventID})
+		}
+	}
+
+	counts := make(map[string]int)
+	for _, r := range records {
+		counts[r.Status]++
+	}
+	resp.Summary = fmt.Sprintf("Workflow has %d events with %d activity executions (%d completed, %d failed, %d timed out, %d canceled, %d still running). Returning %d of them.",
+		read, len(records), counts["Completed"], counts["Failed"], counts["TimedOut"], counts["Canceled"],
+		counts["Scheduled"]+counts["Started"]+counts["CancelRequested"], len(page.items))
+	if elidedBefore > 0 {
+		resp.Summary += fmt.Sprintf(" %d earlier executions were elided (use prev_cursor).", elidedBefore)
+	}
+	if elidedAfter > 0 {
+		resp.Summary += fmt.Sprintf(" %d later executions were elided (use next_cursor).", elidedAfter)
+	}
+	if filter.active() {
+		resp.Summary += " Only activities matching the requested filters are included; pass the same filters with a cursor."
+	}
+	return resp, nil
+}
@@ -0,0 +1,142 @@
+package handler
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.temporal.io/api/common/v1"
+	"go.temporal.io/api/enums/v1"
+	"go.temporal.io/api/failure/v1"
+	"go.temporal.io/api/history/v1"
+	"go.temporal.io/api/workflow/v1"
+)
+
+func TestGetWorkflowHistoryHandler_ActivityView(t *testing.T) {
+	events := filterTestEvents()
+	events[1].GetActivityTaskScheduledEventAttributes().ActivityId = "2"
+	events[1].GetActivityTaskScheduledEventAttributes().Input = payloads(`{"amount":10}`)
+	events[3].GetActivityTaskStartedEventAttributes().Attempt = 3
+	events[3].GetActivityTaskStartedEventAttributes().LastFailure = &failure.Failure{Message: "gateway timeout"}
+	events[4].GetActivityTaskCompletedEventAttributes().Result = payloads(`"ok"`)
+	events[7].GetActivityTaskFailedEventAttributes().RetryState = enums.RETRY_STATE_MAXIMUM_ATTEMPTS_REACHED
+	later := events[7].GetEventTime().Add(time.Minute)
+	events = append(events, &history.HistoryEvent{
+		EventId: 9, EventTime: &later, EventType: enums.EVENT_TYPE_ACTIVITY_TASK_SCHEDULED,
+		Attributes: &history.HistoryEvent_ActivityTaskScheduledEventAttributes{ActivityTaskScheduledEventAttributes: &history.ActivityTaskScheduledEventAttributes{ActivityId: "9", ActivityType: &common.ActivityType{Name: "Notify"}}},
+	})
+	lastStarted := later.Add(time.Minute)
+	mockClient := newFakeClient(t, &fakeRun{workflowID: "wf-1", runID: "run-1", events: events, pendingActivities: []*workflow.PendingActivityInfo{{
+		ActivityId: "9", State: enums.PENDING_ACTIVITY_STATE_SCHEDULED, Attempt: 4, MaximumAttempts: 10,
+		LastStartedTime: &lastStarted, LastFailure: &failure.Failure{Message: "smtp down"},
+	}}})
+
+	result, err := GetWorkflowHistoryHandler(context.Background(), mockClient, WorkflowHistoryArgs{WorkflowID: "wf-1", View: HistoryViewActivities})
+
+	require.NoError(t, err)
+	assert.Empty(t, result.Events)
+	require.Len(t, result.Activities, 3)
+	assert.Equal(t, "Workflow has 9 events with 3 activity executions (1 completed, 1 failed, 0 timed out, 0 canceled, 1 still running). Returning 3 of them.", result.Summary)
+
+	charge := result.Activities[0]
+	assert.Equal(t, int64(2), charge.ScheduledEventID)
+	assert.Equal(t, "Charge", charge.ActivityType)
+	assert.Equal(t, "Completed", charge.Status)
+	assert.Equal(t, int32(3), charge.Attempts)
+	assert.Equal(t, map[string]interface{}{"amount": float64(10)}, charge.Input["input_part_0"])
+	assert.Equal(t, "ok", charge.Result["input_part_0"])
+	assert.Equal(t, "gateway timeout", charge.LastFailure.Message)
+	assert.Equal(t, "2m0s", charge.QueuedTime)
+	assert.Equal(t, "1m0s", charge.RunTime)
+	assert.Equal(t, []ActivityTimelineEntry{
+		{EventID: 2, Type: "ActivityTaskScheduled", Time: "2024-01-02T03:01:00Z"},
+		{EventID: 4, Type: "ActivityTaskStarted", Time: "2024-01-02T03:03:00Z", Attempt: 3},
+		{EventID: 5, Type: "ActivityTaskCompleted", Time: "2024-01-02T03:04:00Z"},
+	}, charge.Timeline)
+	assert.Equal(t, []ActivityAttempt{
+		{Attempt: 2, Outcome: "Failed", Failure: charge.LastFailure},
+		{Attempt: 3, Outcome: "Completed", StartedTime: "2024-01-02T03:03:00Z"},
+	}, charge.RetryTimeline)
+
+	ship := result.Activities[1]
+	assert.Equal(t, "Failed", ship.Status)
+	assert.Equal(t, "no stock", ship.Failure.Message)
+	assert.Equal(t, "MaximumAttemptsReached", ship.RetryState)
+	assert.Empty(t, ship.RunTime)
+	assert.Equal(t, []ActivityAttempt{{Attempt: 1, Outcome: "Failed", Failure: ship.Failure, RetryState: "MaximumAttemptsReached"}}, ship.RetryTimeline)
+
+	notify := result.Activities[2]
+	assert.Equal(t, "Scheduled", notify.Status)
+	assert.Equal(t, int32(4), notify.Attempts)
+	assert.Equal(t, int32(10), notify.MaximumAttempts)
+	assert.Equal(t, []ActivityAttempt{
+		{Attempt: 3, Outcome: "Failed", StartedTime: "2024-01-02T03:09:00Z", Failure: &Failure{Message: "smtp down"}},
+		{Attempt: 4, Outcome: "Scheduled"},
+	}, notify.RetryTimeline)
+}
+
+func TestGetWorkflowHistoryHandler_ActivityViewRunningAttempt(t *testing.T) {
+	scheduled := time.Date(2024, 1, 2, 3, 0, 0, 0, time.UTC)
+	lastStarted := scheduled.Add(5 * time.Minute)
+	events := []*history.HistoryEvent{{
+		EventId: 5, EventTime: &scheduled, EventType: enums.EVENT_TYPE_ACTIVITY_TASK_SCHEDULED,
+		Attributes: &history.HistoryEvent_ActivityTaskScheduledEventAttributes{ActivityTaskScheduledEventAttributes: &history.ActivityTaskScheduledEventAttributes{ActivityId: "5", ActivityType: &common.ActivityType{Name: "Charge"}}},
+	}}
+	mockClient := newFakeClient(t, &fakeRun{workflowID: "wf-1", runID: "run-1", events: events, pendingActivities: []*workflow.PendingActivityInfo{{
+		ActivityId: "5", State: enums.PENDING_ACTIVITY_STATE_STARTED, Attempt: 2, LastStartedTime: &lastStarted,
+	}}})
+
+	// With a retry policy the started event is only written when the
+	// activity closes, so only the pending activity shows it running.
+	result, err := GetWorkflowHistoryHandler(context.Background(), mockClient, WorkflowHistoryArgs{WorkflowID: "wf-1", View: HistoryViewActivities})
+
+	require.NoError(t, err)
+	require.Len(t, result.Activities, 1)
+	assert.Equal(t, "Started", result.Activities[0].Status)
+	assert.Equal(t, "2024-01-02T03:05:00Z", result.Activities[0].StartedTime)
+	assert.Contains(t, result.Summary, "1 still running")
+}
+
+func TestGetWorkflowHistoryHandler_ActivityViewTypeFilter(t *testing.T) {
+	mockClient := newFakeClient(t, &fakeRun{workflowID: "wf-1", runID: "run-1", events: filterTestEvents()})
+
+	// The scheduled events are left out by the filter but still pair the
+	// failure with its activity.
+	result, err := GetWorkflowHistoryHandler(context.Background(), mockClient, WorkflowHistoryArgs{WorkflowID: "wf-1", View: HistoryViewActivities, IncludeEventTypes: []string{"ActivityTaskFailed"}})
+
+	require.NoError(t, err)
+	require.Len(t, result.Activities, 1)
+	assert.Equal(t, "Ship", result.Activities[0].ActivityType)
+	assert.Equal(t, "Failed", result.Activities[0].Status)
+}
+
+func TestGetWorkflowHistoryHandler_ActivityViewPaging(t *testing.T) {
+	mockClient := newFakeClient(t, &fakeRun{workflowID: "wf-1", runID: "run-1", events: filterTestEvents()})
+	ctx := context.Background()
+
+	page1, err := GetWorkflowHistoryHandler(ctx, mockClient, WorkflowHistoryArgs{WorkflowID: "wf-1", View: HistoryViewActivities, MaxEvents: 1})
+	require.NoError(t, err)
+	require.Len(t, page1.Activities, 1)
+	assert.Equal(t, "Charge", page1.Activities[0].ActivityType)
+	assert.Equal(t, "run-1", page1.RunID)
+	cursor, err := decodeCursor(page1.NextCursor)
+	require.NoError(t, err)
+	assert.Equal(t, "run-1", cursor.RunID, "the run read, not the one in the started event")
+
+	page2, err := GetWorkflowHistoryHandler(ctx, mockClient, WorkflowHistoryArgs{WorkflowID: "wf-1", View: HistoryViewActivities, MaxEvents: 1, Cursor: page1.NextCursor})
+	require.NoError(t, err)
+	require.Len(t, page2.Activities, 1)
+	assert.Equal(t, "Ship", page2.Activities[0].ActivityType)
+	assert.Empty(t, page2.NextCursor)
+	assert.NotEmpty(t, page2.PrevCursor)
+
+	_, err = GetWorkflowHistoryHandler(ctx, mockClient, WorkflowHistoryArgs{WorkflowID: "wf-1", MaxEvents: 1, Cursor: page1.NextCursor})
+	assert.EqualError(t, err, "cursor was issued for the activities view, not events")
+
+	filtered, err := GetWorkflowHistoryHandler(ctx, mockClient, WorkflowHistoryArgs{WorkflowID: "wf-1", View: HistoryViewActivities, ActivityType: "Ship"})
+	require.NoError(t, err)
+	require.Len(t, filtered.Activities, 1)
+	assert.Equal(t, "Ship", filtered.Activities[0].ActivityType)
+}
@@ -0,0 +1,389 @@
+package handler
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"go.temporal.io/api/batch/v1"
+	"go.temporal.io/api/common/v1"
+	"go.temporal.io/api/workflowservice/v1"
+	"go.temporal.io/sdk/client"
+	"go.temporal.io/sdk/converter"
+)
+
+const (
+	BatchOperationSignal    = "signal"
+	BatchOperationCancel    = "cancel"
+	BatchOperationTerminate = "terminate"
+	BatchOperationReset     = "reset"
+
+	BatchJobsList     = "list"
+	BatchJobsDescribe = "describe"
+	BatchJobsStop     = "stop"
+)
+
+// batchPreviewSize is the number of matched workflows shown as a sample.
+const batchPreviewSize = 5
+
+// maxBatchResetWorkflows caps a batch reset. The server has no batch reset
+// job in the API version this server is built with, so each workflow is
+// reset in turn from here.
+const maxBatchResetWorkflows = 100
+
+type BatchOperationArgs struct {
+	Query             string `json:"query" jsonschema:"required,description=Visibility query selecting the workflows, e.g. WorkflowType='Order' AND ExecutionStatus='Running'"`
+	Operation         string `json:"operation" jsonschema:"required,enum=signal,enum=cancel,enum=terminate,enum=reset,description=What to do to each matched workflow"`
+	SignalName        string `json:"signal_name,omitempty" jsonschema:"description=With operation signal, the signal to send"`
+	Args              string `json:"args,omitempty" jsonschema:"description=With operation signal, JSON array of arguments to send with the signal"`
+	ResetType         string `json:"reset_type,omitempty" jsonschema:"enum=first_workflow_task,enum=last_workflow_task,enum=build_id,description=With operation reset, where to reset each workflow to"`
+	BuildID           string `json:"build_id,omitempty" jsonschema:"description=With reset_type build_id, reset to before this build first completed a workflow task"`
+	SkipSignalReapply bool   `json:"skip_signal_reapply,omitempty" jsonschema:"description=With operation reset, drop signals received after the reset point instead of reapplying them"`
+	Reason            string `json:"reason" jsonschema:"description=Why the operation is being run, recorded with the batch job and in each workflow's history; required unless doing a dry run"`
+	DryRun            bool   `json:"dry_run,omitempty" jsonschema:"description=Only count the matched workflows and show a sample, without starting the batch job"`
+	MaxWorkflows      int64  `json:"max_workflows,omitempty" jsonschema:"description=Refuse to start the job if more workflows than this match the query"`
+	Namespace         string `json:"-"`
+}
+
+type BatchOperationResponse struct {
+	JobID        string         `json:"job_id,omitempty"`
+	Operation    string         `json:"operation"`
+	Query        string         `json:"query"`
+	MatchedCount int64          `json:"matched_count"`
+	Sample       []WorkflowInfo `json:"sample,omitempty"`
+	Started      bool           `json:"started"`
+	Identity     string         `json:"identity,omitempty"`
+	Resets       []BatchReset   `json:"resets,omitempty"`
+	Summary      string         `json:"summary"`
+}
+
+// BatchReset is the outcome of resetting one workflow in a batch reset.
+type BatchReset struct {
+	WorkflowID string `json:"workflow_id"`
+	RunID      string `json:"run_id"`
+	EventID    int64  `json:"event_id,omitempty"`
+	NewRunID   string `json:"new_run_id,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+type BatchJobsArgs struct {
+	Action        string `json:"action" jsonschema:"required,enum=list,enum=describe,enum=stop,description=List batch jobs, describe one, or stop one"`
+	JobID         string `json:"job_id,omitempty" jsonschema:"description=The batch job to describe or stop"`
+	Reason        string `json:"reason,omitempty" jsonschema:"description=Why the job is being stopped; required to stop a job"`
+	PageSize      int    `json:"page_size,omitempty" jsonschema:"description=Maximum number of jobs to list"`
+	NextPageToken string `json:"next_page_token,omitempty" jsonschema:"description=Token from a previous list to fetch the next page"`
+	Namespace     string `json:"-"`
+}
+
+type BatchJob struct {
+	JobID     string `json:"job_id"`
+	Operation string `json:"operation,omitempty"`
+	State     string `json:"state"`
+	StartTime string `json:"start_time,omitempty"`
+	CloseTime string `json:"close_time,omitempty"`
+	Total     int64  `json:"total,omitempty"`
+	Completed int64  `json:"completed,omitempty"`
+	Failed    int64  `json:"failed,omitempty"`
+	Identity  string `json:"identity,omitempty"`
+	Reason    string `json:"reason,omitempty"`
+}
+
+type BatchJobsResponse struct {
+	Jobs          []BatchJob `json:"jobs"`
+	NextPageToken string     `json:"next_page_token,omitempty"`
+	Summary       string     `json:"summary"`
+}
+
+// BatchOperationHandler counts the workflows matching a visibility query and,
+// unless this is a dry run, starts a server-side batch job over them. Resets
+// have no batch job, so they are made here one workflow at a time.
+func BatchOperationHandler(ctx context.Context, temporalClient client.Client, args BatchOperationArgs) (BatchOperationResponse, error) {
+	if args.Query == "" {
+		return BatchOperationResponse{}, fmt.Errorf("query is required so that the batch cannot match every workflow by accident")
+	}
+	var signalInput *common.Payloads
+	switch args.Operation {
+	case BatchOperationSignal:
+		if args.SignalName == "" {
+			return BatchOperationResponse{}, fmt.Errorf("signal_name is required with operation %s", BatchOperationSignal)
+		}
+		signalArgs, err := parseJSONArgs(args.Args)
+		if err != nil {
+			return BatchOperationResponse{}, err
+		}
+		if signalInput, err = converter.GetDefaultDataConverter().ToPayloads(signalArgs...); err != nil {
+			return BatchOperationResponse{}, fmt.Errorf("failed to encode signal arguments: %w", err)
+		}
+	case BatchOperationCancel, BatchOperationTerminate:
+	case BatchOperationReset:
+		switch args.ResetType {
+		case ResetTypeFirstWorkflowTask, ResetTypeLastWorkflowTask:
+		case ResetTypeBuildID:
+			if args.BuildID == "" {
+				return BatchOperationResponse{}, fmt.Errorf("build_id is required with reset_type %s", ResetTypeBuildID)
+			}
+		default:
+			return BatchOperationResponse{}, fmt.Errorf("invalid reset_type %q for operation %s (expected %s, %s or %s)", args.ResetType,
+				BatchOperationReset, ResetTypeFirstWorkflowTask, ResetTypeLastWorkflowTask, ResetTypeBuildID)
+		}
+	default:
+		return BatchOperationResponse{}, fmt.Errorf("invalid operation %q (expected %s, %s, %s or %s)", args.Operation,
+			BatchOperationSignal, BatchOperationCancel, BatchOperationTerminate, BatchOperationReset)
+	}
+
+	result := BatchOperationResponse{Operation: args.Operation, Query: args.Query}
+	count, err := temporalClient.CountWorkflow(ctx, &workflowservice.CountWorkflowExecutionsRequest{Query: args.Query})
+	if err != nil {
+		return BatchOperationResponse{}, fmt.Errorf("failed to count matching workflows: %w", err)
+	}
+	result.MatchedCount = count.GetCount()
+
+	sample, err := temporalClient.ListWorkflow(ctx, &workflowservice.ListWorkflowExecutionsRequest{PageSize: batchPreviewSize, Query: args.Query})
+	if err != nil {
+		return BatchOperationResponse{}, fmt.Errorf("failed to list matching workflows: %w", err)
+	}
+	for _, info := range sample.GetExecutions() {
+		result.Sample = append(result.Sample, newWorkflowInfo(info))
+	}
+
+	switch {
+	case result.MatchedCount == 0:
+		result.Summary = "No workflows match the query; nothing to do."
+		return result, nil
+	case args.DryRun:
+		result.Summary = fmt.Sprintf("Dry run: %s would apply to %d workflows. Run again without dry_run to start the batch job.", args.Operation, result.MatchedCount)
+		if args.Operation == BatchOperationReset {
+			result.Summary = fmt.Sprintf("Dry run: reset would apply to %d workflows. Run again without dry_run to reset them; use reset_workflow with dry_run to see what a reset discards.", result.MatchedCount)
+		}
+		return result, nil
+	case args.MaxWorkflows > 0 && result.MatchedCount > args.MaxWorkflows:
+		result.Summary = fmt.Sprintf("Not started: %d workflows match, more than max_workflows %d.", result.MatchedCount, args.MaxWorkflows)
+		return result, nil
+	case args.Operation == BatchOperationReset && result.MatchedCount > maxBatchResetWorkflows:
+		result.Summary = fmt.Sprintf("Not started: %d workflows match, more than the %d a batch reset can handle. Narrow the query.", result.MatchedCount, maxBatchResetWorkflows)
+		return result, nil
+	}
+
+	// The reason is only needed once something will change, so that a dry
+	// run can be used to shape the query first.
+	identity, err := mutationIdentity(args.Reason)
+	if err != nil {
+		return BatchOperationResponse{}, err
+	}
+	if args.Operation == BatchOperationReset {
+		return batchReset(ctx, temporalClient, args, identity, result)
+	}
+
+	req := &workflowservice.StartBatchOperationRequest{
+		JobId:           uuid.NewString(),
+		Namespace:       args.Namespace,
+		VisibilityQuery: args.Query,
+		Reason:          args.Reason,
+	}
+	switch args.Operation {
+	case BatchOperationSignal:
+		req.Operation = &workflowservice.StartBatchOperationRequest_SignalOperation{SignalOperation: &batch.BatchOperationSignal{
+			Signal: args.SignalName, Input: signalInput, Identity: identity,
+		}}
+	case BatchOperationCancel:
+		req.Operation = &workflowservice.StartBatchOperationRequest_CancellationOperation{CancellationOperation: &batch.BatchOperationCancellation{Identity: identity}}
+	case BatchOperationTerminate:
+		req.Operation = &workflowservice.StartBatchOperationRequest_TerminationOperation{TerminationOperation: &batch.BatchOperationTermination{Identity: identity}}
+	}
+	if _, err := temporalClient.WorkflowService().StartBatchOperation(ctx, req); err != nil {
+		return BatchOperationResponse{}, fmt.Errorf("failed to start batch operation: %w", err)
+	}
+	result.JobID = req.JobId
+	result.Started = true
+	result.Identity = identity
+	result.Summary = fmt.Sprintf("Started batch job %s to %s %d workflows. Use batch_jobs to follow its progress or stop it.", result.JobID, args.Operation, result.MatchedCount)
+	return result, nil
+}
+
+// batchReset resets each workflow matching the query to the requested reset
+// point, carrying on past workflows that cannot be reset.
+func batchReset(ctx context.Context, temporalClient client.Client, args BatchOperationArgs, identity string, result BatchOperationResponse) (BatchOperationResponse, error) {
+	limit := maxBatchResetWorkflows
+	if args.MaxWorkflows > 0 && args.MaxWorkflows < int64(limit) {
+		limit = int(args.MaxWorkflows)
+	}
+	executions, _, err := listExecutions(ctx, temporalClient, args.Query, limit)
+	if err != nil {
+		return BatchOperationResponse{}, fmt.Errorf("failed to list matching workflows: %w", err)
+	}
+
+	point := ResetWorkflowArgs{ResetType: args.ResetType, BuildID: args.BuildID}
+	var failed int
+	var interrupted error
+	for _, info := range executions {
+		if interrupted = ctx.Err(); interrupted != nil {
+			break
+		}
+		execution := info.GetExecution()
+		reset := BatchReset{WorkflowID: execution.GetWorkflowId(), RunID: execution.GetRunId()}
+		if err := resetOne(ctx, temporalClient, args, identity, point, &reset); err != nil {
+			reset.Error = err.Error()
+			failed++
+		}
+		result.Resets = append(result.Resets, reset)
+	}
+
+	result.Started = true
+	result.Identity = identity
+	if interrupted != nil {
+		// The resets already made stand, so report them rather than only the
+		// error.
+		var done []string
+		for _, reset := range result.Resets {
+			if reset.Error == "" {
+				done = append(done, fmt.Sprintf("%s (new run %s)", reset.WorkflowID, reset.NewRunID))
+			}
+		}
+		result.Summary = fmt.Sprintf("Interrupted (%v) after %d of %d workflows; reset %d to their %s reset point", interrupted, len(result.Resets), len(executions), len(done), args.ResetType)
+		if len(done) > 0 {
+			result.Summary += ": " + strings.Join(done, ", ")
+		}
+		result.Summary += "."
+	} else {
+		result.Summary = fmt.Sprintf("Reset %d of %d matched workflows to their %s reset point.", len(executions)-failed, len(executions), args.ResetType)
+	}
+	if failed > 0 {
+		result.Summary += fmt.Sprintf(" %d could not be reset; see resets for the errors.", failed)
+	}
+	return result, nil
+}
+
+// resetOne resets a single run of a batch reset, filling in where it was reset
+// to and the new run.
+func resetOne(ctx context.Context, temporalClient client.Client, args BatchOperationArgs, identity string, point ResetWorkflowArgs, reset *BatchReset) error {
+	events, err := readHistory(ctx, temporalClient, reset.WorkflowID, reset.RunID)
+	if err != nil {
+		return err
+	}
+	points := resetPoints(events)
+	if len(points) == 0 {
+		return fmt.Errorf("workflow has no completed workflow task to reset to")
+	}
+	if reset.EventID, err = resolveResetPoint(events, points, point); err != nil {
+		return err
+	}
+	resp, err := temporalClient.ResetWorkflowExecution(ctx, resetRequest(args.Namespace, reset.WorkflowID, reset.RunID, reset.EventID, identity, args.SkipSignalReapply))
+	if err != nil {
+		return fmt.Errorf("failed to reset workflow: %w", err)
+	}
+	reset.NewRunID = resp.GetRunId()
+	return nil
+}
+
+// BatchJobsHandler lists, describes or stops batch jobs.
+func BatchJobsHandler(ctx context.Context, temporalClient client.Client, args BatchJobsArgs) (BatchJobsResponse, error) {
+	service := temporalClient.WorkflowService()
+
+	switch args.Action {
+	case BatchJobsList:
+		pageSize := args.PageSize
+		if pageSize <= 0 {
+			pageSize = defaultListPageSize
+		}
+		if pageSize > maxListPageSize {
+			pageSize = maxListPageSize
+		}
+		var token []byte
+		if args.NextPageToken != "" {
+			var err error
+			if token, err = base64.StdEncoding.DecodeString(args.NextPageToken); err != nil {
+				return BatchJobsResponse{}, fmt.Errorf("invalid next_page_token: %w", err)
+			}
+		}
+		resp, err := service.ListBatchOperations(ctx, &workflowservice.ListBatchOperationsRequest{
+			Namespace:     args.Namespace,
+			PageSize:      int32(pageSize),
+			NextPageToken: token,
+		})
+		if err != nil {
+			return BatchJobsResponse{}, fmt.Errorf("failed to list batch operations: %w", err)
+		}
+		result := BatchJobsResponse{Jobs: []BatchJob{}}
+		for _, info := range resp.GetOperationInfo() {
+			result.Jobs = append(result.Jobs, newBatchJobInfo(info))
+		}
+		result.Summary = fmt.Sprintf("Found %d batch jobs on this page.", len(result.Jobs))
+		if len(resp.GetNextPageToken()) > 0 {
+			result.NextPageToken = base64.StdEncoding.EncodeToString(resp.GetNextPageToken())
+			result.Summary += " More results are available with next_page_token."
+		}
+		return result, nil
+
+	case BatchJobsDescribe:
+		if args.JobID == "" {
+			return BatchJobsResponse{}, fmt.Errorf("job_id is required to describe a batch job")
+		}
+		job, err := describeBatchJob(ctx, temporalClient, args.Namespace, args.JobID)
+		if err != nil {
+			return BatchJobsResponse{}, err
+		}
+		return BatchJobsResponse{Jobs: []BatchJob{job}, Summary: batchJobSummary(job)}, nil
+
+	case BatchJobsStop:
+		if args.JobID == "" {
+			return BatchJobsResponse{}, fmt.Errorf("job_id is required to stop a batch job")
+		}
+		identity, err := mutationIdentity(args.Reason)
+		if err != nil {
+			return BatchJobsResponse{}, err
+		}
+		_, err = service.StopBatchOperation(ctx, &workflowservice.StopBatchOperationRequest{
+			Namespace: args.Namespace,
+			JobId:     args.JobID,
+			Reason:    args.Reason,
+			Identity:  identity,
+		})
+		if err != nil {
+			return BatchJobsResponse{}, fmt.Errorf("failed to stop batch operation: %w", err)
+		}
+		job, err := describeBatchJob(ctx, temporalClient, args.Namespace, args.JobID)
+		if err != nil {
+			return BatchJobsResponse{}, err
+		}
+		return BatchJobsResponse{Jobs: []BatchJob{job}, Summary: "Stop requested. " + batchJobSummary(job)}, nil
+	}
+	return BatchJobsResponse{}, fmt.Errorf("invalid action %q (expected %s, %s or %s)", args.Action, BatchJobsList, BatchJobsDescribe, BatchJobsStop)
+}
+
+func describeBatchJob(ctx context.Context, temporalClient client.Client, namespace, jobID string) (BatchJob, error) {
+	resp, err := temporalClient.WorkflowService().DescribeBatchOperation(ctx, &workflowservice.DescribeBatchOperationRequest{
+		Namespace: namespace,
+		JobId:     jobID,
+	})
+	if err != nil {
+		return BatchJob{}, fmt.Errorf("failed to describe batch operation: %w", err)
+	}
+	return BatchJob{
+		JobID:     resp.GetJobId(),
+		Operation: enumName(resp.GetOperationType()),
+		State:     enumName(resp.GetState()),
+		StartTime: formatTime(resp.GetStartTime()),
+		CloseTime: formatTime(resp.GetCloseTime()),
+		Total:     resp.GetTotalOperationCount(),
+		Completed: resp.GetCompleteOperationCount(),
+		Failed:    resp.GetFailureOperationCount(),
+		Identity:  resp.GetIdentity(),
+		Reason:    resp.GetReason(),
+	}, nil
+}
+
+func newBatchJobInfo(info *batch.BatchOperationInfo) BatchJob {
+	return BatchJob{
+		JobID:     info.GetJobId(),
+		State:     enumName(info.GetState()),
+		StartTime: formatTime(info.GetStartTime()),
+		CloseTime: formatTime(info.GetCloseTime()),
+	}
+}
+
+func batchJobSummary(job BatchJob) string {
+	return fmt.Sprintf("Batch job %s (%s) is %s: %d of %d workflows done, %d failed.",
+		job.JobID, job.Operation, job.State, job.Completed, job.Total, job.Failed)
+}
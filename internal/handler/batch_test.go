@@ -0,0 +1,283 @@
+package handler
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.temporal.io/api/batch/v1"
+	"go.temporal.io/api/common/v1"
+	"go.temporal.io/api/enums/v1"
+	"go.temporal.io/api/workflow/v1"
+	"go.temporal.io/api/workflowservice/v1"
+	"go.temporal.io/sdk/client"
+	"go.temporal.io/sdk/mocks"
+)
+
+// batchService serves job-1, a terminate job that is partway through.
+func batchService(t *testing.T) *fakeService {
+	start := time.Date(2024, 1, 2, 3, 0, 0, 0, time.UTC)
+	service := newFakeService(t)
+	service.batchJob = &workflowservice.DescribeBatchOperationResponse{
+		JobId:                  "job-1",
+		OperationType:          enums.BATCH_OPERATION_TYPE_TERMINATE,
+		State:                  enums.BATCH_OPERATION_STATE_RUNNING,
+		TotalOperationCount:    120,
+		CompleteOperationCount: 80,
+		FailureOperationCount:  2,
+		Reason:                 "incident 42",
+	}
+	service.batchJobs = &workflowservice.ListBatchOperationsResponse{
+		OperationInfo: []*batch.BatchOperationInfo{
+			{JobId: "job-1", State: enums.BATCH_OPERATION_STATE_COMPLETED, StartTime: &start},
+		},
+		NextPageToken: []byte("next"),
+	}
+	return service
+}
+
+func batchClient(service *fakeService, matched int64) *mocks.Client {
+	mockClient := &mocks.Client{}
+	mockClient.On("WorkflowService").Return(service)
+	mockClient.On("CountWorkflow", mock.Anything, &workflowservice.CountWorkflowExecutionsRequest{Query: "WorkflowType='Order'"}).
+		Return(&workflowservice.CountWorkflowExecutionsResponse{Count: matched}, nil)
+	mockClient.On("ListWorkflow", mock.Anything, mock.Anything).Return(&workflowservice.ListWorkflowExecutionsResponse{
+		Executions: []*workflow.WorkflowExecutionInfo{
+			{Execution: &common.WorkflowExecution{WorkflowId: "order-1", RunId: "run-1"}, Type: &common.WorkflowType{Name: "Order"}},
+		},
+	}, nil)
+	return mockClient
+}
+
+func TestBatchOperationHandler(t *testing.T) {
+	t.Run("Dry run previews the matched workflows", func(t *testing.T) {
+		service := batchService(t)
+
+		result, err := BatchOperationHandler(context.Background(), batchClient(service, 120), BatchOperationArgs{
+			Query: "WorkflowType='Order'", Operation: BatchOperationTerminate, DryRun: true,
+		})
+
+		require.NoError(t, err)
+		assert.Nil(t, service.batchStart)
+		assert.False(t, result.Started)
+		assert.Equal(t, int64(120), result.MatchedCount)
+		require.Len(t, result.Sample, 1)
+		assert.Equal(t, "order-1", result.Sample[0].WorkflowID)
+		assert.Contains(t, result.Summary, "Dry run: terminate would apply to 120 workflows")
+	})
+
+	t.Run("Starts a signal batch", func(t *testing.T) {
+		service := batchService(t)
+
+		result, err := BatchOperationHandler(context.Background(), batchClient(service, 120), BatchOperationArgs{
+			Query: "WorkflowType='Order'", Operation: BatchOperationSignal, SignalName: "retry", Args: `[true]`,
+			Reason: "incident 42", Namespace: "orders",
+		})
+
+		require.NoError(t, err)
+		require.NotNil(t, service.batchStart)
+		assert.Equal(t, "orders", service.batchStart.GetNamespace())
+		assert.Equal(t, "WorkflowType='Order'", service.batchStart.GetVisibilityQuery())
+		assert.Equal(t, "incident 42", service.batchStart.GetReason())
+		assert.Equal(t, result.JobID, service.batchStart.GetJobId())
+		signal := service.batchStart.GetSignalOperation()
+		require.NotNil(t, signal)
+		assert.Equal(t, "retry", signal.GetSignal())
+		assert.Equal(t, "mcp-temporal-server: incident 42", signal.GetIdentity())
+		assert.Equal(t, "true", string(signal.GetInput().GetPayloads()[0].GetData()))
+		assert.True(t, result.Started)
+	})
+
+	t.Run("Refuses more workflows than max_workflows", func(t *testing.T) {
+		service := batchService(t)
+
+		result, err := BatchOperationHandler(context.Background(), batchClient(service, 120), BatchOperationArgs{
+			Query: "WorkflowType='Order'", Operation: BatchOperationCancel, Reason: "incident 42", MaxWorkflows: 100,
+		})
+
+		require.NoError(t, err)
+		assert.Nil(t, service.batchStart)
+		assert.Equal(t, "Not started: 120 workflows match, more than max_workflows 100.", result.Summary)
+	})
+
+	t.Run("Nothing matched", func(t *testing.T) {
+		service := batchService(t)
+
+		result, err := BatchOperationHandler(context.Background(), batchClient(service, 0), BatchOperationArgs{
+			Query: "WorkflowType='Order'", Operation: BatchOperationCancel, Reason: "incident 42",
+		})
+
+		require.NoError(t, err)
+		assert.Nil(t, service.batchStart)
+		assert.Contains(t, result.Summary, "No workflows match")
+	})
+
+	t.Run("Invalid requests", func(t *testing.T) {
+		service := batchService(t)
+		c := batchClient(service, 1)
+
+		_, err := BatchOperationHandler(context.Background(), c, BatchOperationArgs{Query: "WorkflowType='Order'", Operation: BatchOperationCancel})
+		assert.ErrorContains(t, err, "a reason is required", "only a dry run can leave out the reason")
+
+		_, err = BatchOperationHandler(context.Background(), c, BatchOperationArgs{Operation: BatchOperationCancel, Reason: "r"})
+		assert.ErrorContains(t, err, "query is required")
+
+		_, err = BatchOperationHandler(context.Background(), c, BatchOperationArgs{Query: "WorkflowType='Order'", Operation: BatchOperationReset, ResetType: ResetTypeEventID, Reason: "r"})
+		assert.ErrorContains(t, err, `invalid reset_type "event_id" for operation reset`)
+
+		_, err = BatchOperationHandler(context.Background(), c, BatchOperationArgs{Query: "WorkflowType='Order'", Operation: BatchOperationReset, ResetType: ResetTypeBuildID, Reason: "r"})
+		assert.ErrorContains(t, err, "build_id is required")
+
+		_, err = BatchOperationHandler(context.Background(), c, BatchOperationArgs{Query: "WorkflowType='Order'", Operation: BatchOperationSignal, Reason: "r"})
+		assert.ErrorContains(t, err, "signal_name is required")
+		assert.Nil(t, service.batchStart)
+	})
+}
+
+func TestBatchOperationHandler_Reset(t *testing.T) {
+	resetClient := func(matched int64) *mocks.Client {
+		c := batchClient(batchService(t), matched)
+		c.On("GetWorkflowHistory", mock.Anything, "order-1", "run-1", false, enums.HISTORY_EVENT_FILTER_TYPE_ALL_EVENT).
+			Return(func(context.Context, string, string, bool, enums.HistoryEventFilterType) client.HistoryEventIterator {
+				return &sliceIterator{events: resetTestEvents()}
+			})
+		c.On("ResetWorkflowExecution", mock.Anything, mock.Anything).
+			Return(&workflowservice.ResetWorkflowExecutionResponse{RunId: "run-2"}, nil)
+		return c
+	}
+
+	t.Run("Resets each matched workflow", func(t *testing.T) {
+		c := resetClient(1)
+
+		result, err := BatchOperationHandler(context.Background(), c, BatchOperationArgs{
+			Query: "WorkflowType='Order'", Operation: BatchOperationReset, ResetType: ResetTypeBuildID, BuildID: "v2",
+			Reason: "bad deploy", Namespace: "orders",
+		})
+
+		require.NoError(t, err)
+		assert.True(t, result.Started)
+		assert.Equal(t, []BatchReset{{WorkflowID: "order-1", RunID: "run-1", EventID: 10, NewRunID: "run-2"}}, result.Resets)
+		assert.Equal(t, "Reset 1 of 1 matched workflows to their build_id reset point.", result.Summary)
+
+		reset := c.Calls[len(c.Calls)-1].Arguments.Get(1).(*workflowservice.ResetWorkflowExecutionRequest)
+		assert.Equal(t, "orders", reset.GetNamespace())
+		assert.Equal(t, "mcp-temporal-server: bad deploy", reset.GetReason())
+		assert.Equal(t, int64(10), reset.GetWorkflowTaskFinishEventId())
+		assert.Equal(t, enums.RESET_REAPPLY_TYPE_SIGNAL, reset.GetResetReapplyType())
+		assert.NotEmpty(t, reset.GetRequestId())
+	})
+
+	t.Run("Reports workflows that cannot be reset", func(t *testing.T) {
+		c := resetClient(1)
+
+		result, err := BatchOperationHandler(context.Background(), c, BatchOperationArgs{
+			Query: "WorkflowType='Order'", Operation: BatchOperationReset, ResetType: ResetTypeBuildID, BuildID: "v9", Reason: "bad deploy",
+		})
+
+		require.NoError(t, err)
+		require.Len(t, result.Resets, 1)
+		assert.Equal(t, "build v9 did not complete any workflow task of this run", result.Resets[0].Error)
+		assert.Contains(t, result.Summary, "Reset 0 of 1 matched workflows")
+		assert.Contains(t, result.Summary, "1 could not be reset")
+		c.AssertNotCalled(t, "ResetWorkflowExecution", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Keeps the dry run and size guards", func(t *testing.T) {
+		c := resetClient(120)
+		args := BatchOperationArgs{Query: "WorkflowType='Order'", Operation: BatchOperationReset, ResetType: ResetTypeFirstWorkflowTask, Reason: "bad deploy"}
+
+		dryRun := args
+		dryRun.DryRun = true
+		result, err := BatchOperationHandler(context.Background(), c, dryRun)
+		require.NoError(t, err)
+		assert.Contains(t, result.Summary, "Dry run: reset would apply to 120 workflows")
+
+		limited := args
+		limited.MaxWorkflows = 50
+		result, err = BatchOperationHandler(context.Background(), c, limited)
+		require.NoError(t, err)
+		assert.Equal(t, "Not started: 120 workflows match, more than max_workflows 50.", result.Summary)
+
+		result, err = BatchOperationHandler(context.Background(), c, args)
+		require.NoError(t, err)
+		assert.Contains(t, result.Summary, "more than the 100 a batch reset can handle")
+		assert.False(t, result.Started)
+		c.AssertNotCalled(t, "ResetWorkflowExecution", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Reports the resets made before it was cancelled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		c := &mocks.Client{}
+		c.On("CountWorkflow", mock.Anything, mock.Anything).Return(&workflowservice.CountWorkflowExecutionsResponse{Count: 3}, nil)
+		c.On("ListWorkflow", mock.Anything, mock.Anything).Return(&workflowservice.ListWorkflowExecutionsResponse{
+			Executions: []*workflow.WorkflowExecutionInfo{
+				{Execution: &common.WorkflowExecution{WorkflowId: "order-1", RunId: "run-1"}},
+				{Execution: &common.WorkflowExecution{WorkflowId: "order-2", RunId: "run-1"}},
+				{Execution: &common.WorkflowExecution{WorkflowId: "order-3", RunId: "run-1"}},
+			},
+		}, nil)
+		c.On("GetWorkflowHistory", mock.Anything, mock.Anything, "run-1", false, enums.HISTORY_EVENT_FILTER_TYPE_ALL_EVENT).
+			Return(func(context.Context, string, string, bool, enums.HistoryEventFilterType) client.HistoryEventIterator {
+				return &sliceIterator{events: resetTestEvents()}
+			})
+		c.On("ResetWorkflowExecution", mock.Anything, mock.Anything).
+			Run(func(mock.Arguments) { cancel() }).
+			Return(&workflowservice.ResetWorkflowExecutionResponse{RunId: "run-2"}, nil)
+
+		result, err := BatchOperationHandler(ctx, c, BatchOperationArgs{
+			Query: "WorkflowType='Order'", Operation: BatchOperationReset, ResetType: ResetTypeFirstWorkflowTask, Reason: "bad deploy",
+		})
+
+		require.NoError(t, err)
+		assert.True(t, result.Started)
+		assert.Equal(t, []BatchReset{{WorkflowID: "order-1", RunID: "run-1", EventID: 4, NewRunID: "run-2"}}, result.Resets)
+		assert.Equal(t, "Interrupted (context canceled) after 1 of 3 workflows; reset 1 to their first_workflow_task reset point: order-1 (new run run-2).", result.Summary)
+		c.AssertNumberOfCalls(t, "ResetWorkflowExecution", 1)
+	})
+}
+
+func TestBatchJobsHandler(t *testing.T) {
+	t.Run("List", func(t *testing.T) {
+		service := batchService(t)
+
+		result, err := BatchJobsHandler(context.Background(), batchClient(service, 0), BatchJobsArgs{Action: BatchJobsList, PageSize: 10, Namespace: "orders"})
+
+		require.NoError(t, err)
+		assert.Equal(t, int32(10), service.batchList.GetPageSize())
+		assert.Equal(t, "orders", service.batchList.GetNamespace())
+		require.Len(t, result.Jobs, 1)
+		assert.Equal(t, BatchJob{JobID: "job-1", State: "Completed", StartTime: "2024-01-02T03:00:00Z"}, result.Jobs[0])
+		assert.NotEmpty(t, result.NextPageToken)
+	})
+
+	t.Run("Describe", func(t *testing.T) {
+		result, err := BatchJobsHandler(context.Background(), batchClient(batchService(t), 0), BatchJobsArgs{Action: BatchJobsDescribe, JobID: "job-1"})
+
+		require.NoError(t, err)
+		assert.Equal(t, "Batch job job-1 (Terminate) is Running: 80 of 120 workflows done, 2 failed.", result.Summary)
+	})
+
+	t.Run("Stop", func(t *testing.T) {
+		service := batchService(t)
+
+		result, err := BatchJobsHandler(context.Background(), batchClient(service, 0), BatchJobsArgs{Action: BatchJobsStop, JobID: "job-1", Reason: "wrong query"})
+
+		require.NoError(t, err)
+		require.NotNil(t, service.batchStop)
+		assert.Equal(t, "mcp-temporal-server: wrong query", service.batchStop.GetIdentity())
+		assert.Contains(t, result.Summary, "Stop requested.")
+	})
+
+	t.Run("Stop requires a reason", func(t *testing.T) {
+		service := batchService(t)
+
+		_, err := BatchJobsHandler(context.Background(), batchClient(service, 0), BatchJobsArgs{Action: BatchJobsStop, JobID: "job-1"})
+
+		assert.ErrorContains(t, err, "a reason is required")
+		assert.Nil(t, service.batchStop)
+	})
+}
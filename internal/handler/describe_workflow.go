@@ -0,0 +1,151 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+
+	"go.temporal.io/api/enums/v1"
+	"go.temporal.io/sdk/client"
+)
+
+type DescribeWorkflowArgs struct {
+	WorkflowID string `json:"workflow_id" jsonschema:"required,description=The ID of the workflow to describe"`
+	RunID      string `json:"run_id,omitempty" jsonschema:"description=Optional run ID of the workflow"`
+}
+
+type WorkflowExecutionRef struct {
+	WorkflowID string `json:"workflow_id"`
+	RunID      string `json:"run_id,omitempty"`
+}
+
+type PendingActivity struct {
+	ActivityID         string                 `json:"activity_id"`
+	ActivityType       string                 `json:"activity_type"`
+	State              string                 `json:"state"`
+	Attempt            int32                  `json:"attempt"`
+	MaximumAttempts    int32                  `json:"maximum_attempts,omitempty"`
+	ScheduledTime      string                 `json:"scheduled_time,omitempty"`
+	LastStartedTime    string                 `json:"last_started_time,omitempty"`
+	LastHeartbeatTime  string                 `json:"last_heartbeat_time,omitempty"`
+	NextRetryTime      string                 `json:"next_retry_time,omitempty"`
+	ExpirationTime     string                 `json:"expiration_time,omitempty"`
+	LastFailure        *Failure               `json:"last_failure,omitempty"`
+	LastWorkerIdentity string                 `json:"last_worker_identity,omitempty"`
+	HeartbeatDetails   map[string]interface{} `json:"heartbeat_details,omitempty"`
+}
+
+type PendingChild struct {
+	WorkflowID        string `json:"workflow_id"`
+	RunID             string `json:"run_id,omitempty"`
+	WorkflowType      string `json:"workflow_type"`
+	InitiatedEventID  int64  `json:"initiated_event_id"`
+	ParentClosePolicy string `json:"parent_close_policy"`
+}
+
+type PendingWorkflowTask struct {
+	State         string `json:"state"`
+	Attempt       int32  `json:"attempt"`
+	ScheduledTime string `json:"scheduled_time,omitempty"`
+	StartedTime   string `json:"started_time,omitempty"`
+}
+
+type WorkflowDescription struct {
+	WorkflowID          string                 `json:"workflow_id"`
+	RunID               string                 `json:"run_id"`
+	Type                string                 `json:"type"`
+	Status              string                 `json:"status"`
+	TaskQueue           string                 `json:"task_queue"`
+	StartTime           string                 `json:"start_time,omitempty"`
+	ExecutionTime       string                 `json:"execution_time,omitempty"`
+	CloseTime           string                 `json:"close_time,omitempty"`
+	HistoryLength       int64                  `json:"history_length"`
+	Parent              *WorkflowExecutionRef  `json:"parent,omitempty"`
+	Memo                map[string]interface{} `json:"memo,omitempty"`
+	SearchAttributes    map[string]interface{} `json:"search_attributes,omitempty"`
+	PendingWorkflowTask *PendingWorkflowTask   `json:"pending_workflow_task,omitempty"`
+	PendingActivities   []PendingActivity      `json:"pending_activities,omitempty"`
+	PendingChildren     []PendingChild         `json:"pending_children,omitempty"`
+	Summary             string                 `json:"summary"`
+}
+
+func DescribeWorkflowHandler(ctx context.Context, temporalClient client.Client, args DescribeWorkflowArgs) (WorkflowDescription, error) {
+	resp, err := temporalClient.DescribeWorkflowExecution(ctx, args.WorkflowID, args.RunID)
+	if err != nil {
+		return WorkflowDescription{}, fmt.Errorf("failed to describe workflow: %w", err)
+	}
+
+	info := resp.GetWorkflowExecutionInfo()
+	desc := WorkflowDescription{
+		WorkflowID:       info.GetExecution().GetWorkflowId(),
+		RunID:            info.GetExecution().GetRunId(),
+		Type:             info.GetType().GetName(),
+		Status:           info.GetStatus().String(),
+		TaskQueue:        info.GetTaskQueue(),
+		StartTime:        formatTime(info.GetStartTime()),
+		ExecutionTime:    formatTime(info.GetExecutionTime()),
+		CloseTime:        formatTime(info.GetCloseTime()),
+		HistoryLength:    info.GetHistoryLength(),
+		Memo:             decodePayloadMap(info.GetMemo().GetFields()),
+		SearchAttributes: decodePayloadMap(info.GetSearchAttributes().GetIndexedFields()),
+	}
+	if desc.WorkflowID == "" {
+		desc.WorkflowID = args.WorkflowID
+	}
+
+	if parent := info.GetParentExecution(); parent != nil {
+		desc.Parent = &WorkflowExecutionRef{WorkflowID: parent.GetWorkflowId(), RunID: parent.GetRunId()}
+	}
+
+	if wt := resp.GetPendingWorkflowTask(); wt != nil {
+		desc.PendingWorkflowTask = &PendingWorkflowTask{
+			State:         wt.GetState().String(),
+			Attempt:       wt.GetAttempt(),
+			ScheduledTime: formatTime(wt.GetScheduledTime()),
+			StartedTime:   formatTime(wt.GetStartedTime()),
+		}
+	}
+
+	for _, a := range resp.GetPendingActivities() {
+		activity := PendingActivity{
+			ActivityID:         a.GetActivityId(),
+			ActivityType:       a.GetActivityType().GetName(),
+			State:              a.GetState().String(),
+			Attempt:            a.GetAttempt(),
+			MaximumAttempts:    a.GetMaximumAttempts(),
+			ScheduledTime:      formatTime(a.GetScheduledTime()),
+			LastStartedTime:    formatTime(a.GetLastStartedTime()),
+			LastHeartbeatTime:  formatTime(a.GetLastHeartbeatTime()),
+			ExpirationTime:     formatTime(a.GetExpirationTime()),
+			LastFailure:        formatFailure(a.GetLastFailure()),
+			LastWorkerIdentity: a.GetLastWorkerIdentity(),
+		}
+		// While an activity is backing off between attempts the server reports
+		// it as scheduled, with the scheduled time set to the next attempt.
+		if a.GetState() == enums.PENDING_ACTIVITY_STATE_SCHEDULED && a.GetAttempt() > 1 {
+			activity.NextRetryTime = activity.ScheduledTime
+		}
+		if a.GetHeartbeatDetails() != nil {
+			details := make(map[string]interface{})
+			extractJSON(a.GetHeartbeatDetails(), &details)
+			if len(details) > 0 {
+				activity.HeartbeatDetails = details
+			}
+		}
+		desc.PendingActivities = append(desc.PendingActivities, activity)
+	}
+
+	for _, c := range resp.GetPendingChildren() {
+		desc.PendingChildren = append(desc.PendingChildren, PendingChild{
+			WorkflowID:        c.GetWorkflowId(),
+			RunID:             c.GetRunId(),
+			WorkflowType:      c.GetWorkflowTypeName(),
+			InitiatedEventID:  c.GetInitiatedId(),
+			ParentClosePolicy: c.GetParentClosePolicy().String(),
+		})
+	}
+
+	desc.Summary = fmt.Sprintf("Workflow is %s with %d events, %d pending activities and %d pending children.",
+		desc.Status, desc.HistoryLength, len(desc.PendingActivities), len(desc.PendingChildren))
+
+	return desc, nil
+}
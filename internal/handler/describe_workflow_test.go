@@ -0,0 +1,94 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.temporal.io/api/common/v1"
+	"go.temporal.io/api/enums/v1"
+	"go.temporal.io/api/failure/v1"
+	"go.temporal.io/api/workflow/v1"
+	"go.temporal.io/api/workflowservice/v1"
+	"go.temporal.io/sdk/mocks"
+)
+
+func TestDescribeWorkflowHandler(t *testing.T) {
+	t.Run("Running workflow with pending work", func(t *testing.T) {
+		mockClient := &mocks.Client{}
+
+		start := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+		nextAttempt := start.Add(time.Minute)
+
+		mockClient.On("DescribeWorkflowExecution", mock.Anything, "wf-1", "").Return(&workflowservice.DescribeWorkflowExecutionResponse{
+			WorkflowExecutionInfo: &workflow.WorkflowExecutionInfo{
+				Execution:     &common.WorkflowExecution{WorkflowId: "wf-1", RunId: "run-1"},
+				Type:          &common.WorkflowType{Name: "OrderWorkflow"},
+				StartTime:     &start,
+				Status:        enums.WORKFLOW_EXECUTION_STATUS_RUNNING,
+				HistoryLength: 42,
+				TaskQueue:     "orders",
+				Memo: &common.Memo{Fields: map[string]*common.Payload{
+					"owner": {Data: []byte(`"team-a"`)},
+				}},
+				SearchAttributes: &common.SearchAttributes{IndexedFields: map[string]*common.Payload{
+					"CustomerId": {Data: []byte(`"c-1"`)},
+				}},
+			},
+			PendingActivities: []*workflow.PendingActivityInfo{
+				{
+					ActivityId:       "5",
+					ActivityType:     &common.ActivityType{Name: "ChargeCard"},
+					State:            enums.PENDING_ACTIVITY_STATE_SCHEDULED,
+					Attempt:          3,
+					ScheduledTime:    &nextAttempt,
+					LastFailure:      &failure.Failure{Message: "card declined"},
+					HeartbeatDetails: &common.Payloads{Payloads: []*common.Payload{{Data: []byte(`{"progress":10}`)}}},
+				},
+			},
+			PendingChildren: []*workflow.PendingChildExecutionInfo{
+				{WorkflowId: "child-1", RunId: "child-run", WorkflowTypeName: "ShipWorkflow", InitiatedId: 7},
+			},
+		}, nil)
+
+		desc, err := DescribeWorkflowHandler(context.Background(), mockClient, DescribeWorkflowArgs{WorkflowID: "wf-1"})
+
+		assert.NoError(t, err)
+		assert.Equal(t, "wf-1", desc.WorkflowID)
+		assert.Equal(t, "run-1", desc.RunID)
+		assert.Equal(t, "OrderWorkflow", desc.Type)
+		assert.Equal(t, "Running", desc.Status)
+		assert.Equal(t, "orders", desc.TaskQueue)
+		assert.Equal(t, "2024-01-02T03:04:05Z", desc.StartTime)
+		assert.Empty(t, desc.CloseTime)
+		assert.Equal(t, "team-a", desc.Memo["owner"])
+		assert.Equal(t, "c-1", desc.SearchAttributes["CustomerId"])
+
+		assert.Len(t, desc.PendingActivities, 1)
+		activity := desc.PendingActivities[0]
+		assert.Equal(t, "ChargeCard", activity.ActivityType)
+		assert.Equal(t, int32(3), activity.Attempt)
+		assert.Equal(t, &Failure{Message: "card declined"}, activity.LastFailure)
+		assert.Equal(t, "2024-01-02T03:05:05Z", activity.NextRetryTime)
+		assert.Equal(t, map[string]interface{}{"progress": float64(10)}, activity.HeartbeatDetails["input_part_0"])
+
+		assert.Len(t, desc.PendingChildren, 1)
+		assert.Equal(t, "child-1", desc.PendingChildren[0].WorkflowID)
+		assert.Equal(t, int64(7), desc.PendingChildren[0].InitiatedEventID)
+
+		assert.Equal(t, "Workflow is Running with 42 events, 1 pending activities and 1 pending children.", desc.Summary)
+		mockClient.AssertExpectations(t)
+	})
+
+	t.Run("Describe error", func(t *testing.T) {
+		mockClient := &mocks.Client{}
+		mockClient.On("DescribeWorkflowExecution", mock.Anything, "wf-1", "run-1").Return(nil, errors.New("not found"))
+
+		_, err := DescribeWorkflowHandler(context.Background(), mockClient, DescribeWorkflowArgs{WorkflowID: "wf-1", RunID: "run-1"})
+
+		assert.EqualError(t, err, "failed to describe workflow: not found")
+	})
+}
@@ -0,0 +1,637 @@
+package handler
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.temporal.io/api/common/v1"
+	"go.temporal.io/api/enums/v1"
+	"go.temporal.io/api/failure/v1"
+	"go.temporal.io/api/history/v1"
+	"go.temporal.io/api/taskqueue/v1"
+	"go.temporal.io/api/update/v1"
+)
+
+func payloads(data ...string) *common.Payloads {
+	p := &common.Payloads{}
+	for _, d := range data {
+		p.Payloads = append(p.Payloads, &common.Payload{Data: []byte(d)})
+	}
+	return p
+}
+
+func durationPtr(d time.Duration) *time.Duration {
+	return &d
+}
+
+func TestFormatEvent_AllEventTypes(t *testing.T) {
+	childExecution := &common.WorkflowExecution{WorkflowId: "child-1", RunId: "child-run"}
+	childType := &common.WorkflowType{Name: "ChildWorkflow"}
+	externalExecution := &common.WorkflowExecution{WorkflowId: "other-1", RunId: "other-run"}
+
+	tests := []struct {
+		name       string
+		attributes interface{}
+		want       map[string]interface{}
+	}{
+		{
+			name: "WorkflowExecutionStarted",
+			attributes: &history.HistoryEvent_WorkflowExecutionStartedEventAttributes{WorkflowExecutionStartedEventAttributes: &history.WorkflowExecutionStartedEventAttributes{
+				WorkflowType:            &common.WorkflowType{Name: "OrderWorkflow"},
+				TaskQueue:               &taskqueue.TaskQueue{Name: "orders"},
+				Input:                   payloads(`{"id":1}`),
+				Attempt:                 1,
+				ContinuedExecutionRunId: "prev-run",
+				FirstExecutionRunId:     "first-run",
+				OriginalExecutionRunId:  "orig-run",
+				ParentWorkflowExecution: &common.WorkflowExecution{WorkflowId: "parent-1", RunId: "parent-run"},
+				WorkflowRunTimeout:      durationPtr(time.Hour),
+				Memo:                    &common.Memo{Fields: map[string]*common.Payload{"owner": {Data: []byte(`"a"`)}}},
+			}},
+			want: map[string]interface{}{
+				"input_part_0":               map[string]interface{}{"id": float64(1)},
+				"workflow_type":              "OrderWorkflow",
+				"task_queue":                 "orders",
+				"attempt":                    int32(1),
+				"continued_execution_run_id": "prev-run",
+				"first_execution_run_id":     "first-run",
+				"original_execution_run_id":  "orig-run",
+				"parent_workflow_id":         "parent-1",
+				"parent_run_id":              "parent-run",
+				"run_timeout":                "1h0m0s",
+				"memo":                       map[string]interface{}{"owner": "a"},
+			},
+		},
+		{
+			name: "WorkflowExecutionCompleted",
+			attributes: &history.HistoryEvent_WorkflowExecutionCompletedEventAttributes{WorkflowExecutionCompletedEventAttributes: &history.WorkflowExecutionCompletedEventAttributes{
+				Result:            payloads(`"done"`),
+				NewExecutionRunId: "cron-next",
+			}},
+			want: map[string]interface{}{"input_part_0": "done", "new_execution_run_id": "cron-next"},
+		},
+		{
+			name: "WorkflowExecutionFailed",
+			attributes: &history.HistoryEvent_WorkflowExecutionFailedEventAttributes{WorkflowExecutionFailedEventAttributes: &history.WorkflowExecutionFailedEventAttributes{
+				Failure:    &failure.Failure{Message: "boom"},
+				RetryState: enums.RETRY_STATE_RETRY_POLICY_NOT_SET,
+			}},
+			want: map[string]interface{}{"error": "boom", "failure": &Failure{Message: "boom"}, "retry_state": "RetryPolicyNotSet"},
+		},
+		{
+			name: "WorkflowExecutionTimedOut",
+			attributes: &history.HistoryEvent_WorkflowExecutionTimedOutEventAttributes{WorkflowExecutionTimedOutEventAttributes: &history.WorkflowExecutionTimedOutEventAttributes{
+				RetryState: enums.RETRY_STATE_TIMEOUT,
+			}},
+			want: map[string]interface{}{"retry_state": "Timeout"},
+		},
+		{
+			name: "WorkflowExecutionContinuedAsNew",
+			attributes: &history.HistoryEvent_WorkflowExecutionContinuedAsNewEventAttributes{WorkflowExecutionContinuedAsNewEventAttributes: &history.WorkflowExecutionContinuedAsNewEventAttributes{
+				NewExecutionRunId:    "next-run",
+				WorkflowType:         &common.WorkflowType{Name: "OrderWorkflow"},
+				TaskQueue:            &taskqueue.TaskQueue{Name: "orders"},
+				Input:                payloads(`"next"`),
+				Initiator:            enums.CONTINUE_AS_NEW_INITIATOR_WORKFLOW,
+				LastCompletionResult: payloads(`1`),
+			}},
+			want: map[string]interface{}{
+				"input_part_0":           "next",
+				"new_execution_run_id":   "next-run",
+				"workflow_type":          "OrderWorkflow",
+				"task_queue":             "orders",
+				"initiator":              "Workflow",
+				"last_completion_result": []interface{}{float64(1)},
+			},
+		},
+		{
+			name: "WorkflowExecutionCancelRequested",
+			attributes: &history.HistoryEvent_WorkflowExecutionCancelRequestedEventAttributes{WorkflowExecutionCancelRequestedEventAttributes: &history.WorkflowExecutionCancelRequestedEventAttributes{
+				Cause:                     "user request",
+				Identity:                  "cli",
+				ExternalWorkflowExecution: externalExecution,
+			}},
+			want: map[string]interface{}{
+				"cause":                "user request",
+				"identity":             "cli",
+				"external_workflow_id": "other-1",
+				"external_run_id":      "other-run",
+			},
+		},
+		{
+			name: "WorkflowExecutionCanceled",
+			attributes: &history.HistoryEvent_WorkflowExecutionCanceledEventAttributes{WorkflowExecutionCanceledEventAttributes: &history.WorkflowExecutionCanceledEventAttributes{
+				Details: payloads(`"bye"`),
+			}},
+			want: map[string]interface{}{"input_part_0": "bye"},
+		},
+		{
+			name: "WorkflowExecutionTerminated",
+			attributes: &history.HistoryEvent_WorkflowExecutionTerminatedEventAttributes{WorkflowExecutionTerminatedEventAttributes: &history.WorkflowExecutionTerminatedEventAttributes{
+				Reason:   "stuck",
+				Identity: "ops",
+			}},
+			want: map[string]interface{}{"reason": "stuck", "identity": "ops"},
+		},
+		{
+			name: "WorkflowTaskScheduled",
+			attributes: &history.HistoryEvent_WorkflowTaskScheduledEventAttributes{WorkflowTaskScheduledEventAttributes: &history.WorkflowTaskScheduledEventAttributes{
+				TaskQueue:           &taskqueue.TaskQueue{Name: "orders"},
+				StartToCloseTimeout: durationPtr(10 * time.Second),
+				Attempt:             2,
+			}},
+			want: map[string]interface{}{"task_queue": "orders", "start_to_close_timeout": "10s", "attempt": int32(2)},
+		},
+		{
+			name: "WorkflowTaskStarted",
+			attributes: &history.HistoryEvent_WorkflowTaskStartedEventAttributes{WorkflowTaskStartedEventAttributes: &history.WorkflowTaskStartedEventAttributes{
+				ScheduledEventId: 2,
+				Identity:         "worker-1",
+			}},
+			want: map[string]interface{}{"scheduled_event_id": int64(2), "identity": "worker-1"},
+		},
+		{
+			name: "WorkflowTaskCompleted",
+			attributes: &history.HistoryEvent_WorkflowTaskCompletedEventAttributes{WorkflowTaskCompletedEventAttributes: &history.WorkflowTaskCompletedEventAttributes{
+				ScheduledEventId: 2,
+				StartedEventId:   3,
+				Identity:         "worker-1",
+				BinaryChecksum:   "abc",
+				WorkerVersioningId: &taskqueue.VersionId{
+					WorkerBuildId: "build-7",
+				},
+			}},
+			want: map[string]interface{}{
+				"scheduled_event_id": int64(2),
+				"started_event_id":   int64(3),
+				"identity":           "worker-1",
+				"binary_checksum":    "abc",
+				"build_id":           "build-7",
+			},
+		},
+		{
+			name: "WorkflowTaskTimedOut",
+			attributes: &history.HistoryEvent_WorkflowTaskTimedOutEventAttributes{WorkflowTaskTimedOutEventAttributes: &history.WorkflowTaskTimedOutEventAttributes{
+				ScheduledEventId: 2,
+				StartedEventId:   3,
+				TimeoutType:      enums.TIMEOUT_TYPE_START_TO_CLOSE,
+			}},
+			want: map[string]interface{}{"scheduled_event_id": int64(2), "started_event_id": int64(3), "timeout_type": "StartToClose"},
+		},
+		{
+			name: "WorkflowTaskFailed",
+			attributes: &history.HistoryEvent_WorkflowTaskFailedEventAttributes{WorkflowTaskFailedEventAttributes: &history.WorkflowTaskFailedEventAttributes{
+				ScheduledEventId: 2,
+				StartedEventId:   3,
+				Cause:            enums.WORKFLOW_TASK_FAILED_CAUSE_WORKFLOW_WORKER_UNHANDLED_FAILURE,
+				Failure:          &failure.Failure{Message: "panic", StackTrace: "main.go:1"},
+				Identity:         "worker-1",
+			}},
+			want: map[string]interface{}{
+				"error":              "panic\nmain.go:1",
+				"failure":            &Failure{Message: "panic", StackTrace: "main.go:1"},
+				"scheduled_event_id": int64(2),
+				"started_event_id":   int64(3),
+				"cause":              "WorkflowWorkerUnhandledFailure",
+				"identity":           "worker-1",
+			},
+		},
+		{
+			name: "ActivityTaskScheduled",
+			attributes: &history.HistoryEvent_ActivityTaskScheduledEventAttributes{ActivityTaskScheduledEventAttributes: &history.ActivityTaskScheduledEventAttributes{
+				ActivityId:          "5",
+				ActivityType:        &common.ActivityType{Name: "Charge"},
+				TaskQueue:           &taskqueue.TaskQueue{Name: "payments"},
+				Input:               payloads(`"card"`),
+				StartToCloseTimeout: durationPtr(5 * time.Second),
+				RetryPolicy: &common.RetryPolicy{
+					InitialInterval:    durationPtr(time.Second),
+					BackoffCoefficient: 2,
+					MaximumAttempts:    5,
+				},
+			}},
+			want: map[string]interface{}{
+				"activity_type":          "Charge",
+				"activity_id":            "5",
+				"task_queue":             "payments",
+				"input_part_0":           "card",
+				"start_to_close_timeout": "5s",
+				"retry_policy": map[string]interface{}{
+					"initial_interval":    "1s",
+					"backoff_coefficient": float64(2),
+					"maximum_attempts":    int32(5),
+				},
+			},
+		},
+		{
+			name: "ActivityTaskStarted",
+			attributes: &history.HistoryEvent_ActivityTaskStartedEventAttributes{ActivityTaskStartedEventAttributes: &history.ActivityTaskStartedEventAttributes{
+				ScheduledEventId: 5,
+				Identity:         "worker-1",
+				Attempt:          3,
+				LastFailure:      &failure.Failure{Message: "timeout"},
+			}},
+			want: map[string]interface{}{"scheduled_event_id": int64(5), "identity": "worker-1", "attempt": int32(3), "last_failure": &Failure{Message: "timeout"}},
+		},
+		{
+			name: "ActivityTaskCompleted",
+			attributes: &history.HistoryEvent_ActivityTaskCompletedEventAttributes{ActivityTaskCompletedEventAttributes: &history.ActivityTaskCompletedEventAttributes{
+				Result:           payloads(`"ok"`),
+				ScheduledEventId: 5,
+				StartedEventId:   6,
+			}},
+			want: map[string]interface{}{"input_part_0": "ok", "scheduled_event_id": int64(5), "started_event_id": int64(6)},
+		},
+		{
+			name: "ActivityTaskFailed",
+			attributes: &history.HistoryEvent_ActivityTaskFailedEventAttributes{ActivityTaskFailedEventAttributes: &history.ActivityTaskFailedEventAttributes{
+				Failure:          &failure.Failure{Message: "declined"},
+				ScheduledEventId: 5,
+				StartedEventId:   6,
+				RetryState:       enums.RETRY_STATE_MAXIMUM_ATTEMPTS_REACHED,
+			}},
+			want: map[string]interface{}{
+				"error":              "declined",
+				"failure":            &Failure{Message: "declined"},
+				"scheduled_event_id": int64(5),
+				"started_event_id":   int64(6),
+				"retry_state":        "MaximumAttemptsReached",
+			},
+		},
+		{
+			name: "ActivityTaskTimedOut",
+			attributes: &history.HistoryEvent_ActivityTaskTimedOutEventAttributes{ActivityTaskTimedOutEventAttributes: &history.ActivityTaskTimedOutEventAttributes{
+				Failure: &failure.Failure{
+					Message: "activity timeout",
+					FailureInfo: &failure.Failure_TimeoutFailureInfo{TimeoutFailureInfo: &failure.TimeoutFailureInfo{
+						TimeoutType: enums.TIMEOUT_TYPE_HEARTBEAT,
+					}},
+				},
+				ScheduledEventId: 5,
+				StartedEventId:   6,
+				RetryState:       enums.RETRY_STATE_TIMEOUT,
+			}},
+			want: map[string]interface{}{
+				"error":              "activity timeout",
+				"timeout_type":       "Heartbeat",
+				"failure":            &Failure{Message: "activity timeout", Type: "timeout", TimeoutType: "Heartbeat"},
+				"scheduled_event_id": int64(5),
+				"started_event_id":   int64(6),
+				"retry_state":        "Timeout",
+			},
+		},
+		{
+			name: "ActivityTaskCancelRequested",
+			attributes: &history.HistoryEvent_ActivityTaskCancelRequestedEventAttributes{ActivityTaskCancelRequestedEventAttributes: &history.ActivityTaskCancelRequestedEventAttributes{
+				ScheduledEventId: 5,
+			}},
+			want: map[string]interface{}{"scheduled_event_id": int64(5)},
+		},
+		{
+			name: "ActivityTaskCanceled",
+			attributes: &history.HistoryEvent_ActivityTaskCanceledEventAttributes{ActivityTaskCanceledEventAttributes: &history.ActivityTaskCanceledEventAttributes{
+				Details:          payloads(`"canceled"`),
+				ScheduledEventId: 5,
+				StartedEventId:   6,
+				Identity:         "worker-1",
+			}},
+			want: map[string]interface{}{"input_part_0": "canceled", "scheduled_event_id": int64(5), "started_event_id": int64(6), "identity": "worker-1"},
+		},
+		{
+			name: "ActivityPropertiesModifiedExternally",
+			attributes: &history.HistoryEvent_ActivityPropertiesModifiedExternallyEventAttributes{ActivityPropertiesModifiedExternallyEventAttributes: &history.ActivityPropertiesModifiedExternallyEventAttributes{
+				ScheduledEventId: 5,
+				NewRetryPolicy:   &common.RetryPolicy{MaximumAttempts: 10},
+			}},
+			want: map[string]interface{}{"scheduled_event_id": int64(5), "retry_policy": map[string]interface{}{"maximum_attempts": int32(10)}},
+		},
+		{
+			name: "TimerStarted",
+			attributes: &history.HistoryEvent_TimerStartedEventAttributes{TimerStartedEventAttributes: &history.TimerStartedEventAttributes{
+				TimerId:            "t-1",
+				StartToFireTimeout: durationPtr(time.Minute),
+			}},
+			want: map[string]interface{}{"timer_id": "t-1", "timeout": "1m0s"},
+		},
+		{
+			name: "TimerFired",
+			attributes: &history.HistoryEvent_TimerFiredEventAttributes{TimerFiredEventAttributes: &history.TimerFiredEventAttributes{
+				TimerId:        "t-1",
+				StartedEventId: 8,
+			}},
+			want: map[string]interface{}{"timer_id": "t-1", "started_event_id": int64(8)},
+		},
+		{
+			name: "TimerCanceled",
+			attributes: &history.HistoryEvent_TimerCanceledEventAttributes{TimerCanceledEventAttributes: &history.TimerCanceledEventAttributes{
+				TimerId:        "t-1",
+				StartedEventId: 8,
+				Identity:       "worker-1",
+			}},
+			want: map[string]interface{}{"timer_id": "t-1", "started_event_id": int64(8), "identity": "worker-1"},
+		},
+		{
+			name: "MarkerRecorded",
+			attributes: &history.HistoryEvent_MarkerRecordedEventAttributes{MarkerRecordedEventAttributes: &history.MarkerRecordedEventAttributes{
+				MarkerName: "SideEffect",
+				Details:    map[string]*common.Payloads{"data": payloads(`42`)},
+				Failure:    &failure.Failure{Message: "local activity failed"},
+			}},
+			want: map[string]interface{}{
+				"marker_name": "SideEffect",
+				"details":     map[string]interface{}{"data": []interface{}{float64(42)}},
+				"error":       "local activity failed",
+				"failure":     &Failure{Message: "local activity failed"},
+			},
+		},
+		{
+			name: "WorkflowExecutionSignaled",
+			attributes: &history.HistoryEvent_WorkflowExecutionSignaledEventAttributes{WorkflowExecutionSignaledEventAttributes: &history.WorkflowExecutionSignaledEventAttributes{
+				SignalName: "approve",
+				Input:      payloads(`true`),
+				Identity:   "cli",
+			}},
+			want: map[string]interface{}{"signal_name": "approve", "input_part_0": true, "identity": "cli"},
+		},
+		{
+			name: "RequestCancelExternalWorkflowExecutionInitiated",
+			attributes: &history.HistoryEvent_RequestCancelExternalWorkflowExecutionInitiatedEventAttributes{RequestCancelExternalWorkflowExecutionInitiatedEventAttributes: &history.RequestCancelExternalWorkflowExecutionInitiatedEventAttributes{
+				Namespace:         "default",
+				WorkflowExecution: externalExecution,
+				ChildWorkflowOnly: true,
+				Reason:            "cleanup",
+			}},
+			want: map[string]interface{}{
+				"namespace":           "default",
+				"workflow_id":         "other-1",
+				"run_id":              "other-run",
+				"child_workflow_only": true,
+				"reason":              "cleanup",
+			},
+		},
+		{
+			name: "RequestCancelExternalWorkflowExecutionFailed",
+			attributes: &history.HistoryEvent_RequestCancelExternalWorkflowExecutionFailedEventAttributes{RequestCancelExternalWorkflowExecutionFailedEventAttributes: &history.RequestCancelExternalWorkflowExecutionFailedEventAttributes{
+				Cause:             enums.CANCEL_EXTERNAL_WORKFLOW_EXECUTION_FAILED_CAUSE_EXTERNAL_WORKFLOW_EXECUTION_NOT_FOUND,
+				WorkflowExecution: externalExecution,
+				InitiatedEventId:  9,
+			}},
+			want: map[string]interface{}{
+				"cause":              "ExternalWorkflowExecutionNotFound",
+				"workflow_id":        "other-1",
+				"run_id":             "other-run",
+				"initiated_event_id": int64(9),
+			},
+		},
+		{
+			name: "ExternalWorkflowExecutionCancelRequested",
+			attributes: &history.HistoryEvent_ExternalWorkflowExecutionCancelRequestedEventAttributes{ExternalWorkflowExecutionCancelRequestedEventAttributes: &history.ExternalWorkflowExecutionCancelRequestedEventAttributes{
+				WorkflowExecution: externalExecution,
+				InitiatedEventId:  9,
+			}},
+			want: map[string]interface{}{"workflow_id": "other-1", "run_id": "other-run", "initiated_event_id": int64(9)},
+		},
+		{
+			name: "SignalExternalWorkflowExecutionInitiated",
+			attributes: &history.HistoryEvent_SignalExternalWorkflowExecutionInitiatedEventAttributes{SignalExternalWorkflowExecutionInitiatedEventAttributes: &history.SignalExternalWorkflowExecutionInitiatedEventAttributes{
+				WorkflowExecution: externalExecution,
+				SignalName:        "poke",
+				Input:             payloads(`"hi"`),
+			}},
+			want: map[string]interface{}{"workflow_id": "other-1", "run_id": "other-run", "signal_name": "poke", "input_part_0": "hi"},
+		},
+		{
+			name: "SignalExternalWorkflowExecutionFailed",
+			attributes: &history.HistoryEvent_SignalExternalWorkflowExecutionFailedEventAttributes{SignalExternalWorkflowExecutionFailedEventAttributes: &history.SignalExternalWorkflowExecutionFailedEventAttributes{
+				Cause:             enums.SIGNAL_EXTERNAL_WORKFLOW_EXECUTION_FAILED_CAUSE_EXTERNAL_WORKFLOW_EXECUTION_NOT_FOUND,
+				WorkflowExecution: externalExecution,
+				InitiatedEventId:  9,
+			}},
+			want: map[string]interface{}{
+				"cause":              "ExternalWorkflowExecutionNotFound",
+				"workflow_id":        "other-1",
+				"run_id":             "other-run",
+				"initiated_event_id": int64(9),
+			},
+		},
+		{
+			name: "ExternalWorkflowExecutionSignaled",
+			attributes: &history.HistoryEvent_ExternalWorkflowExecutionSignaledEventAttributes{ExternalWorkflowExecutionSignaledEventAttributes: &history.ExternalWorkflowExecutionSignaledEventAttributes{
+				WorkflowExecution: externalExecution,
+				InitiatedEventId:  9,
+			}},
+			want: map[string]interface{}{"workflow_id": "other-1", "run_id": "other-run", "initiated_event_id": int64(9)},
+		},
+		{
+			name: "StartChildWorkflowExecutionInitiated",
+			attributes: &history.HistoryEvent_StartChildWorkflowExecutionInitiatedEventAttributes{StartChildWorkflowExecutionInitiatedEventAttributes: &history.StartChildWorkflowExecutionInitiatedEventAttributes{
+				Namespace:         "default",
+				WorkflowId:        "child-1",
+				WorkflowType:      childType,
+				TaskQueue:         &taskqueue.TaskQueue{Name: "children"},
+				Input:             payloads(`"child input"`),
+				ParentClosePolicy: enums.PARENT_CLOSE_POLICY_ABANDON,
+			}},
+			want: map[string]interface{}{
+				"namespace":           "default",
+				"workflow_id":         "child-1",
+				"workflow_type":       "ChildWorkflow",
+				"task_queue":          "children",
+				"input_part_0":        "child input",
+				"parent_close_policy": "Abandon",
+			},
+		},
+		{
+			name: "StartChildWorkflowExecutionFailed",
+			attributes: &history.HistoryEvent_StartChildWorkflowExecutionFailedEventAttributes{StartChildWorkflowExecutionFailedEventAttributes: &history.StartChildWorkflowExecutionFailedEventAttributes{
+				WorkflowId:       "child-1",
+				WorkflowType:     childType,
+				Cause:            enums.START_CHILD_WORKFLOW_EXECUTION_FAILED_CAUSE_WORKFLOW_ALREADY_EXISTS,
+				InitiatedEventId: 10,
+			}},
+			want: map[string]interface{}{
+				"workflow_id":        "child-1",
+				"workflow_type":      "ChildWorkflow",
+				"cause":              "WorkflowAlreadyExists",
+				"initiated_event_id": int64(10),
+			},
+		},
+		{
+			name: "ChildWorkflowExecutionStarted",
+			attributes: &history.HistoryEvent_ChildWorkflowExecutionStartedEventAttributes{ChildWorkflowExecutionStartedEventAttributes: &history.ChildWorkflowExecutionStartedEventAttributes{
+				WorkflowExecution: childExecution,
+				WorkflowType:      childType,
+				InitiatedEventId:  10,
+			}},
+			want: map[string]interface{}{"workflow_id": "child-1", "run_id": "child-run", "workflow_type": "ChildWorkflow", "initiated_event_id": int64(10)},
+		},
+		{
+			name: "ChildWorkflowExecutionCompleted",
+			attributes: &history.HistoryEvent_ChildWorkflowExecutionCompletedEventAttributes{ChildWorkflowExecutionCompletedEventAttributes: &history.ChildWorkflowExecutionCompletedEventAttributes{
+				Result:            payloads(`"child done"`),
+				WorkflowExecution: childExecution,
+				WorkflowType:      childType,
+				InitiatedEventId:  10,
+				StartedEventId:    11,
+			}},
+			want: map[string]interface{}{
+				"input_part_0":       "child done",
+				"workflow_id":        "child-1",
+				"run_id":             "child-run",
+				"workflow_type":      "ChildWorkflow",
+				"initiated_event_id": int64(10),
+				"started_event_id":   int64(11),
+			},
+		},
+		{
+			name: "ChildWorkflowExecutionFailed",
+			attributes: &history.HistoryEvent_ChildWorkflowExecutionFailedEventAttributes{ChildWorkflowExecutionFailedEventAttributes: &history.ChildWorkflowExecutionFailedEventAttributes{
+				Failure:           &failure.Failure{Message: "child broke"},
+				WorkflowExecution: childExecution,
+				WorkflowType:      childType,
+				InitiatedEventId:  10,
+				StartedEventId:    11,
+				RetryState:        enums.RETRY_STATE_NON_RETRYABLE_FAILURE,
+			}},
+			want: map[string]interface{}{
+				"error":              "child broke",
+				"failure":            &Failure{Message: "child broke"},
+				"workflow_id":        "child-1",
+				"run_id":             "child-run",
+				"workflow_type":      "ChildWorkflow",
+				"initiated_event_id": int64(10),
+				"started_event_id":   int64(11),
+				"retry_state":        "NonRetryableFailure",
+			},
+		},
+		{
+			name: "ChildWorkflowExecutionCanceled",
+			attributes: &history.HistoryEvent_ChildWorkflowExecutionCanceledEventAttributes{ChildWorkflowExecutionCanceledEventAttributes: &history.ChildWorkflowExecutionCanceledEventAttributes{
+				WorkflowExecution: childExecution,
+				WorkflowType:      childType,
+				InitiatedEventId:  10,
+				StartedEventId:    11,
+			}},
+			want: map[string]interface{}{
+				"workflow_id":        "child-1",
+				"run_id":             "child-run",
+				"workflow_type":      "ChildWorkflow",
+				"initiated_event_id": int64(10),
+				"started_event_id":   int64(11),
+			},
+		},
+		{
+			name: "ChildWorkflowExecutionTimedOut",
+			attributes: &history.HistoryEvent_ChildWorkflowExecutionTimedOutEventAttributes{ChildWorkflowExecutionTimedOutEventAttributes: &history.ChildWorkflowExecutionTimedOutEventAttributes{
+				WorkflowExecution: childExecution,
+				WorkflowType:      childType,
+				InitiatedEventId:  10,
+				StartedEventId:    11,
+				RetryState:        enums.RETRY_STATE_TIMEOUT,
+			}},
+			want: map[string]interface{}{
+				"workflow_id":        "child-1",
+				"run_id":             "child-run",
+				"workflow_type":      "ChildWorkflow",
+				"initiated_event_id": int64(10),
+				"started_event_id":   int64(11),
+				"retry_state":        "Timeout",
+			},
+		},
+		{
+			name: "ChildWorkflowExecutionTerminated",
+			attributes: &history.HistoryEvent_ChildWorkflowExecutionTerminatedEventAttributes{ChildWorkflowExecutionTerminatedEventAttributes: &history.ChildWorkflowExecutionTerminatedEventAttributes{
+				WorkflowExecution: childExecution,
+				WorkflowType:      childType,
+				InitiatedEventId:  10,
+				StartedEventId:    11,
+			}},
+			want: map[string]interface{}{
+				"workflow_id":        "child-1",
+				"run_id":             "child-run",
+				"workflow_type":      "ChildWorkflow",
+				"initiated_event_id": int64(10),
+				"started_event_id":   int64(11),
+			},
+		},
+		{
+			name: "UpsertWorkflowSearchAttributes",
+			attributes: &history.HistoryEvent_UpsertWorkflowSearchAttributesEventAttributes{UpsertWorkflowSearchAttributesEventAttributes: &history.UpsertWorkflowSearchAttributesEventAttributes{
+				SearchAttributes: &common.SearchAttributes{IndexedFields: map[string]*common.Payload{"Stage": {Data: []byte(`"shipping"`)}}},
+			}},
+			want: map[string]interface{}{"search_attributes": map[string]interface{}{"Stage": "shipping"}},
+		},
+		{
+			name: "WorkflowPropertiesModified",
+			attributes: &history.HistoryEvent_WorkflowPropertiesModifiedEventAttributes{WorkflowPropertiesModifiedEventAttributes: &history.WorkflowPropertiesModifiedEventAttributes{
+				UpsertedMemo: &common.Memo{Fields: map[string]*common.Payload{"note": {Data: []byte(`"x"`)}}},
+			}},
+			want: map[string]interface{}{"memo": map[string]interface{}{"note": "x"}},
+		},
+		{
+			name: "WorkflowPropertiesModifiedExternally",
+			attributes: &history.HistoryEvent_WorkflowPropertiesModifiedExternallyEventAttributes{WorkflowPropertiesModifiedExternallyEventAttributes: &history.WorkflowPropertiesModifiedExternallyEventAttributes{
+				NewTaskQueue:          "orders-v2",
+				NewWorkflowRunTimeout: durationPtr(2 * time.Hour),
+			}},
+			want: map[string]interface{}{"task_queue": "orders-v2", "run_timeout": "2h0m0s"},
+		},
+		{
+			name: "WorkflowExecutionUpdateAccepted",
+			attributes: &history.HistoryEvent_WorkflowExecutionUpdateAcceptedEventAttributes{WorkflowExecutionUpdateAcceptedEventAttributes: &history.WorkflowExecutionUpdateAcceptedEventAttributes{
+				AcceptedRequest: &update.Request{
+					Meta:  &update.Meta{UpdateId: "u-1", Identity: "cli"},
+					Input: &update.Input{Name: "setPrice", Args: payloads(`10`)},
+				},
+			}},
+			want: map[string]interface{}{"update_id": "u-1", "identity": "cli", "update_name": "setPrice", "input_part_0": float64(10)},
+		},
+		{
+			name: "WorkflowExecutionUpdateRejected",
+			attributes: &history.HistoryEvent_WorkflowExecutionUpdateRejectedEventAttributes{WorkflowExecutionUpdateRejectedEventAttributes: &history.WorkflowExecutionUpdateRejectedEventAttributes{
+				RejectedRequest: &update.Request{
+					Meta:  &update.Meta{UpdateId: "u-2"},
+					Input: &update.Input{Name: "setPrice", Args: payloads(`-1`)},
+				},
+				Failure: &failure.Failure{Message: "price must be positive"},
+			}},
+			want: map[string]interface{}{"update_id": "u-2", "update_name": "setPrice", "input_part_0": float64(-1), "rejection": &Failure{Message: "price must be positive"}},
+		},
+		{
+			name: "WorkflowExecutionUpdateCompleted",
+			attributes: &history.HistoryEvent_WorkflowExecutionUpdateCompletedEventAttributes{WorkflowExecutionUpdateCompletedEventAttributes: &history.WorkflowExecutionUpdateCompletedEventAttributes{
+				Meta:    &update.Meta{UpdateId: "u-1"},
+				Outcome: &update.Outcome{Value: &update.Outcome_Success{Success: payloads(`"accepted"`)}},
+			}},
+			want: map[string]interface{}{"update_id": "u-1", "input_part_0": "accepted"},
+		},
+	}
+
+	now := time.Now()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			event := &history.HistoryEvent{
+				EventId:   12,
+				EventTime: &now,
+				EventType: enums.EventType(enums.EventType_value[tt.name]),
+			}
+			reflect.ValueOf(event).Elem().FieldByName("Attributes").Set(reflect.ValueOf(tt.attributes))
+
+			formattedEvent, keep := FormatEvent(event, false)
+			assert.True(t, keep)
+			assert.Equal(t, tt.name, formattedEvent.Type)
+			assert.Equal(t, tt.want, formattedEvent.Details)
+		})
+	}
+
+	// Every attribute variant in the pinned API must have a formatter.
+	covered := make(map[reflect.Type]bool)
+	for _, tt := range tests {
+		covered[reflect.TypeOf(tt.attributes)] = true
+	}
+	for _, wrapper := range (*history.HistoryEvent)(nil).XXX_OneofWrappers() {
+		assert.True(t, covered[reflect.TypeOf(wrapper)], "no test case for %T", wrapper)
+	}
+}
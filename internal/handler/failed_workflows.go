@@ -3,63 +3,304 @@ package handler
 import (
 	"context"
 	"fmt"
+	"strings"
+	"sync"
+	"time"
 
 	"go.temporal.io/api/enums/v1"
-	"go.temporal.io/api/workflowservice/v1"
+	"go.temporal.io/api/history/v1"
+	"go.temporal.io/api/workflow/v1"
 	"go.temporal.io/sdk/client"
 )
 
+const (
+	FailedScopeAll     = "all"
+	FailedScopeRunning = "running"
+	FailedScopeClosed  = "closed"
+
+	defaultFailedMaxWorkflows = 200
+	defaultFailedConcurrency  = 10
+	maxFailedConcurrency      = 50
+)
+
+type FailedWorkflowsArgs struct {
+	WorkflowType string `json:"workflow_type,omitempty" jsonschema:"description=Only consider workflows of this type"`
+	StartTime    string `json:"start_time,omitempty" jsonschema:"description=RFC3339 start of the time window"`
+	EndTime      string `json:"end_time,omitempty" jsonschema:"description=RFC3339 end of the time window"`
+	Lookback     string `json:"lookback,omitempty" jsonschema:"description=Duration such as 24h used as the window start when start_time is not set"`
+	Scope        string `json:"scope,omitempty" jsonschema:"description=Which workflows to scan: all, running or closed"`
+	MaxWorkflows int    `json:"max_workflows,omitempty" jsonschema:"description=Maximum number of workflows to scan per scope"`
+	Group        bool   `json:"group,omitempty" jsonschema:"description=Cluster failures by workflow type, activity type and normalized error message"`
+	Concurrency  int    `json:"-"`
+}
+
 type FailedWorkflow struct {
-	WorkflowID string  `json:"workflow_id"`
-	RunID      string  `json:"run_id"`
-	Error      string  `json:"error"`
-	Summary    []Event `json:"summary"`
+	WorkflowID   string  `json:"workflow_id"`
+	RunID        string  `json:"run_id"`
+	Type         string  `json:"type"`
+	Status       string  `json:"status"`
+	ActivityType string  `json:"activity_type,omitempty"`
+	Error        string  `json:"error"`
+	ErrorTime    string  `json:"error_time,omitempty"`
+	Summary      []Event `json:"summary"`
 }
 
 type FailedWorkflowsResponse struct {
-	Workflows []FailedWorkflow `json:"workflows"`
+	Workflows []FailedWorkflow `json:"workflows,omitempty"`
+	Groups    []FailureGroup   `json:"groups,omitempty"`
+	Errors    []string         `json:"errors,omitempty"`
+	Summary   string           `json:"summary"`
 }
 
-func GetFailedWorkflowsHandler(ctx context.Context, temporalClient client.Client) (FailedWorkflowsResponse, error) {
-	var failedWorkflows []FailedWorkflow
+// timeWindow bounds a failed workflow search. A zero Start or End leaves that
+// side of the window open.
+type timeWindow struct {
+	Start time.Time
+	End   time.Time
+}
+
+func (w timeWindow) contains(t time.Time) bool {
+	if !w.Start.IsZero() && t.Before(w.Start) {
+		return false
+	}
+	if !w.End.IsZero() && t.After(w.End) {
+		return false
+	}
+	return true
+}
 
-	// 1. List open workflow executions
-	resp, err := temporalClient.ListOpenWorkflow(ctx, &workflowservice.ListOpenWorkflowExecutionsRequest{})
+func (args FailedWorkflowsArgs) window(now time.Time) (timeWindow, error) {
+	var w timeWindow
+	var err error
+	if args.StartTime != "" {
+		if w.Start, err = time.Parse(time.RFC3339, args.StartTime); err != nil {
+			return timeWindow{}, fmt.Errorf("invalid start_time: %w", err)
+		}
+	} else if args.Lookback != "" {
+		d, err := time.ParseDuration(args.Lookback)
+		if err != nil {
+			return timeWindow{}, fmt.Errorf("invalid lookback: %w", err)
+		}
+		w.Start = now.Add(-d)
+	}
+	if args.EndTime != "" {
+		if w.End, err = time.Parse(time.RFC3339, args.EndTime); err != nil {
+			return timeWindow{}, fmt.Errorf("invalid end_time: %w", err)
+		}
+	}
+	return w, nil
+}
+
+// GetFailedWorkflowsHandler finds running workflows whose history contains an
+// error event, and closed workflows that failed or timed out. Candidates come
+// from visibility queries and their histories are fetched concurrently.
+func GetFailedWorkflowsHandler(ctx context.Context, temporalClient client.Client, args FailedWorkflowsArgs) (FailedWorkflowsResponse, error) {
+	window, err := args.window(time.Now())
 	if err != nil {
-		return FailedWorkflowsResponse{}, fmt.Errorf("failed to list open workflows: %w", err)
+		return FailedWorkflowsResponse{}, err
 	}
 
-	for _, wf := range resp.Executions {
-		iter := temporalClient.GetWorkflowHistory(ctx, wf.Execution.GetWorkflowId(), wf.Execution.GetRunId(), false, enums.HISTORY_EVENT_FILTER_TYPE_ALL_EVENT)
+	scope := args.Scope
+	if scope == "" {
+		scope = FailedScopeAll
+	}
+	if scope != FailedScopeAll && scope != FailedScopeRunning && scope != FailedScopeClosed {
+		return FailedWorkflowsResponse{}, fmt.Errorf("invalid scope %q (expected all, running or closed)", scope)
+	}
 
-		var summaryEvents []Event
-		var errorMsg string
+	maxWorkflows := args.MaxWorkflows
+	if maxWorkflows <= 0 {
+		maxWorkflows = defaultFailedMaxWorkflows
+	}
 
-		for iter.HasNext() {
-			evt, err := iter.Next()
-			if err != nil {
-				// Continue to next workflow if history is not available
-				break
-			}
+	var typeClause string
+	if args.WorkflowType != "" {
+		typeClause = "WorkflowType = " + quoteQueryValue(args.WorkflowType)
+	}
+
+	var candidates []*workflow.WorkflowExecutionInfo
+	var truncated []string
+
+	if scope != FailedScopeClosed {
+		// Running workflows can only be matched on when they started; the
+		// window is applied to their error events once histories are read.
+		var startedBefore string
+		if !window.End.IsZero() {
+			startedBefore = "StartTime <= " + quoteQueryValue(window.End.UTC().Format(time.RFC3339Nano))
+		}
+		query := buildQuery("ExecutionStatus = 'Running'", typeClause, startedBefore)
+		running, more, err := listExecutions(ctx, temporalClient, query, maxWorkflows)
+		if err != nil {
+			return FailedWorkflowsResponse{}, fmt.Errorf("failed to list running workflows: %w", err)
+		}
+		candidates = append(candidates, running...)
+		if more {
+			truncated = append(truncated, "running")
+		}
+	}
+
+	if scope != FailedScopeRunning {
+		var closedAfter, closedBefore string
+		if !window.Start.IsZero() {
+			closedAfter = "CloseTime >= " + quoteQueryValue(window.Start.UTC().Format(time.RFC3339Nano))
+		}
+		if !window.End.IsZero() {
+			closedBefore = "CloseTime <= " + quoteQueryValue(window.End.UTC().Format(time.RFC3339Nano))
+		}
+		query := buildQuery("(ExecutionStatus = 'Failed' OR ExecutionStatus = 'TimedOut')", typeClause, closedAfter, closedBefore)
+		closed, more, err := listExecutions(ctx, temporalClient, query, maxWorkflows)
+		if err != nil {
+			return FailedWorkflowsResponse{}, fmt.Errorf("failed to list closed workflows: %w", err)
+		}
+		candidates = append(candidates, closed...)
+		if more {
+			truncated = append(truncated, "closed")
+		}
+	}
+
+	concurrency := args.Concurrency
+	if concurrency <= 0 {
+		concurrency = defaultFailedConcurrency
+	}
+	if concurrency > maxFailedConcurrency {
+		concurrency = maxFailedConcurrency
+	}
 
-			if formatted, keep := FormatEvent(evt, true); keep {
-				summaryEvents = append(summaryEvents, formatted)
-				if errVal, ok := formatted.Details["error"]; ok {
-					if errMsg, isString := errVal.(string); isString {
-						errorMsg = errMsg
-					}
+	results := make([]*FailedWorkflow, len(candidates))
+	errs := make([]error, len(candidates))
+	var wg sync.WaitGroup
+	work := make(chan int)
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for idx := range work {
+				fw, ok, err := inspectWorkflow(ctx, temporalClient, candidates[idx], window)
+				if err != nil {
+					errs[idx] = err
+				} else if ok {
+					results[idx] = &fw
 				}
 			}
+		}()
+	}
+
+dispatch:
+	for i := range candidates {
+		select {
+		case work <- i:
+		case <-ctx.Done():
+			break dispatch
+		}
+	}
+	close(work)
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return FailedWorkflowsResponse{}, fmt.Errorf("failed workflow scan interrupted: %w", err)
+	}
+
+	failedWorkflows := make([]FailedWorkflow, 0)
+	var inspectErrors []string
+	for i, fw := range results {
+		if errs[i] != nil {
+			inspectErrors = append(inspectErrors, fmt.Sprintf("%s: %v", candidates[i].GetExecution().GetWorkflowId(), errs[i]))
+			continue
+		}
+		if fw != nil {
+			failedWorkflows = append(failedWorkflows, *fw)
+		}
+	}
+
+	summary := fmt.Sprintf("Scanned %d workflows and found %d failing.", len(candidates), len(failedWorkflows))
+	if len(inspectErrors) > 0 {
+		summary += fmt.Sprintf(" %d workflows could not be inspected.", len(inspectErrors))
+	}
+	if len(truncated) > 0 {
+		summary += fmt.Sprintf(" Stopped after %d workflows per scope (%s had more); narrow the time window or workflow type to see the rest.", maxWorkflows, strings.Join(truncated, " and "))
+	}
+
+	if args.Group {
+		groups := groupFailedWorkflows(failedWorkflows)
+		summary += fmt.Sprintf(" Failures fall into %d groups.", len(groups))
+		return FailedWorkflowsResponse{Groups: groups, Errors: inspectErrors, Summary: summary}, nil
+	}
+
+	return FailedWorkflowsResponse{Workflows: failedWorkflows, Errors: inspectErrors, Summary: summary}, nil
+}
+
+// inspectWorkflow reads enough history to decide whether the execution is
+// failing. Closed executions only need their close event; running ones are
+// scanned in full for the most recent error inside the window.
+func inspectWorkflow(ctx context.Context, temporalClient client.Client, info *workflow.WorkflowExecutionInfo, window timeWindow) (FailedWorkflow, bool, error) {
+	filter := enums.HISTORY_EVENT_FILTER_TYPE_ALL_EVENT
+	running := info.GetStatus() == enums.WORKFLOW_EXECUTION_STATUS_RUNNING
+	if !running {
+		filter = enums.HISTORY_EVENT_FILTER_TYPE_CLOSE_EVENT
+	}
+
+	iter := temporalClient.GetWorkflowHistory(ctx, info.GetExecution().GetWorkflowId(), info.GetExecution().GetRunId(), false, filter)
+
+	var summaryEvents []Event
+	var errorMsg, errorTime, activityType string
+	scheduledActivities := make(map[int64]string)
+
+	for iter.HasNext() {
+		evt, err := iter.Next()
+		if err != nil {
+			return FailedWorkflow{}, false, fmt.Errorf("failed to read history: %w", err)
 		}
-		if errorMsg != "" {
-			failedWorkflows = append(failedWorkflows, FailedWorkflow{
-				WorkflowID: wf.Execution.GetWorkflowId(),
-				RunID:      wf.Execution.GetRunId(),
-				Error:      errorMsg,
-				Summary:    summaryEvents,
-			})
+
+		if attr := evt.GetActivityTaskScheduledEventAttributes(); attr != nil {
+			scheduledActivities[evt.GetEventId()] = attr.GetActivityType().GetName()
+		}
+
+		if formatted, keep := FormatEvent(evt, true); keep {
+			summaryEvents = append(summaryEvents, formatted)
+			if t := evt.GetEventTime(); running && t != nil && !window.contains(*t) {
+				continue
+			}
+			if errVal, ok := formatted.Details["error"]; ok {
+				if errMsg, isString := errVal.(string); isString {
+					errorMsg = errMsg
+					errorTime = formatted.Timestamp
+					activityType = failedActivityType(evt, scheduledActivities)
+				}
+			}
 		}
 	}
 
-	return FailedWorkflowsResponse{Workflows: failedWorkflows}, nil
+	if errorMsg == "" && info.GetStatus() == enums.WORKFLOW_EXECUTION_STATUS_TIMED_OUT {
+		errorMsg = "workflow execution timed out"
+		errorTime = formatTime(info.GetCloseTime())
+	}
+	if errorMsg == "" {
+		return FailedWorkflow{}, false, nil
+	}
+
+	return FailedWorkflow{
+		WorkflowID:   info.GetExecution().GetWorkflowId(),
+		RunID:        info.GetExecution().GetRunId(),
+		Type:         info.GetType().GetName(),
+		Status:       info.GetStatus().String(),
+		ActivityType: activityType,
+		Error:        errorMsg,
+		ErrorTime:    errorTime,
+		Summary:      summaryEvents,
+	}, true, nil
+}
+
+// failedActivityType resolves which activity an error event belongs to, either
+// through its scheduled event or from the activity failure in its cause chain.
+func failedActivityType(evt *history.HistoryEvent, scheduledActivities map[int64]string) string {
+	if attr := evt.GetActivityTaskFailedEventAttributes(); attr != nil {
+		if name, ok := scheduledActivities[attr.GetScheduledEventId()]; ok {
+			return name
+		}
+		return activityTypeFromFailure(attr.GetFailure())
+	}
+	if attr := evt.GetWorkflowExecutionFailedEventAttributes(); attr != nil {
+		return activityTypeFromFailure(attr.GetFailure())
+	}
+	return ""
 }
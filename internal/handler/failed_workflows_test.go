@@ -2,6 +2,7 @@ package handler_test
 
 import (
 	"context"
+	"fmt"
 	"testing"
 	"time"
 
@@ -48,11 +49,23 @@ func (s *HandlerSuite) TestGetFailedWorkflowsHandler() {
 	historyEvent := &history.HistoryEvent{
 		EventId:   1,
 		EventTime: &now,
+		EventType: enums.EVENT_TYPE_ACTIVITY_TASK_FAILED,
+		Attributes: &history.HistoryEvent_ActivityTaskFailedEventAttributes{
+			ActivityTaskFailedEventAttributes: &history.ActivityTaskFailedEventAttributes{
+				Failure: &failure.Failure{
+					Message: "test error",
+				},
+			},
+		},
+	}
+	closeEvent := &history.HistoryEvent{
+		EventId:   9,
+		EventTime: &now,
 		EventType: enums.EVENT_TYPE_WORKFLOW_EXECUTION_FAILED,
 		Attributes: &history.HistoryEvent_WorkflowExecutionFailedEventAttributes{
 			WorkflowExecutionFailedEventAttributes: &history.WorkflowExecutionFailedEventAttributes{
 				Failure: &failure.Failure{
-					Message: "test error",
+					Message: "closed error",
 				},
 			},
 		},
@@ -63,26 +76,198 @@ func (s *HandlerSuite) TestGetFailedWorkflowsHandler() {
 	historyIterator.On("HasNext").Return(false)
 	historyIterator.On("Next").Return(historyEvent, nil).Once()
 
+	closeIterator := &MockHistoryIterator{}
+	closeIterator.On("HasNext").Return(true).Once()
+	closeIterator.On("HasNext").Return(false)
+	closeIterator.On("Next").Return(closeEvent, nil).Once()
+
 	s.client.On("GetWorkflowHistory", mock.Anything, "test-workflow-id", "test-run-id", false, enums.HISTORY_EVENT_FILTER_TYPE_ALL_EVENT).Return(historyIterator)
+	s.client.On("GetWorkflowHistory", mock.Anything, "closed-workflow-id", "closed-run-id", false, enums.HISTORY_EVENT_FILTER_TYPE_CLOSE_EVENT).Return(closeIterator)
 
-	s.client.On("ListOpenWorkflow", mock.Anything, mock.Anything).Return(&workflowservice.ListOpenWorkflowExecutionsResponse{
+	s.client.On("ListWorkflow", mock.Anything, mock.MatchedBy(func(req *workflowservice.ListWorkflowExecutionsRequest) bool {
+		return req.Query == "ExecutionStatus = 'Running' AND WorkflowType = 'Order'"
+	})).Return(&workflowservice.ListWorkflowExecutionsResponse{
 		Executions: []*workflow.WorkflowExecutionInfo{
 			{
 				Execution: &common.WorkflowExecution{
 					WorkflowId: "test-workflow-id",
 					RunId:      "test-run-id",
 				},
+				Type:   &common.WorkflowType{Name: "Order"},
+				Status: enums.WORKFLOW_EXECUTION_STATUS_RUNNING,
+			},
+		},
+	}, nil)
+
+	s.client.On("ListWorkflow", mock.Anything, mock.MatchedBy(func(req *workflowservice.ListWorkflowExecutionsRequest) bool {
+		return req.Query == "(ExecutionStatus = 'Failed' OR ExecutionStatus = 'TimedOut') AND WorkflowType = 'Order'"
+	})).Return(&workflowservice.ListWorkflowExecutionsResponse{
+		Executions: []*workflow.WorkflowExecutionInfo{
+			{
+				Execution: &common.WorkflowExecution{
+					WorkflowId: "closed-workflow-id",
+					RunId:      "closed-run-id",
+				},
+				Type:   &common.WorkflowType{Name: "Order"},
+				Status: enums.WORKFLOW_EXECUTION_STATUS_FAILED,
 			},
 		},
 	}, nil)
 
-	resp, err := handler.GetFailedWorkflowsHandler(context.Background(), s.client)
+	resp, err := handler.GetFailedWorkflowsHandler(context.Background(), s.client, handler.FailedWorkflowsArgs{WorkflowType: "Order"})
 	s.NoError(err)
-	s.Len(resp.Workflows, 1)
+	s.Len(resp.Workflows, 2)
 	s.Equal("test-workflow-id", resp.Workflows[0].WorkflowID)
 	s.Equal("test-run-id", resp.Workflows[0].RunID)
 	s.Equal("test error", resp.Workflows[0].Error)
+	s.Equal("Running", resp.Workflows[0].Status)
 	s.Len(resp.Workflows[0].Summary, 1)
+	s.Equal("closed-workflow-id", resp.Workflows[1].WorkflowID)
+	s.Equal("closed error", resp.Workflows[1].Error)
+	s.Equal("Failed", resp.Workflows[1].Status)
+	s.Equal("Scanned 2 workflows and found 2 failing.", resp.Summary)
+}
+
+func (s *HandlerSuite) TestGetFailedWorkflowsHandler_ClosedWindowAndTimeout() {
+	emptyIterator := &MockHistoryIterator{}
+	emptyIterator.On("HasNext").Return(false)
+
+	s.client.On("GetWorkflowHistory", mock.Anything, "timed-out-id", "timed-out-run", false, enums.HISTORY_EVENT_FILTER_TYPE_CLOSE_EVENT).Return(emptyIterator)
+	s.client.On("ListWorkflow", mock.Anything, &workflowservice.ListWorkflowExecutionsRequest{
+		PageSize: 5,
+		Query:    "(ExecutionStatus = 'Failed' OR ExecutionStatus = 'TimedOut') AND CloseTime >= '2024-01-01T00:00:00Z' AND CloseTime <= '2024-01-02T00:00:00Z'",
+	}).Return(&workflowservice.ListWorkflowExecutionsResponse{
+		Executions: []*workflow.WorkflowExecutionInfo{
+			{
+				Execution: &common.WorkflowExecution{WorkflowId: "timed-out-id", RunId: "timed-out-run"},
+				Status:    enums.WORKFLOW_EXECUTION_STATUS_TIMED_OUT,
+			},
+		},
+		NextPageToken: []byte("more"),
+	}, nil)
+	s.client.On("ListWorkflow", mock.Anything, mock.MatchedBy(func(req *workflowservice.ListWorkflowExecutionsRequest) bool {
+		return string(req.NextPageToken) == "more" && req.PageSize == 4
+	})).Return(&workflowservice.ListWorkflowExecutionsResponse{}, nil)
+
+	resp, err := handler.GetFailedWorkflowsHandler(context.Background(), s.client, handler.FailedWorkflowsArgs{
+		Scope:        handler.FailedScopeClosed,
+		StartTime:    "2024-01-01T00:00:00Z",
+		EndTime:      "2024-01-02T00:00:00Z",
+		MaxWorkflows: 5,
+	})
+	s.NoError(err)
+	s.Len(resp.Workflows, 1)
+	s.Equal("workflow execution timed out", resp.Workflows[0].Error)
+	s.Equal("TimedOut", resp.Workflows[0].Status)
+	s.client.AssertExpectations(s.T())
+}
+
+func (s *HandlerSuite) TestGetFailedWorkflowsHandler_Grouped() {
+	now := time.Now()
+	scheduled := &history.HistoryEvent{
+		EventId:   5,
+		EventTime: &now,
+		EventType: enums.EVENT_TYPE_ACTIVITY_TASK_SCHEDULED,
+		Attributes: &history.HistoryEvent_ActivityTaskScheduledEventAttributes{
+			ActivityTaskScheduledEventAttributes: &history.ActivityTaskScheduledEventAttributes{
+				ActivityType: &common.ActivityType{Name: "Charge"},
+			},
+		},
+	}
+
+	var executions []*workflow.WorkflowExecutionInfo
+	for i, id := range []string{"wf-1", "wf-2"} {
+		failed := &history.HistoryEvent{
+			EventId:   7,
+			EventTime: &now,
+			EventType: enums.EVENT_TYPE_ACTIVITY_TASK_FAILED,
+			Attributes: &history.HistoryEvent_ActivityTaskFailedEventAttributes{
+				ActivityTaskFailedEventAttributes: &history.ActivityTaskFailedEventAttributes{
+					ScheduledEventId: 5,
+					Failure:          &failure.Failure{Message: fmt.Sprintf("card %d declined", 1000+i)},
+				},
+			},
+		}
+		iter := &MockHistoryIterator{}
+		iter.On("HasNext").Return(true).Twice()
+		iter.On("HasNext").Return(false)
+		iter.On("Next").Return(scheduled, nil).Once()
+		iter.On("Next").Return(failed, nil).Once()
+		s.client.On("GetWorkflowHistory", mock.Anything, id, "run", false, enums.HISTORY_EVENT_FILTER_TYPE_ALL_EVENT).Return(iter)
+
+		executions = append(executions, &workflow.WorkflowExecutionInfo{
+			Execution: &common.WorkflowExecution{WorkflowId: id, RunId: "run"},
+			Type:      &common.WorkflowType{Name: "Order"},
+			Status:    enums.WORKFLOW_EXECUTION_STATUS_RUNNING,
+		})
+	}
+	s.client.On("ListWorkflow", mock.Anything, mock.Anything).Return(&workflowservice.ListWorkflowExecutionsResponse{Executions: executions}, nil)
+
+	resp, err := handler.GetFailedWorkflowsHandler(context.Background(), s.client, handler.FailedWorkflowsArgs{
+		Scope: handler.FailedScopeRunning,
+		Group: true,
+	})
+	s.NoError(err)
+	s.Empty(resp.Workflows)
+	s.Len(resp.Groups, 1)
+	s.Equal("Charge", resp.Groups[0].ActivityType)
+	s.Equal("card <n> declined", resp.Groups[0].Signature)
+	s.Equal(2, resp.Groups[0].Count)
+	s.ElementsMatch([]string{"wf-1", "wf-2"}, resp.Groups[0].SampleWorkflowIDs)
+	s.Equal("Scanned 2 workflows and found 2 failing. Failures fall into 1 groups.", resp.Summary)
+}
+
+func (s *HandlerSuite) TestGetFailedWorkflowsHandler_HistoryErrors() {
+	now := time.Now()
+	closeEvent := &history.HistoryEvent{
+		EventId:   9,
+		EventTime: &now,
+		EventType: enums.EVENT_TYPE_WORKFLOW_EXECUTION_FAILED,
+		Attributes: &history.HistoryEvent_WorkflowExecutionFailedEventAttributes{
+			WorkflowExecutionFailedEventAttributes: &history.WorkflowExecutionFailedEventAttributes{
+				Failure: &failure.Failure{Message: "closed error"},
+			},
+		},
+	}
+	closeIterator := &MockHistoryIterator{}
+	closeIterator.On("HasNext").Return(true).Once()
+	closeIterator.On("HasNext").Return(false)
+	closeIterator.On("Next").Return(closeEvent, nil).Once()
+	throttledIterator := &MockHistoryIterator{}
+	throttledIterator.On("HasNext").Return(true)
+	throttledIterator.On("Next").Return((*history.HistoryEvent)(nil), fmt.Errorf("resource exhausted")).Once()
+
+	s.client.On("GetWorkflowHistory", mock.Anything, "failed-id", "run", false, enums.HISTORY_EVENT_FILTER_TYPE_CLOSE_EVENT).Return(closeIterator)
+	s.client.On("GetWorkflowHistory", mock.Anything, "throttled-id", "run", false, enums.HISTORY_EVENT_FILTER_TYPE_CLOSE_EVENT).Return(throttledIterator)
+	s.client.On("ListWorkflow", mock.Anything, mock.Anything).Return(&workflowservice.ListWorkflowExecutionsResponse{
+		Executions: []*workflow.WorkflowExecutionInfo{
+			{Execution: &common.WorkflowExecution{WorkflowId: "failed-id", RunId: "run"}, Status: enums.WORKFLOW_EXECUTION_STATUS_FAILED},
+			{Execution: &common.WorkflowExecution{WorkflowId: "throttled-id", RunId: "run"}, Status: enums.WORKFLOW_EXECUTION_STATUS_FAILED},
+		},
+	}, nil)
+
+	resp, err := handler.GetFailedWorkflowsHandler(context.Background(), s.client, handler.FailedWorkflowsArgs{Scope: handler.FailedScopeClosed})
+	s.NoError(err)
+	s.Len(resp.Workflows, 1)
+	s.Equal("failed-id", resp.Workflows[0].WorkflowID)
+	s.Equal([]string{"throttled-id: failed to read history: resource exhausted"}, resp.Errors)
+	s.Equal("Scanned 2 workflows and found 1 failing. 1 workflows could not be inspected.", resp.Summary)
+}
+
+func (s *HandlerSuite) TestGetFailedWorkflowsHandler_InvalidArgs() {
+	_, err := handler.GetFailedWorkflowsHandler(context.Background(), s.client, handler.FailedWorkflowsArgs{Scope: "everything"})
+	s.ErrorContains(err, "invalid scope")
+
+	_, err = handler.GetFailedWorkflowsHandler(context.Background(), s.client, handler.FailedWorkflowsArgs{Lookback: "yesterday"})
+	s.ErrorContains(err, "invalid lookback")
+}
+
+func (s *HandlerSuite) TestGetFailedWorkflowsHandler_Canceled() {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := handler.GetFailedWorkflowsHandler(ctx, s.client, handler.FailedWorkflowsArgs{})
+	s.ErrorIs(err, context.Canceled)
 }
 
 func (s *HandlerSuite) TestFormatEvent_WorkflowExecutionFailed() {
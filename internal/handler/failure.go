@@ -0,0 +1,114 @@
+package handler
+
+import (
+	"go.temporal.io/api/failure/v1"
+)
+
+// maxFailureDepth bounds how far a cause chain is followed.
+const maxFailureDepth = 20
+
+// Failure is a readable form of a Temporal failure and its cause chain.
+type Failure struct {
+	Message         string        `json:"message"`
+	Type            string        `json:"type,omitempty"`
+	Source          string        `json:"source,omitempty"`
+	ApplicationType string        `json:"application_type,omitempty"`
+	NonRetryable    bool          `json:"non_retryable,omitempty"`
+	TimeoutType     string        `json:"timeout_type,omitempty"`
+	RetryState      string        `json:"retry_state,omitempty"`
+	ActivityType    string        `json:"activity_type,omitempty"`
+	ActivityID      string        `json:"activity_id,omitempty"`
+	WorkflowID      string        `json:"workflow_id,omitempty"`
+	WorkflowType    string        `json:"workflow_type,omitempty"`
+	Details         []interface{} `json:"details,omitempty"`
+	StackTrace      string        `json:"stack_trace,omitempty"`
+	Cause           *Failure      `json:"cause,omitempty"`
+}
+
+// formatFailure converts a failure and, recursively, its causes. Details
+// payloads attached to application, canceled and timeout failures are decoded.
+func formatFailure(f *failure.Failure) *Failure {
+	return formatFailureDepth(f, 0)
+}
+
+func formatFailureDepth(f *failure.Failure, depth int) *Failure {
+	if f == nil || depth >= maxFailureDepth {
+		return nil
+	}
+
+	out := &Failure{
+		Message:    f.GetMessage(),
+		Source:     f.GetSource(),
+		StackTrace: f.GetStackTrace(),
+	}
+
+	// Failures encoded by a codec carry their real message and stack trace in
+	// encoded_attributes; surface them when they decode to plain JSON.
+	if f.GetEncodedAttributes() != nil {
+		if attrs, ok := decodePayload(f.GetEncodedAttributes()).(map[string]interface{}); ok {
+			if msg, ok := attrs["message"].(string); ok && msg != "" {
+				out.Message = msg
+			}
+			if stack, ok := attrs["stack_trace"].(string); ok && stack != "" {
+				out.StackTrace = stack
+			}
+		}
+	}
+
+	switch info := f.GetFailureInfo().(type) {
+	case *failure.Failure_ApplicationFailureInfo:
+		out.Type = "application"
+		out.ApplicationType = info.ApplicationFailureInfo.GetType()
+		out.NonRetryable = info.ApplicationFailureInfo.GetNonRetryable()
+		out.Details = decodePayloads(info.ApplicationFailureInfo.GetDetails())
+	case *failure.Failure_TimeoutFailureInfo:
+		out.Type = "timeout"
+		out.TimeoutType = enumName(info.TimeoutFailureInfo.GetTimeoutType())
+		out.Details = decodePayloads(info.TimeoutFailureInfo.GetLastHeartbeatDetails())
+	case *failure.Failure_CanceledFailureInfo:
+		out.Type = "canceled"
+		out.Details = decodePayloads(info.CanceledFailureInfo.GetDetails())
+	case *failure.Failure_TerminatedFailureInfo:
+		out.Type = "terminated"
+	case *failure.Failure_ServerFailureInfo:
+		out.Type = "server"
+		out.NonRetryable = info.ServerFailureInfo.GetNonRetryable()
+	case *failure.Failure_ResetWorkflowFailureInfo:
+		out.Type = "reset_workflow"
+		out.Details = decodePayloads(info.ResetWorkflowFailureInfo.GetLastHeartbeatDetails())
+	case *failure.Failure_ActivityFailureInfo:
+		out.Type = "activity"
+		out.ActivityType = info.ActivityFailureInfo.GetActivityType().GetName()
+		out.ActivityID = info.ActivityFailureInfo.GetActivityId()
+		out.RetryState = enumName(info.ActivityFailureInfo.GetRetryState())
+	case *failure.Failure_ChildWorkflowExecutionFailureInfo:
+		out.Type = "child_workflow"
+		out.WorkflowID = info.ChildWorkflowExecutionFailureInfo.GetWorkflowExecution().GetWorkflowId()
+		out.WorkflowType = info.ChildWorkflowExecutionFailureInfo.GetWorkflowType().GetName()
+		out.RetryState = enumName(info.ChildWorkflowExecutionFailureInfo.GetRetryState())
+	}
+
+	out.Cause = formatFailureDepth(f.GetCause(), depth+1)
+	return out
+}
+
+// rootCause returns the innermost failure in the chain.
+func (f *Failure) rootCause() *Failure {
+	for f != nil && f.Cause != nil {
+		f = f.Cause
+	}
+	return f
+}
+
+// setFailure records the structured failure under key and, when the chain is
+// more than one level deep, the root cause message alongside it.
+func setFailure(details map[string]interface{}, key string, f *failure.Failure) {
+	formatted := formatFailure(f)
+	if formatted == nil {
+		return
+	}
+	details[key] = formatted
+	if root := formatted.rootCause(); root != formatted {
+		details["root_cause"] = root.Message
+	}
+}
@@ -0,0 +1,122 @@
+package handler
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+
+	"go.temporal.io/api/failure/v1"
+)
+
+const maxGroupSamples = 3
+
+type FailureGroup struct {
+	WorkflowType      string   `json:"workflow_type"`
+	ActivityType      string   `json:"activity_type,omitempty"`
+	Signature         string   `json:"error_signature"`
+	ExampleError      string   `json:"example_error"`
+	Count             int      `json:"count"`
+	FirstSeen         string   `json:"first_seen,omitempty"`
+	LastSeen          string   `json:"last_seen,omitempty"`
+	SampleWorkflowIDs []string `json:"sample_workflow_ids"`
+}
+
+// Patterns are applied in order, so the more specific shapes (timestamps,
+// UUIDs) are replaced before the generic ID and number patterns can break
+// them apart.
+var errorNormalizers = []struct {
+	pattern     *regexp.Regexp
+	replacement string
+}{
+	{regexp.MustCompile(`\d{4}-\d{2}-\d{2}[T ]\d{2}:\d{2}:\d{2}(\.\d+)?(Z|[+-]\d{2}:?\d{2})?`), "<time>"},
+	{regexp.MustCompile(`(?i)\b[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}\b`), "<uuid>"},
+	{regexp.MustCompile(`(?i)\b0x[0-9a-f]+\b`), "<hex>"},
+}
+
+var (
+	hexTokenPattern = regexp.MustCompile(`(?i)\b[0-9a-f]{8,}\b`)
+	numberPattern   = regexp.MustCompile(`\d+(\.\d+)?`)
+	spacePattern    = regexp.MustCompile(`\s+`)
+)
+
+// normalizeErrorMessage strips the variable parts of an error message so that
+// failures with the same cause produce the same signature.
+func normalizeErrorMessage(msg string) string {
+	for _, n := range errorNormalizers {
+		msg = n.pattern.ReplaceAllString(msg, n.replacement)
+	}
+	// Long hex tokens are only IDs if they contain a digit; this keeps words
+	// such as "deadbeef" or "accepted" intact.
+	msg = hexTokenPattern.ReplaceAllStringFunc(msg, func(token string) string {
+		if strings.ContainsAny(token, "0123456789") {
+			return "<id>"
+		}
+		return token
+	})
+	msg = numberPattern.ReplaceAllString(msg, "<n>")
+	msg = spacePattern.ReplaceAllString(msg, " ")
+	return strings.TrimSpace(msg)
+}
+
+// groupFailedWorkflows clusters failures by workflow type, activity type and
+// normalized error message. Groups are ordered by descending count.
+func groupFailedWorkflows(workflows []FailedWorkflow) []FailureGroup {
+	type groupKey struct {
+		workflowType string
+		activityType string
+		signature    string
+	}
+
+	index := make(map[groupKey]int)
+	var groups []FailureGroup
+	for _, wf := range workflows {
+		key := groupKey{
+			workflowType: wf.Type,
+			activityType: wf.ActivityType,
+			signature:    normalizeErrorMessage(wf.Error),
+		}
+
+		i, ok := index[key]
+		if !ok {
+			i = len(groups)
+			index[key] = i
+			groups = append(groups, FailureGroup{
+				WorkflowType: key.workflowType,
+				ActivityType: key.activityType,
+				Signature:    key.signature,
+				ExampleError: wf.Error,
+			})
+		}
+
+		g := &groups[i]
+		g.Count++
+		if len(g.SampleWorkflowIDs) < maxGroupSamples {
+			g.SampleWorkflowIDs = append(g.SampleWorkflowIDs, wf.WorkflowID)
+		}
+		// RFC3339 UTC timestamps sort lexically.
+		if wf.ErrorTime != "" {
+			if g.FirstSeen == "" || wf.ErrorTime < g.FirstSeen {
+				g.FirstSeen = wf.ErrorTime
+			}
+			if wf.ErrorTime > g.LastSeen {
+				g.LastSeen = wf.ErrorTime
+			}
+		}
+	}
+
+	sort.SliceStable(groups, func(a, b int) bool {
+		return groups[a].Count > groups[b].Count
+	})
+	return groups
+}
+
+// activityTypeFromFailure walks the cause chain looking for the activity that
+// originally failed.
+func activityTypeFromFailure(f *failure.Failure) string {
+	for ; f != nil; f = f.GetCause() {
+		if info := f.GetActivityFailureInfo(); info != nil {
+			return info.GetActivityType().GetName()
+		}
+	}
+	return ""
+}
@@ -0,0 +1,90 @@
+package handler
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.temporal.io/api/common/v1"
+	"go.temporal.io/api/failure/v1"
+)
+
+func TestNormalizeErrorMessage(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{
+			name: "numbers",
+			in:   "order 12345 failed after 3 attempts",
+			want: "order <n> failed after <n> attempts",
+		},
+		{
+			name: "uuid",
+			in:   "customer 3f2b8c1e-9d4a-4e6f-8a7b-1c2d3e4f5a6b not found",
+			want: "customer <uuid> not found",
+		},
+		{
+			name: "timestamp",
+			in:   "deadline 2024-01-02T03:04:05.123Z exceeded",
+			want: "deadline <time> exceeded",
+		},
+		{
+			name: "hex ids keep plain words",
+			in:   "request 5f3a9c0b12de rejected: deadbeef accepted",
+			want: "request <id> rejected: deadbeef accepted",
+		},
+		{
+			name: "whitespace",
+			in:   "  connection   refused\n",
+			want: "connection refused",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, normalizeErrorMessage(tt.in))
+		})
+	}
+}
+
+func TestGroupFailedWorkflows(t *testing.T) {
+	workflows := []FailedWorkflow{
+		{WorkflowID: "wf-1", Type: "Order", ActivityType: "Charge", Error: "card 1111 declined", ErrorTime: "2024-01-01T00:00:02Z"},
+		{WorkflowID: "wf-2", Type: "Order", ActivityType: "Charge", Error: "card 2222 declined", ErrorTime: "2024-01-01T00:00:01Z"},
+		{WorkflowID: "wf-3", Type: "Order", ActivityType: "Ship", Error: "card 3333 declined", ErrorTime: "2024-01-01T00:00:03Z"},
+		{WorkflowID: "wf-4", Type: "Order", ActivityType: "Charge", Error: "card 4444 declined", ErrorTime: "2024-01-01T00:00:05Z"},
+		{WorkflowID: "wf-5", Type: "Order", ActivityType: "Charge", Error: "card 5555 declined", ErrorTime: "2024-01-01T00:00:04Z"},
+	}
+
+	groups := groupFailedWorkflows(workflows)
+
+	assert.Len(t, groups, 2)
+	assert.Equal(t, FailureGroup{
+		WorkflowType:      "Order",
+		ActivityType:      "Charge",
+		Signature:         "card <n> declined",
+		ExampleError:      "card 1111 declined",
+		Count:             4,
+		FirstSeen:         "2024-01-01T00:00:01Z",
+		LastSeen:          "2024-01-01T00:00:05Z",
+		SampleWorkflowIDs: []string{"wf-1", "wf-2", "wf-4"},
+	}, groups[0])
+	assert.Equal(t, "Ship", groups[1].ActivityType)
+	assert.Equal(t, 1, groups[1].Count)
+}
+
+func TestActivityTypeFromFailure(t *testing.T) {
+	f := &failure.Failure{
+		Message: "activity error",
+		Cause: &failure.Failure{
+			Message: "wrapped",
+			FailureInfo: &failure.Failure_ActivityFailureInfo{ActivityFailureInfo: &failure.ActivityFailureInfo{
+				ActivityType: &common.ActivityType{Name: "Charge"},
+			}},
+		},
+	}
+
+	assert.Equal(t, "Charge", activityTypeFromFailure(f))
+	assert.Equal(t, "", activityTypeFromFailure(&failure.Failure{Message: "plain"}))
+}
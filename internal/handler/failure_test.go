@@ -0,0 +1,100 @@
+package handler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.temporal.io/api/common/v1"
+	"go.temporal.io/api/enums/v1"
+	"go.temporal.io/api/failure/v1"
+	"go.temporal.io/api/history/v1"
+)
+
+func TestFormatFailure_CauseChain(t *testing.T) {
+	f := &failure.Failure{
+		Message: "activity error",
+		Source:  "GoSDK",
+		FailureInfo: &failure.Failure_ActivityFailureInfo{ActivityFailureInfo: &failure.ActivityFailureInfo{
+			ActivityType: &common.ActivityType{Name: "Charge"},
+			ActivityId:   "5",
+			RetryState:   enums.RETRY_STATE_MAXIMUM_ATTEMPTS_REACHED,
+		}},
+		Cause: &failure.Failure{
+			Message:    "payment gateway rejected request",
+			StackTrace: "gateway.go:42",
+			FailureInfo: &failure.Failure_ApplicationFailureInfo{ApplicationFailureInfo: &failure.ApplicationFailureInfo{
+				Type:         "GatewayError",
+				NonRetryable: true,
+				Details:      payloads(`{"code":"DECLINED"}`),
+			}},
+			Cause: &failure.Failure{
+				Message:     "context deadline exceeded",
+				FailureInfo: &failure.Failure_TimeoutFailureInfo{TimeoutFailureInfo: &failure.TimeoutFailureInfo{TimeoutType: enums.TIMEOUT_TYPE_START_TO_CLOSE}},
+			},
+		},
+	}
+
+	assert.Equal(t, &Failure{
+		Message:      "activity error",
+		Type:         "activity",
+		Source:       "GoSDK",
+		ActivityType: "Charge",
+		ActivityID:   "5",
+		RetryState:   "MaximumAttemptsReached",
+		Cause: &Failure{
+			Message:         "payment gateway rejected request",
+			Type:            "application",
+			ApplicationType: "GatewayError",
+			NonRetryable:    true,
+			Details:         []interface{}{map[string]interface{}{"code": "DECLINED"}},
+			StackTrace:      "gateway.go:42",
+			Cause: &Failure{
+				Message:     "context deadline exceeded",
+				Type:        "timeout",
+				TimeoutType: "StartToClose",
+			},
+		},
+	}, formatFailure(f))
+}
+
+func TestFormatFailure_TypesAndEncodedAttributes(t *testing.T) {
+	assert.Nil(t, formatFailure(nil))
+
+	assert.Equal(t, "terminated", formatFailure(&failure.Failure{FailureInfo: &failure.Failure_TerminatedFailureInfo{TerminatedFailureInfo: &failure.TerminatedFailureInfo{}}}).Type)
+	assert.Equal(t, "canceled", formatFailure(&failure.Failure{FailureInfo: &failure.Failure_CanceledFailureInfo{CanceledFailureInfo: &failure.CanceledFailureInfo{}}}).Type)
+	assert.Equal(t, "server", formatFailure(&failure.Failure{FailureInfo: &failure.Failure_ServerFailureInfo{ServerFailureInfo: &failure.ServerFailureInfo{}}}).Type)
+
+	encoded := formatFailure(&failure.Failure{
+		Message:           "Encoded failure",
+		EncodedAttributes: &common.Payload{Data: []byte(`{"message":"real message","stack_trace":"trace"}`)},
+	})
+	assert.Equal(t, "real message", encoded.Message)
+	assert.Equal(t, "trace", encoded.StackTrace)
+}
+
+func TestFormatEvent_FailureRootCause(t *testing.T) {
+	now := time.Now()
+	event := &history.HistoryEvent{
+		EventId:   9,
+		EventTime: &now,
+		EventType: enums.EVENT_TYPE_WORKFLOW_EXECUTION_FAILED,
+		Attributes: &history.HistoryEvent_WorkflowExecutionFailedEventAttributes{
+			WorkflowExecutionFailedEventAttributes: &history.WorkflowExecutionFailedEventAttributes{
+				Failure: &failure.Failure{
+					Message: "activity error",
+					Cause: &failure.Failure{
+						Message: "wrapped",
+						Cause:   &failure.Failure{Message: "disk full"},
+					},
+				},
+			},
+		},
+	}
+
+	formattedEvent, keep := FormatEvent(event, false)
+	assert.True(t, keep)
+	assert.Equal(t, "activity error", formattedEvent.Details["error"])
+	assert.Equal(t, "disk full", formattedEvent.Details["root_cause"])
+	assert.Equal(t, "disk full", formattedEvent.Details["failure"].(*Failure).Cause.Cause.Message)
+}
@@ -0,0 +1,445 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"go.temporal.io/api/common/v1"
+	"go.temporal.io/api/enums/v1"
+	"go.temporal.io/api/history/v1"
+	"go.temporal.io/api/taskqueue/v1"
+	"go.temporal.io/api/workflow/v1"
+	"go.temporal.io/api/workflowservice/v1"
+	"go.temporal.io/api/workflowservicemock/v1"
+	"go.temporal.io/sdk/client"
+	"go.temporal.io/sdk/mocks"
+	"google.golang.org/grpc"
+)
+
+// fakeRun is one run of a workflow served by fakeClient.
+type fakeRun struct {
+	workflowID   string
+	runID        string
+	workflowType string
+	taskQueue    string
+	// status defaults to Running, or ContinuedAsNew if the history ends by
+	// continuing as new.
+	status enums.WorkflowExecutionStatus
+	events []*history.HistoryEvent
+	// err fails every describe and history read of the run.
+	err error
+
+	pendingActivities   []*workflow.PendingActivityInfo
+	pendingChildren     []*workflow.PendingChildExecutionInfo
+	pendingWorkflowTask *workflow.PendingWorkflowTaskInfo
+}
+
+func (r *fakeRun) info() *workflow.WorkflowExecutionInfo {
+	info := &workflow.WorkflowExecutionInfo{
+		Execution:     &common.WorkflowExecution{WorkflowId: r.workflowID, RunId: r.runID},
+		Status:        r.status,
+		TaskQueue:     r.taskQueue,
+		HistoryLength: int64(len(r.events)),
+	}
+	if r.workflowType != "" {
+		info.Type = &common.WorkflowType{Name: r.workflowType}
+	}
+	if len(r.events) > 0 {
+		if first := r.events[0]; first.GetEventType() == enums.EVENT_TYPE_WORKFLOW_EXECUTION_STARTED {
+			info.StartTime = first.GetEventTime()
+		}
+		last := r.events[len(r.events)-1]
+		if info.Status == enums.WORKFLOW_EXECUTION_STATUS_UNSPECIFIED && last.GetEventType() == enums.EVENT_TYPE_WORKFLOW_EXECUTION_CONTINUED_AS_NEW {
+			info.Status = enums.WORKFLOW_EXECUTION_STATUS_CONTINUED_AS_NEW
+			info.CloseTime = last.GetEventTime()
+		}
+	}
+	if info.Status == enums.WORKFLOW_EXECUTION_STATUS_UNSPECIFIED {
+		info.Status = enums.WORKFLOW_EXECUTION_STATUS_RUNNING
+	}
+	return info
+}
+
+// fakeClient is the client shared by the handler tests. It serves workflow
+// runs, their histories and one schedule from memory and records what the
+// handlers read and change. Calls it does not implement go to an empty
+// mocks.Client, which fails the test with the name of the unexpected call.
+type fakeClient struct {
+	*mocks.Client
+	service *fakeService
+
+	mu sync.Mutex
+	// runs are in start order, so the last run of a workflow is its current
+	// one.
+	runs []*fakeRun
+	// reads are the runs whose history was read.
+	reads []string
+	// served counts the events handed out by history iterators.
+	served    int
+	listQuery string
+	resets    []*workflowservice.ResetWorkflowExecutionRequest
+
+	schedules []*client.ScheduleListEntry
+	// schedule is what every schedule handle describes.
+	schedule *client.ScheduleDescription
+	paused   *client.SchedulePauseOptions
+	unpaused *client.ScheduleUnpauseOptions
+	trigger  *client.ScheduleTriggerOptions
+	backfill *client.ScheduleBackfillOptions
+}
+
+func newFakeClient(t *testing.T, runs ...*fakeRun) *fakeClient {
+	c := &fakeClient{Client: &mocks.Client{}, service: newFakeService(t), runs: runs}
+	c.Client.Test(t)
+	c.service.client = c
+	return c
+}
+
+// run finds a run, or the current run of the workflow if runID is empty.
+func (c *fakeClient) run(workflowID, runID string) (*fakeRun, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for i := len(c.runs) - 1; i >= 0; i-- {
+		r := c.runs[i]
+		if r.workflowID != workflowID || (runID != "" && r.runID != runID) {
+			continue
+		}
+		if r.err != nil {
+			return nil, r.err
+		}
+		return r, nil
+	}
+	return nil, errors.New("workflow not found")
+}
+
+func (c *fakeClient) DescribeWorkflowExecution(_ context.Context, workflowID, runID string) (*workflowservice.DescribeWorkflowExecutionResponse, error) {
+	r, err := c.run(workflowID, runID)
+	if err != nil {
+		return nil, err
+	}
+	return &workflowservice.DescribeWorkflowExecutionResponse{
+		WorkflowExecutionInfo: r.info(),
+		PendingActivities:     r.pendingActivities,
+		PendingChildren:       r.pendingChildren,
+		PendingWorkflowTask:   r.pendingWorkflowTask,
+	}, nil
+}
+
+func (c *fakeClient) GetWorkflowHistory(_ context.Context, workflowID, runID string, _ bool, filterType enums.HistoryEventFilterType) client.HistoryEventIterator {
+	r, err := c.run(workflowID, runID)
+	if err != nil {
+		return &fakeHistory{err: err}
+	}
+	c.mu.Lock()
+	c.reads = append(c.reads, r.runID)
+	c.mu.Unlock()
+	events := r.events
+	if filterType == enums.HISTORY_EVENT_FILTER_TYPE_CLOSE_EVENT && len(events) > 0 {
+		events = events[len(events)-1:]
+	}
+	return &fakeHistory{client: c, events: events}
+}
+
+// ListWorkflow lists the current run of every workflow, whatever the query.
+func (c *fakeClient) ListWorkflow(_ context.Context, req *workflowservice.ListWorkflowExecutionsRequest) (*workflowservice.ListWorkflowExecutionsResponse, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.listQuery = req.GetQuery()
+	resp := &workflowservice.ListWorkflowExecutionsResponse{}
+	listed := make(map[string]int)
+	for _, r := range c.runs {
+		if i, ok := listed[r.workflowID]; ok {
+			resp.Executions[i] = r.info()
+			continue
+		}
+		listed[r.workflowID] = len(resp.Executions)
+		resp.Executions = append(resp.Executions, r.info())
+	}
+	return resp, nil
+}
+
+// ResetWorkflowExecution starts a new run with the events up to the reset
+// point.
+func (c *fakeClient) ResetWorkflowExecution(_ context.Context, req *workflowservice.ResetWorkflowExecutionRequest) (*workflowservice.ResetWorkflowExecutionResponse, error) {
+	execution := req.GetWorkflowExecution()
+	r, err := c.run(execution.GetWorkflowId(), execution.GetRunId())
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.resets = append(c.resets, req)
+	next := *r
+	next.runID = "run-" + strconv.Itoa(len(c.runs)+1)
+	next.status = enums.WORKFLOW_EXECUTION_STATUS_UNSPECIFIED
+	next.events = nil
+	for _, evt := range r.events {
+		if evt.GetEventId() <= req.GetWorkflowTaskFinishEventId() {
+			next.events = append(next.events, evt)
+		}
+	}
+	c.runs = append(c.runs, &next)
+	return &workflowservice.ResetWorkflowExecutionResponse{RunId: next.runID}, nil
+}
+
+func (c *fakeClient) DescribeTaskQueue(ctx context.Context, taskQueue string, taskQueueType enums.TaskQueueType) (*workflowservice.DescribeTaskQueueResponse, error) {
+	return c.service.DescribeTaskQueue(ctx, &workflowservice.DescribeTaskQueueRequest{
+		TaskQueue:     &taskqueue.TaskQueue{Name: taskQueue},
+		TaskQueueType: taskQueueType,
+	})
+}
+
+func (c *fakeClient) WorkflowService() workflowservice.WorkflowServiceClient {
+	return c.service
+}
+
+func (c *fakeClient) ScheduleClient() client.ScheduleClient {
+	return fakeScheduleClient{c}
+}
+
+// fakeHistory hands out a run's events, counting them on its client.
+type fakeHistory struct {
+	client *fakeClient
+	events []*history.HistoryEvent
+	err    error
+}
+
+func (it *fakeHistory) HasNext() bool {
+	return it.err != nil || len(it.events) > 0
+}
+
+func (it *fakeHistory) Next() (*history.HistoryEvent, error) {
+	if it.err != nil {
+		return nil, it.err
+	}
+	evt := it.events[0]
+	it.events = it.events[1:]
+	it.client.mu.Lock()
+	it.client.served++
+	it.client.mu.Unlock()
+	return evt, nil
+}
+
+// sliceIterator replays a fixed list of events, for tests that mock the
+// client.
+type sliceIterator struct {
+	events []*history.HistoryEvent
+}
+
+func (it *sliceIterator) HasNext() bool {
+	return len(it.events) > 0
+}
+
+func (it *sliceIterator) Next() (*history.HistoryEvent, error) {
+	evt := it.events[0]
+	it.events = it.events[1:]
+	return evt, nil
+}
+
+type fakeScheduleClient struct {
+	c *fakeClient
+}
+
+func (s fakeScheduleClient) Create(context.Context, client.ScheduleOptions) (client.ScheduleHandle, error) {
+	return nil, errors.New("fake schedule client cannot create schedules")
+}
+
+func (s fakeScheduleClient) List(context.Context, client.ScheduleListOptions) (client.ScheduleListIterator, error) {
+	return &scheduleIterator{entries: s.c.schedules}, nil
+}
+
+func (s fakeScheduleClient) GetHandle(_ context.Context, scheduleID string) client.ScheduleHandle {
+	return &fakeScheduleHandle{id: scheduleID, c: s.c}
+}
+
+type scheduleIterator struct {
+	entries []*client.ScheduleListEntry
+}
+
+func (it *scheduleIterator) HasNext() bool { return len(it.entries) > 0 }
+
+func (it *scheduleIterator) Next() (*client.ScheduleListEntry, error) {
+	entry := it.entries[0]
+	it.entries = it.entries[1:]
+	return entry, nil
+}
+
+// fakeScheduleHandle records the changes made to the client's schedule.
+type fakeScheduleHandle struct {
+	id string
+	c  *fakeClient
+}
+
+func (h *fakeScheduleHandle) GetID() string { return h.id }
+
+func (h *fakeScheduleHandle) Describe(context.Context) (*client.ScheduleDescription, error) {
+	if h.c.schedule == nil {
+		return nil, fmt.Errorf("schedule %s not found", h.id)
+	}
+	return h.c.schedule, nil
+}
+
+func (h *fakeScheduleHandle) Pause(_ context.Context, options client.SchedulePauseOptions) error {
+	h.c.paused = &options
+	if h.c.schedule != nil {
+		h.c.schedule.Schedule.State.Paused = true
+		h.c.schedule.Schedule.State.Note = options.Note
+	}
+	return nil
+}
+
+func (h *fakeScheduleHandle) Unpause(_ context.Context, options client.ScheduleUnpauseOptions) error {
+	h.c.unpaused = &options
+	if h.c.schedule != nil {
+		h.c.schedule.Schedule.State.Paused = false
+		h.c.schedule.Schedule.State.Note = options.Note
+	}
+	return nil
+}
+
+func (h *fakeScheduleHandle) Trigger(_ context.Context, options client.ScheduleTriggerOptions) error {
+	h.c.trigger = &options
+	return nil
+}
+
+func (h *fakeScheduleHandle) Backfill(_ context.Context, options client.ScheduleBackfillOptions) error {
+	h.c.backfill = &options
+	return nil
+}
+
+func (h *fakeScheduleHandle) Update(context.Context, client.ScheduleUpdateOptions) error {
+	return errors.New("fake schedule handle cannot update schedules")
+}
+
+func (h *fakeScheduleHandle) Delete(context.Context) error {
+	return errors.New("fake schedule handle cannot delete schedules")
+}
+
+// taskQueueKey names one type of a task queue.
+type taskQueueKey struct {
+	name string
+	kind enums.TaskQueueType
+}
+
+// fakeService is the workflow service shared by the handler tests. It records
+// the requests it receives and serves task queues, one batch job and, through
+// its client, histories newest first. Calls it does not implement go to a
+// gomock service with no expectations, which fails the test.
+type fakeService struct {
+	workflowservice.WorkflowServiceClient
+	client *fakeClient
+	// err fails the signal, cancel and terminate calls.
+	err        error
+	taskQueues map[taskQueueKey]*workflowservice.DescribeTaskQueueResponse
+	batchJob   *workflowservice.DescribeBatchOperationResponse
+	batchJobs  *workflowservice.ListBatchOperationsResponse
+
+	mu                sync.Mutex
+	signal            *workflowservice.SignalWorkflowExecutionRequest
+	cancel            *workflowservice.RequestCancelWorkflowExecutionRequest
+	terminate         *workflowservice.TerminateWorkflowExecutionRequest
+	batchStart        *workflowservice.StartBatchOperationRequest
+	batchStop         *workflowservice.StopBatchOperationRequest
+	batchList         *workflowservice.ListBatchOperationsRequest
+	taskQueueRequests []*workflowservice.DescribeTaskQueueRequest
+	// eventsRead counts the events read newest first, by workflow ID.
+	eventsRead map[string]int
+}
+
+func newFakeService(t *testing.T) *fakeService {
+	return &fakeService{
+		WorkflowServiceClient: workflowservicemock.NewMockWorkflowServiceClient(gomock.NewController(t)),
+		taskQueues:            make(map[taskQueueKey]*workflowservice.DescribeTaskQueueResponse),
+		eventsRead:            make(map[string]int),
+	}
+}
+
+func (s *fakeService) SignalWorkflowExecution(_ context.Context, req *workflowservice.SignalWorkflowExecutionRequest, _ ...grpc.CallOption) (*workflowservice.SignalWorkflowExecutionResponse, error) {
+	s.signal = req
+	return &workflowservice.SignalWorkflowExecutionResponse{}, s.err
+}
+
+func (s *fakeService) RequestCancelWorkflowExecution(_ context.Context, req *workflowservice.RequestCancelWorkflowExecutionRequest, _ ...grpc.CallOption) (*workflowservice.RequestCancelWorkflowExecutionResponse, error) {
+	s.cancel = req
+	return &workflowservice.RequestCancelWorkflowExecutionResponse{}, s.err
+}
+
+func (s *fakeService) TerminateWorkflowExecution(_ context.Context, req *workflowservice.TerminateWorkflowExecutionRequest, _ ...grpc.CallOption) (*workflowservice.TerminateWorkflowExecutionResponse, error) {
+	s.terminate = req
+	return &workflowservice.TerminateWorkflowExecutionResponse{}, s.err
+}
+
+// DescribeTaskQueue serves the registered queue, or one nobody polls.
+func (s *fakeService) DescribeTaskQueue(_ context.Context, req *workflowservice.DescribeTaskQueueRequest, _ ...grpc.CallOption) (*workflowservice.DescribeTaskQueueResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.taskQueueRequests = append(s.taskQueueRequests, req)
+	if resp, ok := s.taskQueues[taskQueueKey{req.GetTaskQueue().GetName(), req.GetTaskQueueType()}]; ok {
+		return resp, nil
+	}
+	return &workflowservice.DescribeTaskQueueResponse{}, nil
+}
+
+func (s *fakeService) StartBatchOperation(_ context.Context, req *workflowservice.StartBatchOperationRequest, _ ...grpc.CallOption) (*workflowservice.StartBatchOperationResponse, error) {
+	s.batchStart = req
+	return &workflowservice.StartBatchOperationResponse{}, nil
+}
+
+func (s *fakeService) StopBatchOperation(_ context.Context, req *workflowservice.StopBatchOperationRequest, _ ...grpc.CallOption) (*workflowservice.StopBatchOperationResponse, error) {
+	s.batchStop = req
+	if s.batchJob != nil {
+		s.batchJob.State = enums.BATCH_OPERATION_STATE_FAILED
+	}
+	return &workflowservice.StopBatchOperationResponse{}, nil
+}
+
+func (s *fakeService) DescribeBatchOperation(_ context.Context, req *workflowservice.DescribeBatchOperationRequest, _ ...grpc.CallOption) (*workflowservice.DescribeBatchOperationResponse, error) {
+	if s.batchJob == nil || s.batchJob.GetJobId() != req.GetJobId() {
+		return nil, fmt.Errorf("batch job %s not found", req.GetJobId())
+	}
+	return s.batchJob, nil
+}
+
+func (s *fakeService) ListBatchOperations(_ context.Context, req *workflowservice.ListBatchOperationsRequest, _ ...grpc.CallOption) (*workflowservice.ListBatchOperationsResponse, error) {
+	s.batchList = req
+	if s.batchJobs == nil {
+		return &workflowservice.ListBatchOperationsResponse{}, nil
+	}
+	return s.batchJobs, nil
+}
+
+// GetWorkflowExecutionHistoryReverse serves the client's histories newest
+// first, at most 100 events a page.
+func (s *fakeService) GetWorkflowExecutionHistoryReverse(_ context.Context, req *workflowservice.GetWorkflowExecutionHistoryReverseRequest, _ ...grpc.CallOption) (*workflowservice.GetWorkflowExecutionHistoryReverseResponse, error) {
+	workflowID := req.GetExecution().GetWorkflowId()
+	r, err := s.client.run(workflowID, req.GetExecution().GetRunId())
+	if err != nil {
+		return nil, err
+	}
+	offset := 0
+	if len(req.GetNextPageToken()) > 0 {
+		offset, _ = strconv.Atoi(string(req.GetNextPageToken()))
+	}
+	size := int(req.GetMaximumPageSize())
+	if size <= 0 || size > 100 {
+		size = 100
+	}
+	resp := &workflowservice.GetWorkflowExecutionHistoryReverseResponse{History: &history.History{}}
+	for i := len(r.events) - 1 - offset; i >= 0 && len(resp.History.Events) < size; i-- {
+		resp.History.Events = append(resp.History.Events, r.events[i])
+	}
+	offset += len(resp.History.Events)
+	if offset < len(r.events) {
+		resp.NextPageToken = []byte(strconv.Itoa(offset))
+	}
+
+	s.mu.Lock()
+	s.eventsRead[workflowID] += len(resp.History.Events)
+	s.mu.Unlock()
+	return resp, nil
+}
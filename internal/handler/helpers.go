@@ -1,11 +1,11 @@
 package handler
 
 import (
-	"encoding/json"
 	"fmt"
 	"time"
 
 	"go.temporal.io/api/common/v1"
+	"go.temporal.io/api/enums/v1"
 	"go.temporal.io/api/history/v1"
 )
 
@@ -14,10 +14,11 @@ type Event struct {
 	Type      string                 `json:"type"`
 	Timestamp string                 `json:"timestamp"`
 	Details   map[string]interface{} `json:"details,omitempty"`
+	RunID     string                 `json:"run_id,omitempty"`
 }
 
 func FormatEvent(e *history.HistoryEvent, summaryOnly bool) (Event, bool) {
-	if e == nil {
+	if e == nil || e.GetEventType() == enums.EVENT_TYPE_UNSPECIFIED {
 		return Event{}, false
 	}
 
@@ -27,48 +28,177 @@ func FormatEvent(e *history.HistoryEvent, summaryOnly bool) (Event, bool) {
 		switch attr := e.Attributes.(type) {
 		case *history.HistoryEvent_WorkflowExecutionStartedEventAttributes:
 			if attr.WorkflowExecutionStartedEventAttributes != nil {
-				extractJSON(attr.WorkflowExecutionStartedEventAttributes.GetInput(), &details)
+				a := attr.WorkflowExecutionStartedEventAttributes
+				extractJSON(a.GetInput(), &details)
+				setDetail(details, "workflow_type", a.GetWorkflowType().GetName())
+				setDetail(details, "task_queue", a.GetTaskQueue().GetName())
+				setDetail(details, "attempt", a.GetAttempt())
+				setDetail(details, "cron_schedule", a.GetCronSchedule())
+				setDetail(details, "identity", a.GetIdentity())
+				setDetail(details, "continued_execution_run_id", a.GetContinuedExecutionRunId())
+				setDetail(details, "first_execution_run_id", a.GetFirstExecutionRunId())
+				setDetail(details, "original_execution_run_id", a.GetOriginalExecutionRunId())
+				setDetail(details, "execution_timeout", a.GetWorkflowExecutionTimeout())
+				setDetail(details, "run_timeout", a.GetWorkflowRunTimeout())
+				setDetail(details, "first_workflow_task_backoff", a.GetFirstWorkflowTaskBackoff())
+				setExecution(details, "parent_", a.GetParentWorkflowExecution())
+				setDetail(details, "memo", decodePayloadMap(a.GetMemo().GetFields()))
+				setDetail(details, "search_attributes", decodePayloadMap(a.GetSearchAttributes().GetIndexedFields()))
+				setFailure(details, "continued_failure", a.GetContinuedFailure())
 			}
 
+		case *history.HistoryEvent_WorkflowExecutionCompletedEventAttributes:
+			if attr.WorkflowExecutionCompletedEventAttributes != nil {
+				extractJSON(attr.WorkflowExecutionCompletedEventAttributes.Result, &details)
+				setDetail(details, "new_execution_run_id", attr.WorkflowExecutionCompletedEventAttributes.GetNewExecutionRunId())
+			}
+
+		case *history.HistoryEvent_WorkflowExecutionFailedEventAttributes:
+			if attr.WorkflowExecutionFailedEventAttributes != nil && attr.WorkflowExecutionFailedEventAttributes.Failure != nil {
+				details["error"] = attr.WorkflowExecutionFailedEventAttributes.Failure.Message
+				setFailure(details, "failure", attr.WorkflowExecutionFailedEventAttributes.Failure)
+			}
+			setEnum(details, "retry_state", attr.WorkflowExecutionFailedEventAttributes.GetRetryState())
+			setDetail(details, "new_execution_run_id", attr.WorkflowExecutionFailedEventAttributes.GetNewExecutionRunId())
+
+		case *history.HistoryEvent_WorkflowExecutionTimedOutEventAttributes:
+			a := attr.WorkflowExecutionTimedOutEventAttributes
+			setEnum(details, "retry_state", a.GetRetryState())
+			setDetail(details, "new_execution_run_id", a.GetNewExecutionRunId())
+
+		case *history.HistoryEvent_WorkflowExecutionContinuedAsNewEventAttributes:
+			a := attr.WorkflowExecutionContinuedAsNewEventAttributes
+			extractJSON(a.GetInput(), &details)
+			setDetail(details, "new_execution_run_id", a.GetNewExecutionRunId())
+			setDetail(details, "workflow_type", a.GetWorkflowType().GetName())
+			setDetail(details, "task_queue", a.GetTaskQueue().GetName())
+			setDetail(details, "backoff", a.GetBackoffStartInterval())
+			setEnum(details, "initiator", a.GetInitiator())
+			setDetail(details, "last_completion_result", decodePayloads(a.GetLastCompletionResult()))
+			setFailure(details, "continued_failure", a.GetFailure())
+
+		case *history.HistoryEvent_WorkflowExecutionCancelRequestedEventAttributes:
+			a := attr.WorkflowExecutionCancelRequestedEventAttributes
+			setDetail(details, "cause", a.GetCause())
+			setDetail(details, "identity", a.GetIdentity())
+			setExecution(details, "external_", a.GetExternalWorkflowExecution())
+
+		case *history.HistoryEvent_WorkflowExecutionCanceledEventAttributes:
+			extractJSON(attr.WorkflowExecutionCanceledEventAttributes.GetDetails(), &details)
+
+		case *history.HistoryEvent_WorkflowExecutionTerminatedEventAttributes:
+			a := attr.WorkflowExecutionTerminatedEventAttributes
+			extractJSON(a.GetDetails(), &details)
+			setDetail(details, "reason", a.GetReason())
+			setDetail(details, "identity", a.GetIdentity())
+
+		case *history.HistoryEvent_WorkflowTaskScheduledEventAttributes:
+			a := attr.WorkflowTaskScheduledEventAttributes
+			setDetail(details, "task_queue", a.GetTaskQueue().GetName())
+			setDetail(details, "start_to_close_timeout", a.GetStartToCloseTimeout())
+			setDetail(details, "attempt", a.GetAttempt())
+
+		case *history.HistoryEvent_WorkflowTaskStartedEventAttributes:
+			a := attr.WorkflowTaskStartedEventAttributes
+			setDetail(details, "scheduled_event_id", a.GetScheduledEventId())
+			setDetail(details, "identity", a.GetIdentity())
+			setDetail(details, "suggest_continue_as_new", a.GetSuggestContinueAsNew())
+			setDetail(details, "history_size_bytes", a.GetHistorySizeBytes())
+
+		case *history.HistoryEvent_WorkflowTaskCompletedEventAttributes:
+			a := attr.WorkflowTaskCompletedEventAttributes
+			setDetail(details, "scheduled_event_id", a.GetScheduledEventId())
+			setDetail(details, "started_event_id", a.GetStartedEventId())
+			setDetail(details, "identity", a.GetIdentity())
+			setDetail(details, "binary_checksum", a.GetBinaryChecksum())
+			setDetail(details, "build_id", a.GetWorkerVersioningId().GetWorkerBuildId())
+
+		case *history.HistoryEvent_WorkflowTaskTimedOutEventAttributes:
+			a := attr.WorkflowTaskTimedOutEventAttributes
+			setDetail(details, "scheduled_event_id", a.GetScheduledEventId())
+			setDetail(details, "started_event_id", a.GetStartedEventId())
+			setEnum(details, "timeout_type", a.GetTimeoutType())
+
+		case *history.HistoryEvent_WorkflowTaskFailedEventAttributes:
+			if attr.WorkflowTaskFailedEventAttributes != nil && attr.WorkflowTaskFailedEventAttributes.Failure != nil {
+				details["error"] = attr.WorkflowTaskFailedEventAttributes.Failure.Message + "\n" + attr.WorkflowTaskFailedEventAttributes.Failure.StackTrace
+				setFailure(details, "failure", attr.WorkflowTaskFailedEventAttributes.Failure)
+			}
+			a := attr.WorkflowTaskFailedEventAttributes
+			setDetail(details, "scheduled_event_id", a.GetScheduledEventId())
+			setDetail(details, "started_event_id", a.GetStartedEventId())
+			setEnum(details, "cause", a.GetCause())
+			setDetail(details, "identity", a.GetIdentity())
+			setDetail(details, "binary_checksum", a.GetBinaryChecksum())
+			setDetail(details, "base_run_id", a.GetBaseRunId())
+			setDetail(details, "new_run_id", a.GetNewRunId())
+
 		case *history.HistoryEvent_ActivityTaskScheduledEventAttributes:
 			if attr.ActivityTaskScheduledEventAttributes != nil {
 				details["activity_type"] = attr.ActivityTaskScheduledEventAttributes.ActivityType.GetName()
 				extractJSON(attr.ActivityTaskScheduledEventAttributes.Input, &details)
 			}
+			a := attr.ActivityTaskScheduledEventAttributes
+			setDetail(details, "activity_id", a.GetActivityId())
+			setDetail(details, "task_queue", a.GetTaskQueue().GetName())
+			setDetail(details, "schedule_to_close_timeout", a.GetScheduleToCloseTimeout())
+			setDetail(details, "schedule_to_start_timeout", a.GetScheduleToStartTimeout())
+			setDetail(details, "start_to_close_timeout", a.GetStartToCloseTimeout())
+			setDetail(details, "heartbeat_timeout", a.GetHeartbeatTimeout())
+			setDetail(details, "retry_policy", formatRetryPolicy(a.GetRetryPolicy()))
 
-		case *history.HistoryEvent_WorkflowExecutionSignaledEventAttributes:
-			if attr.WorkflowExecutionSignaledEventAttributes != nil {
-				details["signal_name"] = attr.WorkflowExecutionSignaledEventAttributes.GetSignalName()
-				extractJSON(attr.WorkflowExecutionSignaledEventAttributes.Input, &details)
-			}
-		case *history.HistoryEvent_WorkflowExecutionUpdateAcceptedEventAttributes:
-			if attr.WorkflowExecutionUpdateAcceptedEventAttributes != nil && attr.WorkflowExecutionUpdateAcceptedEventAttributes.AcceptedRequest != nil && attr.WorkflowExecutionUpdateAcceptedEventAttributes.AcceptedRequest.GetInput() != nil {
-				updateInput := attr.WorkflowExecutionUpdateAcceptedEventAttributes.AcceptedRequest.GetInput()
-				if updateInput.GetName() != "" {
-					details["update_name"] = updateInput.GetName()
-				}
+		case *history.HistoryEvent_ActivityTaskStartedEventAttributes:
+			a := attr.ActivityTaskStartedEventAttributes
+			setDetail(details, "scheduled_event_id", a.GetScheduledEventId())
+			setDetail(details, "identity", a.GetIdentity())
+			setDetail(details, "attempt", a.GetAttempt())
+			setFailure(details, "last_failure", a.GetLastFailure())
 
-				extractJSON(&common.Payloads{Payloads: updateInput.Args.Payloads}, &details)
-			}
 		case *history.HistoryEvent_ActivityTaskCompletedEventAttributes:
 			if attr.ActivityTaskCompletedEventAttributes != nil {
 				extractJSON(attr.ActivityTaskCompletedEventAttributes.Result, &details)
 			}
+			a := attr.ActivityTaskCompletedEventAttributes
+			setDetail(details, "scheduled_event_id", a.GetScheduledEventId())
+			setDetail(details, "started_event_id", a.GetStartedEventId())
+			setDetail(details, "identity", a.GetIdentity())
 
 		case *history.HistoryEvent_ActivityTaskFailedEventAttributes:
 			if attr.ActivityTaskFailedEventAttributes != nil && attr.ActivityTaskFailedEventAttributes.Failure != nil {
 				details["error"] = attr.ActivityTaskFailedEventAttributes.Failure.Message
+				setFailure(details, "failure", attr.ActivityTaskFailedEventAttributes.Failure)
 			}
+			a := attr.ActivityTaskFailedEventAttributes
+			setDetail(details, "scheduled_event_id", a.GetScheduledEventId())
+			setDetail(details, "started_event_id", a.GetStartedEventId())
+			setDetail(details, "identity", a.GetIdentity())
+			setEnum(details, "retry_state", a.GetRetryState())
 
-		case *history.HistoryEvent_WorkflowExecutionCompletedEventAttributes:
-			if attr.WorkflowExecutionCompletedEventAttributes != nil {
-				extractJSON(attr.WorkflowExecutionCompletedEventAttributes.Result, &details)
+		case *history.HistoryEvent_ActivityTaskTimedOutEventAttributes:
+			a := attr.ActivityTaskTimedOutEventAttributes
+			if a.GetFailure() != nil {
+				details["error"] = a.GetFailure().GetMessage()
+				setEnum(details, "timeout_type", a.GetFailure().GetTimeoutFailureInfo().GetTimeoutType())
+				setFailure(details, "failure", a.GetFailure())
 			}
+			setDetail(details, "scheduled_event_id", a.GetScheduledEventId())
+			setDetail(details, "started_event_id", a.GetStartedEventId())
+			setEnum(details, "retry_state", a.GetRetryState())
 
-		case *history.HistoryEvent_WorkflowExecutionFailedEventAttributes:
-			if attr.WorkflowExecutionFailedEventAttributes != nil && attr.WorkflowExecutionFailedEventAttributes.Failure != nil {
-				details["error"] = attr.WorkflowExecutionFailedEventAttributes.Failure.Message
-			}
+		case *history.HistoryEvent_ActivityTaskCancelRequestedEventAttributes:
+			setDetail(details, "scheduled_event_id", attr.ActivityTaskCancelRequestedEventAttributes.GetScheduledEventId())
+
+		case *history.HistoryEvent_ActivityTaskCanceledEventAttributes:
+			a := attr.ActivityTaskCanceledEventAttributes
+			extractJSON(a.GetDetails(), &details)
+			setDetail(details, "scheduled_event_id", a.GetScheduledEventId())
+			setDetail(details, "started_event_id", a.GetStartedEventId())
+			setDetail(details, "identity", a.GetIdentity())
+
+		case *history.HistoryEvent_ActivityPropertiesModifiedExternallyEventAttributes:
+			a := attr.ActivityPropertiesModifiedExternallyEventAttributes
+			setDetail(details, "scheduled_event_id", a.GetScheduledEventId())
+			setDetail(details, "retry_policy", formatRetryPolicy(a.GetNewRetryPolicy()))
 
 		case *history.HistoryEvent_TimerStartedEventAttributes:
 			if attr.TimerStartedEventAttributes != nil {
@@ -76,10 +206,196 @@ func FormatEvent(e *history.HistoryEvent, summaryOnly bool) (Event, bool) {
 				details["timeout"] = attr.TimerStartedEventAttributes.GetStartToFireTimeout().String()
 			}
 
-		case *history.HistoryEvent_WorkflowTaskFailedEventAttributes:
-			if attr.WorkflowTaskFailedEventAttributes != nil && attr.WorkflowTaskFailedEventAttributes.Failure != nil {
-				details["error"] = attr.WorkflowTaskFailedEventAttributes.Failure.Message + "\n" + attr.WorkflowTaskFailedEventAttributes.Failure.StackTrace
+		case *history.HistoryEvent_TimerFiredEventAttributes:
+			a := attr.TimerFiredEventAttributes
+			setDetail(details, "timer_id", a.GetTimerId())
+			setDetail(details, "started_event_id", a.GetStartedEventId())
+
+		case *history.HistoryEvent_TimerCanceledEventAttributes:
+			a := attr.TimerCanceledEventAttributes
+			setDetail(details, "timer_id", a.GetTimerId())
+			setDetail(details, "started_event_id", a.GetStartedEventId())
+			setDetail(details, "identity", a.GetIdentity())
+
+		case *history.HistoryEvent_MarkerRecordedEventAttributes:
+			a := attr.MarkerRecordedEventAttributes
+			setDetail(details, "marker_name", a.GetMarkerName())
+			if len(a.GetDetails()) > 0 {
+				markerDetails := make(map[string]interface{}, len(a.GetDetails()))
+				for name, payloads := range a.GetDetails() {
+					markerDetails[name] = decodePayloads(payloads)
+				}
+				details["details"] = markerDetails
+			}
+			if a.GetFailure() != nil {
+				details["error"] = a.GetFailure().GetMessage()
+				setFailure(details, "failure", a.GetFailure())
 			}
+
+		case *history.HistoryEvent_WorkflowExecutionSignaledEventAttributes:
+			if attr.WorkflowExecutionSignaledEventAttributes != nil {
+				details["signal_name"] = attr.WorkflowExecutionSignaledEventAttributes.GetSignalName()
+				extractJSON(attr.WorkflowExecutionSignaledEventAttributes.Input, &details)
+			}
+			setDetail(details, "identity", attr.WorkflowExecutionSignaledEventAttributes.GetIdentity())
+
+		case *history.HistoryEvent_RequestCancelExternalWorkflowExecutionInitiatedEventAttributes:
+			a := attr.RequestCancelExternalWorkflowExecutionInitiatedEventAttributes
+			setDetail(details, "namespace", a.GetNamespace())
+			setExecution(details, "", a.GetWorkflowExecution())
+			setDetail(details, "child_workflow_only", a.GetChildWorkflowOnly())
+			setDetail(details, "reason", a.GetReason())
+
+		case *history.HistoryEvent_RequestCancelExternalWorkflowExecutionFailedEventAttributes:
+			a := attr.RequestCancelExternalWorkflowExecutionFailedEventAttributes
+			setEnum(details, "cause", a.GetCause())
+			setDetail(details, "namespace", a.GetNamespace())
+			setExecution(details, "", a.GetWorkflowExecution())
+			setDetail(details, "initiated_event_id", a.GetInitiatedEventId())
+
+		case *history.HistoryEvent_ExternalWorkflowExecutionCancelRequestedEventAttributes:
+			a := attr.ExternalWorkflowExecutionCancelRequestedEventAttributes
+			setDetail(details, "namespace", a.GetNamespace())
+			setExecution(details, "", a.GetWorkflowExecution())
+			setDetail(details, "initiated_event_id", a.GetInitiatedEventId())
+
+		case *history.HistoryEvent_SignalExternalWorkflowExecutionInitiatedEventAttributes:
+			a := attr.SignalExternalWorkflowExecutionInitiatedEventAttributes
+			extractJSON(a.GetInput(), &details)
+			setDetail(details, "signal_name", a.GetSignalName())
+			setDetail(details, "namespace", a.GetNamespace())
+			setExecution(details, "", a.GetWorkflowExecution())
+			setDetail(details, "child_workflow_only", a.GetChildWorkflowOnly())
+
+		case *history.HistoryEvent_SignalExternalWorkflowExecutionFailedEventAttributes:
+			a := attr.SignalExternalWorkflowExecutionFailedEventAttributes
+			setEnum(details, "cause", a.GetCause())
+			setDetail(details, "namespace", a.GetNamespace())
+			setExecution(details, "", a.GetWorkflowExecution())
+			setDetail(details, "initiated_event_id", a.GetInitiatedEventId())
+
+		case *history.HistoryEvent_ExternalWorkflowExecutionSignaledEventAttributes:
+			a := attr.ExternalWorkflowExecutionSignaledEventAttributes
+			setDetail(details, "namespace", a.GetNamespace())
+			setExecution(details, "", a.GetWorkflowExecution())
+			setDetail(details, "initiated_event_id", a.GetInitiatedEventId())
+
+		case *history.HistoryEvent_StartChildWorkflowExecutionInitiatedEventAttributes:
+			a := attr.StartChildWorkflowExecutionInitiatedEventAttributes
+			extractJSON(a.GetInput(), &details)
+			setDetail(details, "namespace", a.GetNamespace())
+			setDetail(details, "workflow_id", a.GetWorkflowId())
+			setDetail(details, "workflow_type", a.GetWorkflowType().GetName())
+			setDetail(details, "task_queue", a.GetTaskQueue().GetName())
+			setEnum(details, "parent_close_policy", a.GetParentClosePolicy())
+			setDetail(details, "execution_timeout", a.GetWorkflowExecutionTimeout())
+			setDetail(details, "run_timeout", a.GetWorkflowRunTimeout())
+			setDetail(details, "cron_schedule", a.GetCronSchedule())
+			setDetail(details, "retry_policy", formatRetryPolicy(a.GetRetryPolicy()))
+
+		case *history.HistoryEvent_StartChildWorkflowExecutionFailedEventAttributes:
+			a := attr.StartChildWorkflowExecutionFailedEventAttributes
+			setEnum(details, "cause", a.GetCause())
+			setDetail(details, "namespace", a.GetNamespace())
+			setDetail(details, "workflow_id", a.GetWorkflowId())
+			setDetail(details, "workflow_type", a.GetWorkflowType().GetName())
+			setDetail(details, "initiated_event_id", a.GetInitiatedEventId())
+
+		case *history.HistoryEvent_ChildWorkflowExecutionStartedEventAttributes:
+			a := attr.ChildWorkflowExecutionStartedEventAttributes
+			setDetail(details, "namespace", a.GetNamespace())
+			setExecution(details, "", a.GetWorkflowExecution())
+			setDetail(details, "workflow_type", a.GetWorkflowType().GetName())
+			setDetail(details, "initiated_event_id", a.GetInitiatedEventId())
+
+		case *history.HistoryEvent_ChildWorkflowExecutionCompletedEventAttributes:
+			a := attr.ChildWorkflowExecutionCompletedEventAttributes
+			extractJSON(a.GetResult(), &details)
+			setDetail(details, "namespace", a.GetNamespace())
+			setExecution(details, "", a.GetWorkflowExecution())
+			setDetail(details, "workflow_type", a.GetWorkflowType().GetName())
+			setDetail(details, "initiated_event_id", a.GetInitiatedEventId())
+			setDetail(details, "started_event_id", a.GetStartedEventId())
+
+		case *history.HistoryEvent_ChildWorkflowExecutionFailedEventAttributes:
+			a := attr.ChildWorkflowExecutionFailedEventAttributes
+			if a.GetFailure() != nil {
+				details["error"] = a.GetFailure().GetMessage()
+				setFailure(details, "failure", a.GetFailure())
+			}
+			setDetail(details, "namespace", a.GetNamespace())
+			setExecution(details, "", a.GetWorkflowExecution())
+			setDetail(details, "workflow_type", a.GetWorkflowType().GetName())
+			setDetail(details, "initiated_event_id", a.GetInitiatedEventId())
+			setDetail(details, "started_event_id", a.GetStartedEventId())
+			setEnum(details, "retry_state", a.GetRetryState())
+
+		case *history.HistoryEvent_ChildWorkflowExecutionCanceledEventAttributes:
+			a := attr.ChildWorkflowExecutionCanceledEventAttributes
+			extractJSON(a.GetDetails(), &details)
+			setDetail(details, "namespace", a.GetNamespace())
+			setExecution(details, "", a.GetWorkflowExecution())
+			setDetail(details, "workflow_type", a.GetWorkflowType().GetName())
+			setDetail(details, "initiated_event_id", a.GetInitiatedEventId())
+			setDetail(details, "started_event_id", a.GetStartedEventId())
+
+		case *history.HistoryEvent_ChildWorkflowExecutionTimedOutEventAttributes:
+			a := attr.ChildWorkflowExecutionTimedOutEventAttributes
+			setDetail(details, "namespace", a.GetNamespace())
+			setExecution(details, "", a.GetWorkflowExecution())
+			setDetail(details, "workflow_type", a.GetWorkflowType().GetName())
+			setDetail(details, "initiated_event_id", a.GetInitiatedEventId())
+			setDetail(details, "started_event_id", a.GetStartedEventId())
+			setEnum(details, "retry_state", a.GetRetryState())
+
+		case *history.HistoryEvent_ChildWorkflowExecutionTerminatedEventAttributes:
+			a := attr.ChildWorkflowExecutionTerminatedEventAttributes
+			setDetail(details, "namespace", a.GetNamespace())
+			setExecution(details, "", a.GetWorkflowExecution())
+			setDetail(details, "workflow_type", a.GetWorkflowType().GetName())
+			setDetail(details, "initiated_event_id", a.GetInitiatedEventId())
+			setDetail(details, "started_event_id", a.GetStartedEventId())
+
+		case *history.HistoryEvent_UpsertWorkflowSearchAttributesEventAttributes:
+			setDetail(details, "search_attributes", decodePayloadMap(attr.UpsertWorkflowSearchAttributesEventAttributes.GetSearchAttributes().GetIndexedFields()))
+
+		case *history.HistoryEvent_WorkflowPropertiesModifiedEventAttributes:
+			setDetail(details, "memo", decodePayloadMap(attr.WorkflowPropertiesModifiedEventAttributes.GetUpsertedMemo().GetFields()))
+
+		case *history.HistoryEvent_WorkflowPropertiesModifiedExternallyEventAttributes:
+			a := attr.WorkflowPropertiesModifiedExternallyEventAttributes
+			setDetail(details, "task_queue", a.GetNewTaskQueue())
+			setDetail(details, "workflow_task_timeout", a.GetNewWorkflowTaskTimeout())
+			setDetail(details, "run_timeout", a.GetNewWorkflowRunTimeout())
+			setDetail(details, "execution_timeout", a.GetNewWorkflowExecutionTimeout())
+			setDetail(details, "memo", decodePayloadMap(a.GetUpsertedMemo().GetFields()))
+
+		case *history.HistoryEvent_WorkflowExecutionUpdateAcceptedEventAttributes:
+			if attr.WorkflowExecutionUpdateAcceptedEventAttributes != nil && attr.WorkflowExecutionUpdateAcceptedEventAttributes.AcceptedRequest != nil && attr.WorkflowExecutionUpdateAcceptedEventAttributes.AcceptedRequest.GetInput() != nil {
+				updateInput := attr.WorkflowExecutionUpdateAcceptedEventAttributes.AcceptedRequest.GetInput()
+				if updateInput.GetName() != "" {
+					details["update_name"] = updateInput.GetName()
+				}
+
+				extractJSON(&common.Payloads{Payloads: updateInput.Args.Payloads}, &details)
+			}
+			setDetail(details, "update_id", attr.WorkflowExecutionUpdateAcceptedEventAttributes.GetAcceptedRequest().GetMeta().GetUpdateId())
+			setDetail(details, "identity", attr.WorkflowExecutionUpdateAcceptedEventAttributes.GetAcceptedRequest().GetMeta().GetIdentity())
+
+		case *history.HistoryEvent_WorkflowExecutionUpdateRejectedEventAttributes:
+			a := attr.WorkflowExecutionUpdateRejectedEventAttributes
+			setDetail(details, "update_id", a.GetRejectedRequest().GetMeta().GetUpdateId())
+			setDetail(details, "update_name", a.GetRejectedRequest().GetInput().GetName())
+			setDetail(details, "identity", a.GetRejectedRequest().GetMeta().GetIdentity())
+			extractJSON(a.GetRejectedRequest().GetInput().GetArgs(), &details)
+			setFailure(details, "rejection", a.GetFailure())
+
+		case *history.HistoryEvent_WorkflowExecutionUpdateCompletedEventAttributes:
+			a := attr.WorkflowExecutionUpdateCompletedEventAttributes
+			setDetail(details, "update_id", a.GetMeta().GetUpdateId())
+			extractJSON(a.GetOutcome().GetSuccess(), &details)
+			setFailure(details, "update_failure", a.GetOutcome().GetFailure())
+
 		default:
 			return Event{}, false
 		}
@@ -93,22 +409,93 @@ func FormatEvent(e *history.HistoryEvent, summaryOnly bool) (Event, bool) {
 	}, true
 }
 
-func extractJSON(payload *common.Payloads, out *map[string]interface{}) {
-	if payload == nil {
+// setDetail records value under key unless it is a zero value, keeping the
+// details map free of fields the event did not set.
+func setDetail(details map[string]interface{}, key string, value interface{}) {
+	switch v := value.(type) {
+	case nil:
 		return
+	case string:
+		if v == "" {
+			return
+		}
+	case int64:
+		if v == 0 {
+			return
+		}
+	case int32:
+		if v == 0 {
+			return
+		}
+	case bool:
+		if !v {
+			return
+		}
+	case *time.Duration:
+		if v == nil {
+			return
+		}
+		value = v.String()
+	case *time.Time:
+		if v == nil {
+			return
+		}
+		value = v.UTC().Format(time.RFC3339)
+	case map[string]interface{}:
+		if len(v) == 0 {
+			return
+		}
+	case []interface{}:
+		if len(v) == 0 {
+			return
+		}
 	}
+	details[key] = value
+}
 
-	data := payload.GetPayloads()
-	if len(data) == 0 {
-		return
+// setEnum records an enum by name, skipping the unspecified zero value.
+func setEnum(details map[string]interface{}, key string, value fmt.Stringer) {
+	setDetail(details, key, enumName(value))
+}
+
+// enumName returns the enum's name, or an empty string for the unspecified
+// zero value.
+func enumName(value fmt.Stringer) string {
+	if name := value.String(); name != "Unspecified" {
+		return name
 	}
+	return ""
+}
 
-	for i, p := range data {
-		var decoded interface{}
-		if err := json.Unmarshal(p.GetData(), &decoded); err != nil {
-			(*out)[fmt.Sprintf("input_part_%d", i)] = string(p.GetData())
-		} else {
-			(*out)[fmt.Sprintf("input_part_%d", i)] = decoded
-		}
+// setExecution records a workflow execution reference as <prefix>workflow_id
+// and <prefix>run_id.
+func setExecution(details map[string]interface{}, prefix string, execution *common.WorkflowExecution) {
+	setDetail(details, prefix+"workflow_id", execution.GetWorkflowId())
+	setDetail(details, prefix+"run_id", execution.GetRunId())
+}
+
+func formatRetryPolicy(policy *common.RetryPolicy) map[string]interface{} {
+	if policy == nil {
+		return nil
+	}
+	out := make(map[string]interface{})
+	setDetail(out, "initial_interval", policy.GetInitialInterval())
+	if policy.GetBackoffCoefficient() != 0 {
+		out["backoff_coefficient"] = policy.GetBackoffCoefficient()
+	}
+	setDetail(out, "maximum_interval", policy.GetMaximumInterval())
+	setDetail(out, "maximum_attempts", policy.GetMaximumAttempts())
+	if len(policy.GetNonRetryableErrorTypes()) > 0 {
+		out["non_retryable_error_types"] = policy.GetNonRetryableErrorTypes()
+	}
+	return out
+}
+
+// formatTime renders a timestamp the same way as Event.Timestamp, or an empty
+// string when it is unset.
+func formatTime(t *time.Time) string {
+	if t == nil || t.IsZero() {
+		return ""
 	}
+	return t.UTC().Format(time.RFC3339)
 }
@@ -141,3 +141,22 @@ func TestFormatEvent_UnknownEvent(t *testing.T) {
 	assert.Equal(t, Event{}, formattedEvent)
 }
 
+func TestFormatEvent_ActivityTaskCanceledEvent(t *testing.T) {
+	now := time.Now()
+	event := &history.HistoryEvent{
+		EventId:   5,
+		EventTime: &now,
+		EventType: enums.EVENT_TYPE_ACTIVITY_TASK_CANCELED,
+		Attributes: &history.HistoryEvent_ActivityTaskCanceledEventAttributes{
+			ActivityTaskCanceledEventAttributes: &history.ActivityTaskCanceledEventAttributes{
+				ScheduledEventId: 2,
+			},
+		},
+	}
+
+	formattedEvent, keep := FormatEvent(event, false)
+	assert.True(t, keep)
+	assert.Equal(t, int64(5), formattedEvent.EventID)
+	assert.Equal(t, "ActivityTaskCanceled", formattedEvent.Type)
+	assert.Equal(t, int64(2), formattedEvent.Details["scheduled_event_id"])
+}
@@ -0,0 +1,181 @@
+package handler
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"go.temporal.io/api/enums/v1"
+	"go.temporal.io/api/history/v1"
+)
+
+// eventCategories name groups of event types that can be used in place of
+// individual types when including or excluding events. Each maps to the
+// substring shared by the type names in the group.
+var eventCategories = map[string]string{
+	"activity":       "ActivityTask",
+	"child_workflow": "ChildWorkflowExecution",
+	"marker":         "MarkerRecorded",
+	"signal":         "Signal",
+	"timer":          "Timer",
+	"update":         "WorkflowExecutionUpdate",
+	"workflow_task":  "WorkflowTask",
+}
+
+// historyFilter selects the events workflow_history returns. It observes every
+// event so that lifecycle events can be matched to the activity type or signal
+// name recorded on the event that started them.
+type historyFilter struct {
+	include      map[enums.EventType]bool
+	exclude      map[enums.EventType]bool
+	window       timeWindow
+	activityType string
+	signalName   string
+	activities   map[int64]string
+	signals      map[int64]string
+}
+
+func newHistoryFilter(args WorkflowHistoryArgs) (*historyFilter, error) {
+	f := &historyFilter{
+		activityType: args.ActivityType,
+		signalName:   args.SignalName,
+		activities:   make(map[int64]string),
+		signals:      make(map[int64]string),
+	}
+
+	var err error
+	if f.include, err = parseEventTypes(args.IncludeEventTypes); err != nil {
+		return nil, fmt.Errorf("invalid include_event_types: %w", err)
+	}
+	if f.exclude, err = parseEventTypes(args.ExcludeEventTypes); err != nil {
+		return nil, fmt.Errorf("invalid exclude_event_types: %w", err)
+	}
+	if args.StartTime != "" {
+		if f.window.Start, err = time.Parse(time.RFC3339, args.StartTime); err != nil {
+			return nil, fmt.Errorf("invalid start_time: %w", err)
+		}
+	}
+	if args.EndTime != "" {
+		if f.window.End, err = time.Parse(time.RFC3339, args.EndTime); err != nil {
+			return nil, fmt.Errorf("invalid end_time: %w", err)
+		}
+	}
+	return f, nil
+}
+
+// parseEventTypes resolves a list of event type names (e.g.
+// ActivityTaskFailed) and categories (e.g. activity) into a set of types.
+func parseEventTypes(names []string) (map[enums.EventType]bool, error) {
+	if len(names) == 0 {
+		return nil, nil
+	}
+	types := make(map[enums.EventType]bool)
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		if substr, ok := eventCategories[strings.ToLower(name)]; ok {
+			for typeName, v := range enums.EventType_value {
+				if strings.Contains(typeName, substr) {
+					types[enums.EventType(v)] = true
+				}
+			}
+			continue
+		}
+		v, ok := enums.EventType_value[name]
+		if !ok || v == 0 {
+			return nil, fmt.Errorf("unknown event type %q (expected an event type such as ActivityTaskFailed or one of %s)", name, strings.Join(categoryNames(), ", "))
+		}
+		types[enums.EventType(v)] = true
+	}
+	return types, nil
+}
+
+func categoryNames() []string {
+	names := make([]string, 0, len(eventCategories))
+	for name := range eventCategories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (f *historyFilter) active() bool {
+	return f.include != nil || f.exclude != nil || !f.window.Start.IsZero() || !f.window.End.IsZero() ||
+		f.activityType != "" || f.signalName != ""
+}
+
+// observe records the activity type and signal name of events that later
+// lifecycle events refer back to. It must see every event, including those
+// outside the requested range.
+func (f *historyFilter) observe(e *history.HistoryEvent) {
+	if attrs := e.GetActivityTaskScheduledEventAttributes(); attrs != nil && f.activityType != "" {
+		f.activities[e.GetEventId()] = attrs.GetActivityType().GetName()
+	}
+	if attrs := e.GetSignalExternalWorkflowExecutionInitiatedEventAttributes(); attrs != nil && f.signalName != "" {
+		f.signals[e.GetEventId()] = attrs.GetSignalName()
+	}
+}
+
+// afterWindow reports whether the event, and so every later one, is past the
+// end of the time window.
+func (f *historyFilter) afterWindow(e *history.HistoryEvent) bool {
+	return !f.window.End.IsZero() && e.GetEventTime() != nil && e.GetEventTime().After(f.window.End)
+}
+
+func (f *historyFilter) match(e *history.HistoryEvent) bool {
+	if f.include != nil && !f.include[e.GetEventType()] {
+		return false
+	}
+	if f.exclude[e.GetEventType()] {
+		return false
+	}
+	if e.GetEventTime() != nil && !f.window.contains(*e.GetEventTime()) {
+		return false
+	}
+	if f.activityType == "" && f.signalName == "" {
+		return true
+	}
+	return (f.activityType != "" && f.activityType == f.activityTypeOf(e)) ||
+		(f.signalName != "" && f.signalName == f.signalNameOf(e))
+}
+
+func (f *historyFilter) activityTypeOf(e *history.HistoryEvent) string {
+	if attrs := e.GetActivityTaskScheduledEventAttributes(); attrs != nil {
+		return attrs.GetActivityType().GetName()
+	}
+	var scheduledID int64
+	switch {
+	case e.GetActivityTaskStartedEventAttributes() != nil:
+		scheduledID = e.GetActivityTaskStartedEventAttributes().GetScheduledEventId()
+	case e.GetActivityTaskCompletedEventAttributes() != nil:
+		scheduledID = e.GetActivityTaskCompletedEventAttributes().GetScheduledEventId()
+	case e.GetActivityTaskFailedEventAttributes() != nil:
+		scheduledID = e.GetActivityTaskFailedEventAttributes().GetScheduledEventId()
+	case e.GetActivityTaskTimedOutEventAttributes() != nil:
+		scheduledID = e.GetActivityTaskTimedOutEventAttributes().GetScheduledEventId()
+	case e.GetActivityTaskCancelRequestedEventAttributes() != nil:
+		scheduledID = e.GetActivityTaskCancelRequestedEventAttributes().GetScheduledEventId()
+	case e.GetActivityTaskCanceledEventAttributes() != nil:
+		scheduledID = e.GetActivityTaskCanceledEventAttributes().GetScheduledEventId()
+	default:
+		return ""
+	}
+	return f.activities[scheduledID]
+}
+
+func (f *historyFilter) signalNameOf(e *history.HistoryEvent) string {
+	switch {
+	case e.GetWorkflowExecutionSignaledEventAttributes() != nil:
+		return e.GetWorkflowExecutionSignaledEventAttributes().GetSignalName()
+	case e.GetSignalExternalWorkflowExecutionInitiatedEventAttributes() != nil:
+		return e.GetSignalExternalWorkflowExecutionInitiatedEventAttributes().GetSignalName()
+	case e.GetExternalWorkflowExecutionSignaledEventAttributes() != nil:
+		return f.signals[e.GetExternalWorkflowExecutionSignaledEventAttributes().GetInitiatedEventId()]
+	case e.GetSignalExternalWorkflowExecutionFailedEventAttributes() != nil:
+		return f.signals[e.GetSignalExternalWorkflowExecutionFailedEventAttributes().GetInitiatedEventId()]
+	}
+	return ""
+}
@@ -0,0 +1,80 @@
+package handler
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.temporal.io/api/common/v1"
+	"go.temporal.io/api/enums/v1"
+	"go.temporal.io/api/failure/v1"
+	"go.temporal.io/api/history/v1"
+)
+
+func filterTestEvents() []*history.HistoryEvent {
+	start := time.Date(2024, 1, 2, 3, 0, 0, 0, time.UTC)
+	at := func(minutes int) *time.Time {
+		t := start.Add(time.Duration(minutes) * time.Minute)
+		return &t
+	}
+	return []*history.HistoryEvent{
+		{EventId: 1, EventTime: at(0), EventType: enums.EVENT_TYPE_WORKFLOW_EXECUTION_STARTED,
+			Attributes: &history.HistoryEvent_WorkflowExecutionStartedEventAttributes{WorkflowExecutionStartedEventAttributes: &history.WorkflowExecutionStartedEventAttributes{}}},
+		{EventId: 2, EventTime: at(1), EventType: enums.EVENT_TYPE_ACTIVITY_TASK_SCHEDULED,
+			Attributes: &history.HistoryEvent_ActivityTaskScheduledEventAttributes{ActivityTaskScheduledEventAttributes: &history.ActivityTaskScheduledEventAttributes{ActivityType: &common.ActivityType{Name: "Charge"}}}},
+		{EventId: 3, EventTime: at(2), EventType: enums.EVENT_TYPE_ACTIVITY_TASK_SCHEDULED,
+			Attributes: &history.HistoryEvent_ActivityTaskScheduledEventAttributes{ActivityTaskScheduledEventAttributes: &history.ActivityTaskScheduledEventAttributes{ActivityType: &common.ActivityType{Name: "Ship"}}}},
+		{EventId: 4, EventTime: at(3), EventType: enums.EVENT_TYPE_ACTIVITY_TASK_STARTED,
+			Attributes: &history.HistoryEvent_ActivityTaskStartedEventAttributes{ActivityTaskStartedEventAttributes: &history.ActivityTaskStartedEventAttributes{ScheduledEventId: 2}}},
+		{EventId: 5, EventTime: at(4), EventType: enums.EVENT_TYPE_ACTIVITY_TASK_COMPLETED,
+			Attributes: &history.HistoryEvent_ActivityTaskCompletedEventAttributes{ActivityTaskCompletedEventAttributes: &history.ActivityTaskCompletedEventAttributes{ScheduledEventId: 2}}},
+		{EventId: 6, EventTime: at(5), EventType: enums.EVENT_TYPE_WORKFLOW_EXECUTION_SIGNALED,
+			Attributes: &history.HistoryEvent_WorkflowExecutionSignaledEventAttributes{WorkflowExecutionSignaledEventAttributes: &history.WorkflowExecutionSignaledEventAttributes{SignalName: "approve"}}},
+		{EventId: 7, EventTime: at(6), EventType: enums.EVENT_TYPE_WORKFLOW_EXECUTION_SIGNALED,
+			Attributes: &history.HistoryEvent_WorkflowExecutionSignaledEventAttributes{WorkflowExecutionSignaledEventAttributes: &history.WorkflowExecutionSignaledEventAttributes{SignalName: "cancel"}}},
+		{EventId: 8, EventTime: at(7), EventType: enums.EVENT_TYPE_ACTIVITY_TASK_FAILED,
+			Attributes: &history.HistoryEvent_ActivityTaskFailedEventAttributes{ActivityTaskFailedEventAttributes: &history.ActivityTaskFailedEventAttributes{ScheduledEventId: 3, Failure: &failure.Failure{Message: "no stock"}}}},
+	}
+}
+
+func TestGetWorkflowHistoryHandler_Filters(t *testing.T) {
+	mockClient := newFakeClient(t, &fakeRun{workflowID: "wf-1", runID: "run-1", events: filterTestEvents()})
+
+	tests := []struct {
+		name string
+		args WorkflowHistoryArgs
+		want []int64
+	}{
+		{"include categories", WorkflowHistoryArgs{IncludeEventTypes: []string{"signal"}}, []int64{6, 7}},
+		{"include type names", WorkflowHistoryArgs{IncludeEventTypes: []string{"ActivityTaskFailed", "WorkflowExecutionStarted"}}, []int64{1, 8}},
+		{"exclude", WorkflowHistoryArgs{ExcludeEventTypes: []string{"activity"}}, []int64{1, 6, 7}},
+		{"time window", WorkflowHistoryArgs{StartTime: "2024-01-02T03:03:00Z", EndTime: "2024-01-02T03:05:00Z"}, []int64{4, 5, 6}},
+		{"activity type", WorkflowHistoryArgs{ActivityType: "Charge"}, []int64{2, 4, 5}},
+		{"activity type or signal name", WorkflowHistoryArgs{ActivityType: "Ship", SignalName: "approve"}, []int64{3, 6, 8}},
+		{"activity type with event range", WorkflowHistoryArgs{ActivityType: "Charge", StartEventID: 3}, []int64{4, 5}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.args.WorkflowID = "wf-1"
+			result, err := GetWorkflowHistoryHandler(context.Background(), mockClient, tt.args)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, eventIDs(result.Events))
+			assert.Contains(t, result.Summary, "Only events matching the requested filters are included")
+		})
+	}
+}
+
+func TestGetWorkflowHistoryHandler_InvalidFilters(t *testing.T) {
+	mockClient := newFakeClient(t, &fakeRun{workflowID: "wf-1", runID: "run-1", events: filterTestEvents()})
+
+	_, err := GetWorkflowHistoryHandler(context.Background(), mockClient, WorkflowHistoryArgs{WorkflowID: "wf-1", IncludeEventTypes: []string{"Activity"}})
+	assert.NoError(t, err)
+
+	_, err = GetWorkflowHistoryHandler(context.Background(), mockClient, WorkflowHistoryArgs{WorkflowID: "wf-1", IncludeEventTypes: []string{"Bogus"}})
+	assert.EqualError(t, err, `invalid include_event_types: unknown event type "Bogus" (expected an event type such as ActivityTaskFailed or one of activity, child_workflow, marker, signal, timer, update, workflow_task)`)
+
+	_, err = GetWorkflowHistoryHandler(context.Background(), mockClient, WorkflowHistoryArgs{WorkflowID: "wf-1", StartTime: "yesterday"})
+	assert.ErrorContains(t, err, "invalid start_time")
+}
@@ -0,0 +1,157 @@
+package handler
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"go.temporal.io/api/workflow/v1"
+	"go.temporal.io/api/workflowservice/v1"
+	"go.temporal.io/sdk/client"
+)
+
+const (
+	defaultListPageSize = 50
+	maxListPageSize     = 1000
+)
+
+type ListWorkflowsArgs struct {
+	Query         string `json:"query,omitempty" jsonschema:"description=Visibility query, e.g. WorkflowType='Order' AND ExecutionStatus='Running'"`
+	PageSize      int    `json:"page_size,omitempty" jsonschema:"description=Maximum number of workflows to return"`
+	NextPageToken string `json:"next_page_token,omitempty" jsonschema:"description=Token from a previous response to fetch the next page"`
+}
+
+type WorkflowInfo struct {
+	WorkflowID       string                 `json:"workflow_id"`
+	RunID            string                 `json:"run_id"`
+	Type             string                 `json:"type"`
+	Status           string                 `json:"status"`
+	TaskQueue        string                 `json:"task_queue,omitempty"`
+	StartTime        string                 `json:"start_time,omitempty"`
+	CloseTime        string                 `json:"close_time,omitempty"`
+	SearchAttributes map[string]interface{} `json:"search_attributes,omitempty"`
+}
+
+type ListWorkflowsResponse struct {
+	Workflows     []WorkflowInfo `json:"workflows"`
+	NextPageToken string         `json:"next_page_token,omitempty"`
+	Summary       string         `json:"summary"`
+}
+
+func ListWorkflowsHandler(ctx context.Context, temporalClient client.Client, args ListWorkflowsArgs) (ListWorkflowsResponse, error) {
+	pageSize := args.PageSize
+	if pageSize <= 0 {
+		pageSize = defaultListPageSize
+	}
+	if pageSize > maxListPageSize {
+		pageSize = maxListPageSize
+	}
+
+	var token []byte
+	if args.NextPageToken != "" {
+		var err error
+		token, err = base64.StdEncoding.DecodeString(args.NextPageToken)
+		if err != nil {
+			return ListWorkflowsResponse{}, fmt.Errorf("invalid next_page_token: %w", err)
+		}
+	}
+
+	resp, err := temporalClient.ListWorkflow(ctx, &workflowservice.ListWorkflowExecutionsRequest{
+		PageSize:      int32(pageSize),
+		NextPageToken: token,
+		Query:         args.Query,
+	})
+	if err != nil {
+		return ListWorkflowsResponse{}, fmt.Errorf("failed to list workflows: %w", err)
+	}
+
+	workflows := make([]WorkflowInfo, 0, len(resp.GetExecutions()))
+	for _, info := range resp.GetExecutions() {
+		workflows = append(workflows, newWorkflowInfo(info))
+	}
+
+	result := ListWorkflowsResponse{
+		Workflows: workflows,
+		Summary:   fmt.Sprintf("Found %d workflows on this page.", len(workflows)),
+	}
+	if len(resp.GetNextPageToken()) > 0 {
+		result.NextPageToken = base64.StdEncoding.EncodeToString(resp.GetNextPageToken())
+		result.Summary += " More results are available with next_page_token."
+	}
+	return result, nil
+}
+
+func newWorkflowInfo(info *workflow.WorkflowExecutionInfo) WorkflowInfo {
+	return WorkflowInfo{
+		WorkflowID:       info.GetExecution().GetWorkflowId(),
+		RunID:            info.GetExecution().GetRunId(),
+		Type:             info.GetType().GetName(),
+		Status:           info.GetStatus().String(),
+		TaskQueue:        info.GetTaskQueue(),
+		StartTime:        formatTime(info.GetStartTime()),
+		CloseTime:        formatTime(info.GetCloseTime()),
+		SearchAttributes: decodePayloadMap(info.GetSearchAttributes().GetIndexedFields()),
+	}
+}
+
+// listExecutions pages through a visibility query until limit executions have
+// been collected or the results are exhausted. It reports whether more
+// results were available beyond the limit.
+func listExecutions(ctx context.Context, temporalClient client.Client, query string, limit int) ([]*workflow.WorkflowExecutionInfo, bool, error) {
+	if limit <= 0 {
+		return nil, false, nil
+	}
+
+	var executions []*workflow.WorkflowExecutionInfo
+	var token []byte
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, false, err
+		}
+
+		pageSize := limit - len(executions)
+		if pageSize > maxListPageSize {
+			pageSize = maxListPageSize
+		}
+		resp, err := temporalClient.ListWorkflow(ctx, &workflowservice.ListWorkflowExecutionsRequest{
+			PageSize:      int32(pageSize),
+			NextPageToken: token,
+			Query:         query,
+		})
+		if err != nil {
+			return nil, false, err
+		}
+
+		for _, info := range resp.GetExecutions() {
+			if len(executions) == limit {
+				return executions, true, nil
+			}
+			executions = append(executions, info)
+		}
+
+		token = resp.GetNextPageToken()
+		if len(token) == 0 {
+			return executions, false, nil
+		}
+		if len(executions) == limit {
+			return executions, true, nil
+		}
+	}
+}
+
+// buildQuery joins the non-empty visibility query clauses with AND.
+func buildQuery(clauses ...string) string {
+	var parts []string
+	for _, c := range clauses {
+		if c != "" {
+			parts = append(parts, c)
+		}
+	}
+	return strings.Join(parts, " AND ")
+}
+
+// quoteQueryValue quotes a string literal for use in a visibility query.
+func quoteQueryValue(v string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(v) + "'"
+}
@@ -0,0 +1,93 @@
+package handler
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.temporal.io/api/common/v1"
+	"go.temporal.io/api/enums/v1"
+	"go.temporal.io/api/workflow/v1"
+	"go.temporal.io/api/workflowservice/v1"
+	"go.temporal.io/sdk/mocks"
+)
+
+func TestListWorkflowsHandler(t *testing.T) {
+	t.Run("Returns a page with continuation token", func(t *testing.T) {
+		mockClient := &mocks.Client{}
+		start := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+		query := "WorkflowType='Order' AND ExecutionStatus='Running'"
+
+		mockClient.On("ListWorkflow", mock.Anything, &workflowservice.ListWorkflowExecutionsRequest{
+			PageSize:      10,
+			NextPageToken: []byte("page-1"),
+			Query:         query,
+		}).Return(&workflowservice.ListWorkflowExecutionsResponse{
+			Executions: []*workflow.WorkflowExecutionInfo{
+				{
+					Execution: &common.WorkflowExecution{WorkflowId: "order-1", RunId: "run-1"},
+					Type:      &common.WorkflowType{Name: "Order"},
+					Status:    enums.WORKFLOW_EXECUTION_STATUS_RUNNING,
+					StartTime: &start,
+					TaskQueue: "orders",
+					SearchAttributes: &common.SearchAttributes{IndexedFields: map[string]*common.Payload{
+						"CustomerId": {Data: []byte(`"c-1"`)},
+					}},
+				},
+			},
+			NextPageToken: []byte("page-2"),
+		}, nil)
+
+		resp, err := ListWorkflowsHandler(context.Background(), mockClient, ListWorkflowsArgs{
+			Query:         query,
+			PageSize:      10,
+			NextPageToken: base64.StdEncoding.EncodeToString([]byte("page-1")),
+		})
+
+		assert.NoError(t, err)
+		assert.Len(t, resp.Workflows, 1)
+		assert.Equal(t, WorkflowInfo{
+			WorkflowID:       "order-1",
+			RunID:            "run-1",
+			Type:             "Order",
+			Status:           "Running",
+			TaskQueue:        "orders",
+			StartTime:        "2024-05-01T00:00:00Z",
+			SearchAttributes: map[string]interface{}{"CustomerId": "c-1"},
+		}, resp.Workflows[0])
+		assert.Equal(t, base64.StdEncoding.EncodeToString([]byte("page-2")), resp.NextPageToken)
+		assert.Equal(t, "Found 1 workflows on this page. More results are available with next_page_token.", resp.Summary)
+		mockClient.AssertExpectations(t)
+	})
+
+	t.Run("Defaults page size", func(t *testing.T) {
+		mockClient := &mocks.Client{}
+		mockClient.On("ListWorkflow", mock.Anything, mock.MatchedBy(func(req *workflowservice.ListWorkflowExecutionsRequest) bool {
+			return req.PageSize == defaultListPageSize && req.Query == ""
+		})).Return(&workflowservice.ListWorkflowExecutionsResponse{}, nil)
+
+		resp, err := ListWorkflowsHandler(context.Background(), mockClient, ListWorkflowsArgs{})
+
+		assert.NoError(t, err)
+		assert.Empty(t, resp.Workflows)
+		assert.Empty(t, resp.NextPageToken)
+		mockClient.AssertExpectations(t)
+	})
+
+	t.Run("Invalid token", func(t *testing.T) {
+		_, err := ListWorkflowsHandler(context.Background(), &mocks.Client{}, ListWorkflowsArgs{NextPageToken: "!!"})
+		assert.ErrorContains(t, err, "invalid next_page_token")
+	})
+
+	t.Run("List error", func(t *testing.T) {
+		mockClient := &mocks.Client{}
+		mockClient.On("ListWorkflow", mock.Anything, mock.Anything).Return(nil, errors.New("bad query"))
+
+		_, err := ListWorkflowsHandler(context.Background(), mockClient, ListWorkflowsArgs{Query: "nope"})
+		assert.EqualError(t, err, "failed to list workflows: bad query")
+	})
+}
@@ -0,0 +1,154 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"go.temporal.io/api/common/v1"
+	"go.temporal.io/api/workflowservice/v1"
+	"go.temporal.io/sdk/client"
+	"go.temporal.io/sdk/converter"
+)
+
+// serverIdentity prefixes the identity recorded in history for every change
+// made through the server, followed by the caller's reason.
+const serverIdentity = "mcp-temporal-server"
+
+type SignalWorkflowArgs struct {
+	WorkflowID string `json:"workflow_id" jsonschema:"required,description=The ID of the workflow to signal"`
+	RunID      string `json:"run_id,omitempty" jsonschema:"description=Optional run ID of the workflow"`
+	SignalName string `json:"signal_name" jsonschema:"required,description=The name of the signal to send"`
+	Args       string `json:"args,omitempty" jsonschema:"description=JSON array of arguments to send with the signal"`
+	Reason     string `json:"reason" jsonschema:"required,description=Why the signal is being sent, recorded as the identity in the workflow history"`
+	Namespace  string `json:"-"`
+}
+
+type CancelWorkflowArgs struct {
+	WorkflowID string `json:"workflow_id" jsonschema:"required,description=The ID of the workflow to cancel"`
+	RunID      string `json:"run_id,omitempty" jsonschema:"description=Optional run ID of the workflow"`
+	Reason     string `json:"reason" jsonschema:"required,description=Why the workflow is being canceled, recorded in the workflow history"`
+	Namespace  string `json:"-"`
+}
+
+type TerminateWorkflowArgs struct {
+	WorkflowID string `json:"workflow_id" jsonschema:"required,description=The ID of the workflow to terminate"`
+	RunID      string `json:"run_id,omitempty" jsonschema:"description=Optional run ID of the workflow"`
+	Reason     string `json:"reason" jsonschema:"required,description=Why the workflow is being terminated, recorded in the workflow history"`
+	Namespace  string `json:"-"`
+}
+
+// MutationResponse reports a change made to a workflow and its state
+// afterwards.
+type MutationResponse struct {
+	WorkflowID string               `json:"workflow_id"`
+	RunID      string               `json:"run_id,omitempty"`
+	Action     string               `json:"action"`
+	Identity   string               `json:"identity"`
+	State      *WorkflowDescription `json:"state,omitempty"`
+	Summary    string               `json:"summary"`
+}
+
+func SignalWorkflowHandler(ctx context.Context, temporalClient client.Client, args SignalWorkflowArgs) (MutationResponse, error) {
+	identity, err := mutationIdentity(args.Reason)
+	if err != nil {
+		return MutationResponse{}, err
+	}
+	if args.SignalName == "" {
+		return MutationResponse{}, fmt.Errorf("signal_name is required")
+	}
+	signalArgs, err := parseJSONArgs(args.Args)
+	if err != nil {
+		return MutationResponse{}, err
+	}
+	input, err := converter.GetDefaultDataConverter().ToPayloads(signalArgs...)
+	if err != nil {
+		return MutationResponse{}, fmt.Errorf("failed to encode signal arguments: %w", err)
+	}
+
+	_, err = temporalClient.WorkflowService().SignalWorkflowExecution(ctx, &workflowservice.SignalWorkflowExecutionRequest{
+		Namespace:         args.Namespace,
+		WorkflowExecution: &common.WorkflowExecution{WorkflowId: args.WorkflowID, RunId: args.RunID},
+		SignalName:        args.SignalName,
+		Input:             input,
+		Identity:          identity,
+		RequestId:         uuid.NewString(),
+	})
+	if err != nil {
+		return MutationResponse{}, fmt.Errorf("failed to signal workflow: %w", err)
+	}
+
+	resp := mutationResult(ctx, temporalClient, args.WorkflowID, args.RunID, "signal", identity)
+	resp.Summary = fmt.Sprintf("Sent signal %s. %s", args.SignalName, resp.Summary)
+	return resp, nil
+}
+
+func CancelWorkflowHandler(ctx context.Context, temporalClient client.Client, args CancelWorkflowArgs) (MutationResponse, error) {
+	identity, err := mutationIdentity(args.Reason)
+	if err != nil {
+		return MutationResponse{}, err
+	}
+
+	_, err = temporalClient.WorkflowService().RequestCancelWorkflowExecution(ctx, &workflowservice.RequestCancelWorkflowExecutionRequest{
+		Namespace:         args.Namespace,
+		WorkflowExecution: &common.WorkflowExecution{WorkflowId: args.WorkflowID, RunId: args.RunID},
+		Identity:          identity,
+		RequestId:         uuid.NewString(),
+		Reason:            args.Reason,
+	})
+	if err != nil {
+		return MutationResponse{}, fmt.Errorf("failed to cancel workflow: %w", err)
+	}
+
+	resp := mutationResult(ctx, temporalClient, args.WorkflowID, args.RunID, "cancel", identity)
+	resp.Summary = "Requested cancellation; the workflow closes as Canceled once its code handles the request. " + resp.Summary
+	return resp, nil
+}
+
+func TerminateWorkflowHandler(ctx context.Context, temporalClient client.Client, args TerminateWorkflowArgs) (MutationResponse, error) {
+	identity, err := mutationIdentity(args.Reason)
+	if err != nil {
+		return MutationResponse{}, err
+	}
+
+	_, err = temporalClient.WorkflowService().TerminateWorkflowExecution(ctx, &workflowservice.TerminateWorkflowExecutionRequest{
+		Namespace:         args.Namespace,
+		WorkflowExecution: &common.WorkflowExecution{WorkflowId: args.WorkflowID, RunId: args.RunID},
+		Reason:            args.Reason,
+		Identity:          identity,
+	})
+	if err != nil {
+		return MutationResponse{}, fmt.Errorf("failed to terminate workflow: %w", err)
+	}
+
+	resp := mutationResult(ctx, temporalClient, args.WorkflowID, args.RunID, "terminate", identity)
+	resp.Summary = "Terminated the workflow. " + resp.Summary
+	return resp, nil
+}
+
+// mutationIdentity validates the reason for a change and returns the identity
+// recorded with it.
+func mutationIdentity(reason string) (string, error) {
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return "", fmt.Errorf("a reason is required for changes to workflows")
+	}
+	return fmt.Sprintf("%s: %s", serverIdentity, reason), nil
+}
+
+// mutationResult describes the workflow after a change. The change has
+// already been made, so failing to describe the workflow is reported in the
+// summary rather than as an error.
+func mutationResult(ctx context.Context, temporalClient client.Client, workflowID, runID, action, identity string) MutationResponse {
+	resp := MutationResponse{WorkflowID: workflowID, RunID: runID, Action: action, Identity: identity}
+	state, err := DescribeWorkflowHandler(ctx, temporalClient, DescribeWorkflowArgs{WorkflowID: workflowID, RunID: runID})
+	if err != nil {
+		resp.Summary = fmt.Sprintf("Could not read the workflow state afterwards: %v", err)
+		return resp
+	}
+	resp.RunID = state.RunID
+	resp.State = &state
+	resp.Summary = fmt.Sprintf("Workflow is now %s.", state.Status)
+	return resp
+}
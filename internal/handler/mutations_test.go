@@ -0,0 +1,101 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.temporal.io/api/common/v1"
+	"go.temporal.io/api/enums/v1"
+	"go.temporal.io/api/workflow/v1"
+	"go.temporal.io/api/workflowservice/v1"
+	"go.temporal.io/sdk/mocks"
+)
+
+func mutationClient(service *fakeService, status enums.WorkflowExecutionStatus) *mocks.Client {
+	mockClient := &mocks.Client{}
+	mockClient.On("WorkflowService").Return(service)
+	mockClient.On("DescribeWorkflowExecution", mock.Anything, "wf-1", "").Return(&workflowservice.DescribeWorkflowExecutionResponse{
+		WorkflowExecutionInfo: &workflow.WorkflowExecutionInfo{
+			Execution: &common.WorkflowExecution{WorkflowId: "wf-1", RunId: "run-1"},
+			Status:    status,
+		},
+	}, nil)
+	return mockClient
+}
+
+func TestSignalWorkflowHandler(t *testing.T) {
+	service := newFakeService(t)
+	mockClient := mutationClient(service, enums.WORKFLOW_EXECUTION_STATUS_RUNNING)
+
+	result, err := SignalWorkflowHandler(context.Background(), mockClient, SignalWorkflowArgs{
+		WorkflowID: "wf-1",
+		SignalName: "approve",
+		Args:       `[{"approver":"ops"}]`,
+		Reason:     "manual approval for INC-42",
+		Namespace:  "orders",
+	})
+
+	require.NoError(t, err)
+	require.NotNil(t, service.signal)
+	assert.Equal(t, "orders", service.signal.GetNamespace())
+	assert.Equal(t, "approve", service.signal.GetSignalName())
+	assert.Equal(t, "mcp-temporal-server: manual approval for INC-42", service.signal.GetIdentity())
+	assert.NotEmpty(t, service.signal.GetRequestId())
+	require.Len(t, service.signal.GetInput().GetPayloads(), 1)
+	assert.JSONEq(t, `{"approver":"ops"}`, string(service.signal.GetInput().GetPayloads()[0].GetData()))
+
+	assert.Equal(t, "signal", result.Action)
+	assert.Equal(t, "run-1", result.RunID)
+	require.NotNil(t, result.State)
+	assert.Equal(t, "Running", result.State.Status)
+	assert.Equal(t, "Sent signal approve. Workflow is now Running.", result.Summary)
+}
+
+func TestCancelWorkflowHandler(t *testing.T) {
+	service := newFakeService(t)
+	mockClient := mutationClient(service, enums.WORKFLOW_EXECUTION_STATUS_RUNNING)
+
+	result, err := CancelWorkflowHandler(context.Background(), mockClient, CancelWorkflowArgs{WorkflowID: "wf-1", Reason: "stuck on bad input"})
+
+	require.NoError(t, err)
+	require.NotNil(t, service.cancel)
+	assert.Equal(t, "stuck on bad input", service.cancel.GetReason())
+	assert.Equal(t, "mcp-temporal-server: stuck on bad input", service.cancel.GetIdentity())
+	assert.Contains(t, result.Summary, "Requested cancellation")
+}
+
+func TestTerminateWorkflowHandler(t *testing.T) {
+	t.Run("Terminates with reason", func(t *testing.T) {
+		service := newFakeService(t)
+		mockClient := mutationClient(service, enums.WORKFLOW_EXECUTION_STATUS_TERMINATED)
+
+		result, err := TerminateWorkflowHandler(context.Background(), mockClient, TerminateWorkflowArgs{WorkflowID: "wf-1", Reason: "duplicate order"})
+
+		require.NoError(t, err)
+		require.NotNil(t, service.terminate)
+		assert.Equal(t, "duplicate order", service.terminate.GetReason())
+		assert.Equal(t, "Terminated the workflow. Workflow is now Terminated.", result.Summary)
+	})
+
+	t.Run("Reason is required", func(t *testing.T) {
+		service := newFakeService(t)
+
+		_, err := TerminateWorkflowHandler(context.Background(), mutationClient(service, enums.WORKFLOW_EXECUTION_STATUS_RUNNING), TerminateWorkflowArgs{WorkflowID: "wf-1", Reason: "  "})
+
+		assert.ErrorContains(t, err, "a reason is required")
+		assert.Nil(t, service.terminate)
+	})
+
+	t.Run("Server error", func(t *testing.T) {
+		service := newFakeService(t)
+		service.err = errors.New("workflow not found")
+
+		_, err := TerminateWorkflowHandler(context.Background(), mutationClient(service, enums.WORKFLOW_EXECUTION_STATUS_RUNNING), TerminateWorkflowArgs{WorkflowID: "wf-1", Reason: "cleanup"})
+
+		assert.ErrorContains(t, err, "failed to terminate workflow: workflow not found")
+	})
+}
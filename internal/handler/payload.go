@@ -0,0 +1,88 @@
+package handler
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"unicode/utf8"
+
+	"go.temporal.io/api/common/v1"
+	"go.temporal.io/sdk/converter"
+)
+
+func extractJSON(payload *common.Payloads, out *map[string]interface{}) {
+	if payload == nil {
+		return
+	}
+
+	data := payload.GetPayloads()
+	if len(data) == 0 {
+		return
+	}
+
+	for i, p := range data {
+		(*out)[fmt.Sprintf("input_part_%d", i)] = decodePayload(p)
+	}
+}
+
+// decodePayload renders a payload according to its encoding metadata. JSON
+// encodings are decoded, binary/null becomes nil and binary/plain becomes a
+// string when it is valid UTF-8. Anything else, such as a payload that is
+// still encrypted because no codec is configured, is returned as its encoding
+// and base64 data so the caller can at least tell what it is. Payloads with no
+// encoding are treated as JSON, falling back to the raw data as a string.
+func decodePayload(p *common.Payload) interface{} {
+	if p == nil {
+		return nil
+	}
+
+	encoding := string(p.GetMetadata()[converter.MetadataEncoding])
+	switch encoding {
+	case converter.MetadataEncodingNil:
+		return nil
+	case "", converter.MetadataEncodingJSON, converter.MetadataEncodingProtoJSON:
+		var decoded interface{}
+		if err := json.Unmarshal(p.GetData(), &decoded); err != nil {
+			return string(p.GetData())
+		}
+		return decoded
+	case converter.MetadataEncodingBinary:
+		if utf8.Valid(p.GetData()) {
+			return string(p.GetData())
+		}
+	}
+
+	out := map[string]interface{}{
+		"encoding": encoding,
+		"data":     base64.StdEncoding.EncodeToString(p.GetData()),
+	}
+	if messageType := string(p.GetMetadata()[converter.MetadataMessageType]); messageType != "" {
+		out["message_type"] = messageType
+	}
+	return out
+}
+
+// decodePayloads decodes a list of payloads for fields that sit alongside an
+// event's main input or result.
+func decodePayloads(payloads *common.Payloads) []interface{} {
+	if len(payloads.GetPayloads()) == 0 {
+		return nil
+	}
+	out := make([]interface{}, 0, len(payloads.GetPayloads()))
+	for _, p := range payloads.GetPayloads() {
+		out = append(out, decodePayload(p))
+	}
+	return out
+}
+
+// decodePayloadMap decodes each payload in a memo or search attribute map.
+func decodePayloadMap(fields map[string]*common.Payload) map[string]interface{} {
+	if len(fields) == 0 {
+		return nil
+	}
+	out := make(map[string]interface{}, len(fields))
+	for k, p := range fields {
+		out[k] = decodePayload(p)
+	}
+	return out
+}
@@ -0,0 +1,49 @@
+package handler
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.temporal.io/api/common/v1"
+)
+
+func payloadWithEncoding(encoding, data string) *common.Payload {
+	return &common.Payload{
+		Metadata: map[string][]byte{"encoding": []byte(encoding)},
+		Data:     []byte(data),
+	}
+}
+
+func TestDecodePayload_Encodings(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload *common.Payload
+		want    interface{}
+	}{
+		{"nil payload", nil, nil},
+		{"no metadata JSON", &common.Payload{Data: []byte(`{"a":1}`)}, map[string]interface{}{"a": float64(1)}},
+		{"no metadata text", &common.Payload{Data: []byte(`not json`)}, "not json"},
+		{"json/plain", payloadWithEncoding("json/plain", `["x",2]`), []interface{}{"x", float64(2)}},
+		{"json/protobuf", payloadWithEncoding("json/protobuf", `{"orderId":"o-1"}`), map[string]interface{}{"orderId": "o-1"}},
+		{"binary/null", payloadWithEncoding("binary/null", ``), nil},
+		{"binary/plain text", payloadWithEncoding("binary/plain", `hello`), "hello"},
+		{"binary/plain bytes", payloadWithEncoding("binary/plain", "\xff\x00"), map[string]interface{}{
+			"encoding": "binary/plain",
+			"data":     "/wA=",
+		}},
+		{"encrypted", &common.Payload{
+			Metadata: map[string][]byte{"encoding": []byte("binary/encrypted"), "messageType": []byte("orders.Order")},
+			Data:     []byte("secret"),
+		}, map[string]interface{}{
+			"encoding":     "binary/encrypted",
+			"data":         "c2VjcmV0",
+			"message_type": "orders.Order",
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, decodePayload(tt.payload))
+		})
+	}
+}
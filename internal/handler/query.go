@@ -0,0 +1,326 @@
+package handler
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gogo/protobuf/jsonpb"
+	"github.com/gogo/protobuf/types"
+	"go.temporal.io/api/enums/v1"
+	"go.temporal.io/api/serviceerror"
+	"go.temporal.io/sdk/client"
+	"go.temporal.io/sdk/converter"
+)
+
+// queryTimeout bounds how long a query waits for a worker to answer. Without
+// a worker the server holds the query until its own deadline.
+const queryTimeout = 15 * time.Second
+
+// metadataQueryType is the built-in query newer SDKs answer with the
+// workflow's query, signal and update definitions.
+const metadataQueryType = "__temporal_workflow_metadata"
+
+const (
+	QueryRejectNone                = "none"
+	QueryRejectNotOpen             = "not_open"
+	QueryRejectNotCompletedCleanly = "not_completed_cleanly"
+)
+
+var queryRejectConditions = map[string]enums.QueryRejectCondition{
+	QueryRejectNone:                enums.QUERY_REJECT_CONDITION_NONE,
+	QueryRejectNotOpen:             enums.QUERY_REJECT_CONDITION_NOT_OPEN,
+	QueryRejectNotCompletedCleanly: enums.QUERY_REJECT_CONDITION_NOT_COMPLETED_CLEANLY,
+}
+
+type QueryWorkflowArgs struct {
+	WorkflowID      string `json:"workflow_id" jsonschema:"required,description=The ID of the workflow to query"`
+	RunID           string `json:"run_id,omitempty" jsonschema:"description=Optional run ID of the workflow"`
+	QueryType       string `json:"query_type,omitempty" jsonschema:"description=The query to run. Leave empty to list the workflow's query, signal and update handlers"`
+	Args            string `json:"args,omitempty" jsonschema:"description=JSON array of arguments to pass to the query"`
+	RejectCondition string `json:"reject_condition,omitempty" jsonschema:"enum=none,enum=not_open,enum=not_completed_cleanly,description=Reject the query instead of running it when the workflow is not open or did not complete cleanly (default none)"`
+}
+
+type HandlerInfo struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+}
+
+// WorkflowHandlers lists the handlers a workflow defines. Source is
+// "metadata" when they came from the __temporal_workflow_metadata query, or
+// "known_query_types" when the worker does not support it and only the query
+// names it reported are known.
+type WorkflowHandlers struct {
+	Source  string        `json:"source"`
+	Queries []HandlerInfo `json:"queries"`
+	Signals []HandlerInfo `json:"signals,omitempty"`
+	Updates []HandlerInfo `json:"updates,omitempty"`
+}
+
+type QueryWorkflowResponse struct {
+	WorkflowID string            `json:"workflow_id"`
+	RunID      string            `json:"run_id"`
+	Status     string            `json:"status"`
+	TaskQueue  string            `json:"task_queue"`
+	QueryType  string            `json:"query_type,omitempty"`
+	Result     interface{}       `json:"result,omitempty"`
+	Rejected   bool              `json:"rejected,omitempty"`
+	Handlers   *WorkflowHandlers `json:"handlers,omitempty"`
+	Summary    string            `json:"summary"`
+}
+
+// QueryWorkflowHandler runs a query handler defined by the workflow, or lists
+// the handlers when no query type is given.
+func QueryWorkflowHandler(ctx context.Context, temporalClient client.Client, args QueryWorkflowArgs) (QueryWorkflowResponse, error) {
+	queryArgs, err := parseJSONArgs(args.Args)
+	if err != nil {
+		return QueryWorkflowResponse{}, err
+	}
+	rejectCondition := enums.QUERY_REJECT_CONDITION_NONE
+	if args.RejectCondition != "" {
+		var ok bool
+		if rejectCondition, ok = queryRejectConditions[args.RejectCondition]; !ok {
+			return QueryWorkflowResponse{}, fmt.Errorf("invalid reject_condition %q (expected %s, %s or %s)",
+				args.RejectCondition, QueryRejectNone, QueryRejectNotOpen, QueryRejectNotCompletedCleanly)
+		}
+	}
+
+	target, err := describeQueryTarget(ctx, temporalClient, args.WorkflowID, args.RunID)
+	if err != nil {
+		return QueryWorkflowResponse{}, err
+	}
+	result := QueryWorkflowResponse{
+		WorkflowID: args.WorkflowID,
+		RunID:      target.RunID,
+		Status:     target.Status,
+		TaskQueue:  target.TaskQueue,
+		QueryType:  args.QueryType,
+	}
+	if target.Pollers == 0 {
+		result.Summary = target.noPollerSummary()
+		return result, nil
+	}
+
+	if args.QueryType == "" {
+		return discoverHandlers(ctx, temporalClient, result, target)
+	}
+
+	queryCtx, cancel := context.WithTimeout(ctx, queryTimeout)
+	defer cancel()
+	resp, err := temporalClient.QueryWorkflowWithOptions(queryCtx, &client.QueryWorkflowWithOptionsRequest{
+		WorkflowID:           args.WorkflowID,
+		RunID:                target.RunID,
+		QueryType:            args.QueryType,
+		Args:                 queryArgs,
+		QueryRejectCondition: rejectCondition,
+	})
+	if err != nil {
+		if isQueryUnanswered(err) {
+			result.Summary = target.unansweredSummary()
+			return result, nil
+		}
+		if known := knownQueryTypes(err); known != nil {
+			return QueryWorkflowResponse{}, fmt.Errorf("failed to query workflow: %w (queries defined by the workflow: %s)", err, strings.Join(known, ", "))
+		}
+		return QueryWorkflowResponse{}, fmt.Errorf("failed to query workflow: %w", err)
+	}
+
+	if rejected := resp.QueryRejected; rejected != nil {
+		result.Rejected = true
+		result.Summary = fmt.Sprintf("Query %s was rejected because the workflow is %s.", args.QueryType, enumName(rejected.GetStatus()))
+		return result, nil
+	}
+	if result.Result, err = decodeQueryResult(resp.QueryResult); err != nil {
+		return QueryWorkflowResponse{}, fmt.Errorf("failed to decode query result: %w", err)
+	}
+	result.Summary = fmt.Sprintf("Query %s answered for workflow in status %s.", args.QueryType, result.Status)
+	return result, nil
+}
+
+// discoverHandlers lists the workflow's handlers with the metadata query,
+// falling back to the query names Go workers report when asked for a query
+// they do not know.
+func discoverHandlers(ctx context.Context, temporalClient client.Client, result QueryWorkflowResponse, target queryTarget) (QueryWorkflowResponse, error) {
+	queryCtx, cancel := context.WithTimeout(ctx, queryTimeout)
+	defer cancel()
+	value, err := temporalClient.QueryWorkflow(queryCtx, result.WorkflowID, result.RunID, metadataQueryType)
+	if err != nil {
+		if isQueryUnanswered(err) {
+			result.Summary = target.unansweredSummary()
+			return result, nil
+		}
+		known := knownQueryTypes(err)
+		if known == nil {
+			return QueryWorkflowResponse{}, fmt.Errorf("failed to list workflow handlers: %w", err)
+		}
+		result.Handlers = &WorkflowHandlers{Source: "known_query_types", Queries: []HandlerInfo{}}
+		for _, name := range known {
+			result.Handlers.Queries = append(result.Handlers.Queries, HandlerInfo{Name: name})
+		}
+		result.Summary = fmt.Sprintf("Workflow defines %d queries. The worker does not support %s, so signals, updates and descriptions are not available.",
+			len(result.Handlers.Queries), metadataQueryType)
+		return result, nil
+	}
+
+	metadata, err := decodeQueryResult(value)
+	if err != nil {
+		return QueryWorkflowResponse{}, fmt.Errorf("failed to decode workflow metadata: %w", err)
+	}
+	fields, _ := metadata.(map[string]interface{})
+	definition, _ := fields["definition"].(map[string]interface{})
+	result.Handlers = &WorkflowHandlers{
+		Source:  "metadata",
+		Queries: handlerDefinitions(definition, "queryDefinitions", "query_definitions"),
+		Signals: handlerDefinitions(definition, "signalDefinitions", "signal_definitions"),
+		Updates: handlerDefinitions(definition, "updateDefinitions", "update_definitions"),
+	}
+	if result.Handlers.Queries == nil {
+		result.Handlers.Queries = []HandlerInfo{}
+	}
+	result.Summary = fmt.Sprintf("Workflow defines %d queries, %d signals and %d updates.",
+		len(result.Handlers.Queries), len(result.Handlers.Signals), len(result.Handlers.Updates))
+	return result, nil
+}
+
+// handlerDefinitions reads a list of {name, description} definitions from
+// workflow metadata, which may use either JSON or proto field names.
+func handlerDefinitions(definition map[string]interface{}, keys ...string) []HandlerInfo {
+	var handlers []HandlerInfo
+	for _, key := range keys {
+		list, _ := definition[key].([]interface{})
+		for _, item := range list {
+			def, _ := item.(map[string]interface{})
+			name, _ := def["name"].(string)
+			if name == "" || strings.HasPrefix(name, "__") {
+				continue
+			}
+			description, _ := def["description"].(string)
+			handlers = append(handlers, HandlerInfo{Name: name, Description: description})
+		}
+	}
+	return handlers
+}
+
+var knownQueryTypesPattern = regexp.MustCompile(`KnownQueryTypes=\[([^\]]*)\]`)
+
+// knownQueryTypes extracts the query names a Go worker lists when it is asked
+// for a query it does not define, leaving out the built-in ones. It returns
+// nil when the error carries no such list.
+func knownQueryTypes(err error) []string {
+	m := knownQueryTypesPattern.FindStringSubmatch(err.Error())
+	if m == nil {
+		return nil
+	}
+	known := []string{}
+	for _, name := range strings.Fields(m[1]) {
+		if !strings.HasPrefix(name, "__") {
+			known = append(known, name)
+		}
+	}
+	return known
+}
+
+// parseJSONArgs parses a JSON array of arguments for a query or signal.
+func parseJSONArgs(raw string) ([]interface{}, error) {
+	if strings.TrimSpace(raw) == "" {
+		return nil, nil
+	}
+	var args []interface{}
+	if err := json.Unmarshal([]byte(raw), &args); err != nil {
+		return nil, fmt.Errorf("invalid args, expected a JSON array: %w", err)
+	}
+	return args, nil
+}
+
+// decodeQueryResult decodes a query result without knowing its type. JSON
+// results decode directly, binary results become a string when they are
+// valid UTF-8, and protobuf results are decoded through a generic Struct.
+func decodeQueryResult(value converter.EncodedValue) (interface{}, error) {
+	if value == nil || !value.HasValue() {
+		return nil, nil
+	}
+
+	var result interface{}
+	err := value.Get(&result)
+	if err == nil {
+		if data, ok := result.([]byte); ok {
+			if utf8.Valid(data) {
+				return string(data), nil
+			}
+			return base64.StdEncoding.EncodeToString(data), nil
+		}
+		return result, nil
+	}
+
+	var message types.Struct
+	if value.Get(&message) != nil {
+		return nil, err
+	}
+	data, err := (&jsonpb.Marshaler{}).MarshalToString(&message)
+	if err != nil {
+		return nil, err
+	}
+	var decoded map[string]interface{}
+	if err := json.Unmarshal([]byte(data), &decoded); err != nil {
+		return nil, err
+	}
+	return decoded, nil
+}
+
+// queryTarget is the workflow a query is sent to and the number of workers
+// recently polling its task queue, without which the query cannot be
+// answered. Pollers the server still lists but has not seen lately are
+// workers that have gone away.
+type queryTarget struct {
+	RunID     string
+	Status    string
+	TaskQueue string
+	Pollers   int
+}
+
+func describeQueryTarget(ctx context.Context, temporalClient client.Client, workflowID, runID string) (queryTarget, error) {
+	resp, err := temporalClient.DescribeWorkflowExecution(ctx, workflowID, runID)
+	if err != nil {
+		return queryTarget{}, fmt.Errorf("failed to describe workflow: %w", err)
+	}
+	info := resp.GetWorkflowExecutionInfo()
+	target := queryTarget{
+		RunID:     info.GetExecution().GetRunId(),
+		Status:    info.GetStatus().String(),
+		TaskQueue: info.GetTaskQueue(),
+	}
+
+	queue, err := temporalClient.DescribeTaskQueue(ctx, target.TaskQueue, enums.TASK_QUEUE_TYPE_WORKFLOW)
+	if err != nil {
+		return queryTarget{}, fmt.Errorf("failed to describe task queue %s: %w", target.TaskQueue, err)
+	}
+	now := time.Now()
+	for _, p := range queue.GetPollers() {
+		if recentPoller(p, now) {
+			target.Pollers++
+		}
+	}
+	return target, nil
+}
+
+func (t queryTarget) noPollerSummary() string {
+	return fmt.Sprintf("No worker is polling task queue %s, so queries cannot be answered. Start a worker for %s and try again.", t.TaskQueue, t.TaskQueue)
+}
+
+func (t queryTarget) unansweredSummary() string {
+	return fmt.Sprintf("No worker answered the query within %s although %d pollers were seen on task queue %s; the workers may be overloaded or unable to replay the workflow.",
+		queryTimeout, t.Pollers, t.TaskQueue)
+}
+
+// isQueryUnanswered reports whether a query failed because no worker picked
+// it up in time, as opposed to the worker rejecting it.
+func isQueryUnanswered(err error) bool {
+	var deadline *serviceerror.DeadlineExceeded
+	return errors.Is(err, context.DeadlineExceeded) || errors.As(err, &deadline)
+}
@@ -0,0 +1,143 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.temporal.io/api/common/v1"
+	"go.temporal.io/api/enums/v1"
+	"go.temporal.io/api/query/v1"
+	"go.temporal.io/sdk/client"
+	"go.temporal.io/sdk/converter"
+)
+
+// payloadValue is an EncodedValue backed by real payloads and the default
+// data converter.
+type payloadValue struct {
+	payloads *common.Payloads
+}
+
+func (v payloadValue) HasValue() bool { return v.payloads != nil }
+
+func (v payloadValue) Get(valuePtr interface{}) error {
+	return converter.GetDefaultDataConverter().FromPayloads(v.payloads, valuePtr)
+}
+
+func payloadOf(encoding, data string) payloadValue {
+	return payloadValue{payloads: &common.Payloads{Payloads: []*common.Payload{{
+		Metadata: map[string][]byte{converter.MetadataEncoding: []byte(encoding)},
+		Data:     []byte(data),
+	}}}}
+}
+
+func TestQueryWorkflowHandler(t *testing.T) {
+	t.Run("Query with arguments", func(t *testing.T) {
+		mockClient := stackTraceClient(1)
+		mockClient.On("QueryWorkflowWithOptions", mock.Anything, &client.QueryWorkflowWithOptionsRequest{
+			WorkflowID:           "wf-1",
+			RunID:                "run-1",
+			QueryType:            "order_state",
+			Args:                 []interface{}{"line-1", float64(2)},
+			QueryRejectCondition: enums.QUERY_REJECT_CONDITION_NOT_OPEN,
+		}).Return(&client.QueryWorkflowWithOptionsResponse{
+			QueryResult: payloadOf(converter.MetadataEncodingJSON, `{"state":"shipping","items":2}`),
+		}, nil)
+
+		result, err := QueryWorkflowHandler(context.Background(), mockClient, QueryWorkflowArgs{
+			WorkflowID:      "wf-1",
+			QueryType:       "order_state",
+			Args:            `["line-1", 2]`,
+			RejectCondition: QueryRejectNotOpen,
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, map[string]interface{}{"state": "shipping", "items": float64(2)}, result.Result)
+		assert.Contains(t, result.Summary, "Query order_state answered")
+	})
+
+	t.Run("Query rejected", func(t *testing.T) {
+		mockClient := stackTraceClient(1)
+		mockClient.On("QueryWorkflowWithOptions", mock.Anything, mock.Anything).Return(&client.QueryWorkflowWithOptionsResponse{
+			QueryRejected: &query.QueryRejected{Status: enums.WORKFLOW_EXECUTION_STATUS_TERMINATED},
+		}, nil)
+
+		result, err := QueryWorkflowHandler(context.Background(), mockClient, QueryWorkflowArgs{WorkflowID: "wf-1", QueryType: "order_state", RejectCondition: QueryRejectNotCompletedCleanly})
+
+		require.NoError(t, err)
+		assert.True(t, result.Rejected)
+		assert.Contains(t, result.Summary, "rejected because the workflow is Terminated")
+	})
+
+	t.Run("Unknown query lists the known ones", func(t *testing.T) {
+		mockClient := stackTraceClient(1)
+		mockClient.On("QueryWorkflowWithOptions", mock.Anything, mock.Anything).
+			Return(nil, errors.New("unknown queryType missing. KnownQueryTypes=[__stack_trace __open_sessions order_state]"))
+
+		_, err := QueryWorkflowHandler(context.Background(), mockClient, QueryWorkflowArgs{WorkflowID: "wf-1", QueryType: "missing"})
+
+		assert.ErrorContains(t, err, "queries defined by the workflow: order_state")
+	})
+
+	t.Run("Invalid arguments", func(t *testing.T) {
+		_, err := QueryWorkflowHandler(context.Background(), stackTraceClient(1), QueryWorkflowArgs{WorkflowID: "wf-1", QueryType: "q", Args: `{"a":1}`})
+		assert.ErrorContains(t, err, "expected a JSON array")
+
+		_, err = QueryWorkflowHandler(context.Background(), stackTraceClient(1), QueryWorkflowArgs{WorkflowID: "wf-1", QueryType: "q", RejectCondition: "sometimes"})
+		assert.ErrorContains(t, err, "invalid reject_condition")
+	})
+
+	t.Run("No worker polling", func(t *testing.T) {
+		result, err := QueryWorkflowHandler(context.Background(), stackTraceClient(0), QueryWorkflowArgs{WorkflowID: "wf-1", QueryType: "order_state"})
+
+		require.NoError(t, err)
+		assert.Contains(t, result.Summary, "No worker is polling task queue orders")
+	})
+}
+
+func TestQueryWorkflowHandler_Discovery(t *testing.T) {
+	t.Run("Workflow metadata", func(t *testing.T) {
+		mockClient := stackTraceClient(1)
+		metadata := `{"definition":{"type":"OrderWorkflow",
+			"queryDefinitions":[{"name":"__stack_trace"},{"name":"order_state","description":"Current order state"}],
+			"signalDefinitions":[{"name":"cancel_order"}],
+			"updateDefinitions":[{"name":"add_item","description":"Adds a line item"}]}}`
+		mockClient.On("QueryWorkflow", mock.Anything, "wf-1", "run-1", metadataQueryType).
+			Return(payloadOf(converter.MetadataEncodingProtoJSON, metadata), nil)
+
+		result, err := QueryWorkflowHandler(context.Background(), mockClient, QueryWorkflowArgs{WorkflowID: "wf-1"})
+
+		require.NoError(t, err)
+		require.NotNil(t, result.Handlers)
+		assert.Equal(t, "metadata", result.Handlers.Source)
+		assert.Equal(t, []HandlerInfo{{Name: "order_state", Description: "Current order state"}}, result.Handlers.Queries)
+		assert.Equal(t, []HandlerInfo{{Name: "cancel_order"}}, result.Handlers.Signals)
+		assert.Equal(t, []HandlerInfo{{Name: "add_item", Description: "Adds a line item"}}, result.Handlers.Updates)
+		assert.Equal(t, "Workflow defines 1 queries, 1 signals and 1 updates.", result.Summary)
+	})
+
+	t.Run("Falls back to known query types", func(t *testing.T) {
+		mockClient := stackTraceClient(1)
+		mockClient.On("QueryWorkflow", mock.Anything, "wf-1", "run-1", metadataQueryType).
+			Return(nil, errors.New("unknown queryType __temporal_workflow_metadata. KnownQueryTypes=[__stack_trace __open_sessions order_state items]"))
+
+		result, err := QueryWorkflowHandler(context.Background(), mockClient, QueryWorkflowArgs{WorkflowID: "wf-1"})
+
+		require.NoError(t, err)
+		assert.Equal(t, "known_query_types", result.Handlers.Source)
+		assert.Equal(t, []HandlerInfo{{Name: "order_state"}, {Name: "items"}}, result.Handlers.Queries)
+	})
+}
+
+func TestDecodeQueryResult(t *testing.T) {
+	result, err := decodeQueryResult(payloadOf(converter.MetadataEncodingBinary, "raw text"))
+	require.NoError(t, err)
+	assert.Equal(t, "raw text", result)
+
+	result, err = decodeQueryResult(payloadValue{})
+	require.NoError(t, err)
+	assert.Nil(t, result)
+}
@@ -0,0 +1,337 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"go.temporal.io/api/common/v1"
+	"go.temporal.io/api/enums/v1"
+	"go.temporal.io/api/history/v1"
+	"go.temporal.io/api/workflowservice/v1"
+	"go.temporal.io/sdk/client"
+)
+
+const (
+	ResetTypeFirstWorkflowTask = "first_workflow_task"
+	ResetTypeLastWorkflowTask  = "last_workflow_task"
+	ResetTypeBuildID           = "build_id"
+	ResetTypeEventID           = "event_id"
+)
+
+// maxResetPlanEvents caps how many discarded events a dry run describes one
+// by one; the rest are only counted by type.
+const maxResetPlanEvents = 50
+
+type ResetWorkflowArgs struct {
+	WorkflowID        string `json:"workflow_id" jsonschema:"required,description=The ID of the workflow to reset"`
+	RunID             string `json:"run_id,omitempty" jsonschema:"description=Optional run ID of the workflow"`
+	ResetType         string `json:"reset_type,omitempty" jsonschema:"enum=first_workflow_task,enum=last_workflow_task,enum=build_id,enum=event_id,description=Where to reset to. Leave empty to list the reset points"`
+	EventID           int64  `json:"event_id,omitempty" jsonschema:"description=With reset_type event_id, reset to before this event"`
+	BuildID           string `json:"build_id,omitempty" jsonschema:"description=With reset_type build_id, reset to before this build first completed a workflow task"`
+	SkipSignalReapply bool   `json:"skip_signal_reapply,omitempty" jsonschema:"description=Drop signals received after the reset point instead of reapplying them to the new run"`
+	DryRun            bool   `json:"dry_run,omitempty" jsonschema:"description=Explain what the reset would discard without resetting"`
+	Reason            string `json:"reason,omitempty" jsonschema:"description=Why the workflow is being reset, recorded in the workflow history. Required unless listing reset points or doing a dry run"`
+	Namespace         string `json:"-"`
+}
+
+// ResetPoint is a workflow task completion the workflow can be reset to.
+// Resetting to it discards the events from EventID onwards and runs the
+// workflow task again.
+type ResetPoint struct {
+	Type        string `json:"type"`
+	EventID     int64  `json:"event_id"`
+	BuildID     string `json:"build_id,omitempty"`
+	Time        string `json:"time,omitempty"`
+	Discards    int    `json:"discards_events"`
+	Description string `json:"description"`
+}
+
+// ResetPlan explains what resetting to EventID would do.
+type ResetPlan struct {
+	EventID          int64          `json:"event_id"`
+	DiscardedEvents  int            `json:"discarded_events"`
+	DiscardedByType  map[string]int `json:"discarded_by_type,omitempty"`
+	Discarded        []string       `json:"discarded,omitempty"`
+	ReappliedSignals []string       `json:"reapplied_signals,omitempty"`
+	DroppedSignals   []string       `json:"dropped_signals,omitempty"`
+}
+
+type ResetWorkflowResponse struct {
+	WorkflowID  string               `json:"workflow_id"`
+	RunID       string               `json:"run_id"`
+	ResetPoints []ResetPoint         `json:"reset_points,omitempty"`
+	Plan        *ResetPlan           `json:"plan,omitempty"`
+	NewRunID    string               `json:"new_run_id,omitempty"`
+	Identity    string               `json:"identity,omitempty"`
+	State       *WorkflowDescription `json:"state,omitempty"`
+	Summary     string               `json:"summary"`
+}
+
+// ResetWorkflowHandler lists the points a workflow can be reset to, explains
+// a reset in a dry run, or resets the workflow.
+func ResetWorkflowHandler(ctx context.Context, temporalClient client.Client, args ResetWorkflowArgs) (ResetWorkflowResponse, error) {
+	var identity string
+	if args.ResetType != "" && !args.DryRun {
+		var err error
+		if identity, err = mutationIdentity(args.Reason); err != nil {
+			return ResetWorkflowResponse{}, err
+		}
+	}
+
+	resp, err := temporalClient.DescribeWorkflowExecution(ctx, args.WorkflowID, args.RunID)
+	if err != nil {
+		return ResetWorkflowResponse{}, fmt.Errorf("failed to describe workflow: %w", err)
+	}
+	runID := resp.GetWorkflowExecutionInfo().GetExecution().GetRunId()
+
+	events, err := readHistory(ctx, temporalClient, args.WorkflowID, runID)
+	if err != nil {
+		return ResetWorkflowResponse{}, err
+	}
+
+	result := ResetWorkflowResponse{WorkflowID: args.WorkflowID, RunID: runID}
+	points := resetPoints(events)
+	if len(points) == 0 {
+		return ResetWorkflowResponse{}, fmt.Errorf("workflow has no completed workflow task to reset to")
+	}
+
+	if args.ResetType == "" {
+		result.ResetPoints = points
+		result.Summary = fmt.Sprintf("Workflow can be reset to any of %d completed workflow tasks. Listed are the first and last ones and the first completed by each build; use reset_type event_id to reset to before any other event.", countWorkflowTasks(events))
+		return result, nil
+	}
+
+	eventID, err := resolveResetPoint(events, points, args)
+	if err != nil {
+		return ResetWorkflowResponse{}, err
+	}
+	plan := planReset(events, eventID, !args.SkipSignalReapply)
+	result.Plan = &plan
+
+	if args.DryRun {
+		result.Summary = fmt.Sprintf("Dry run: resetting to event %d would discard %d events and run the workflow task again from there.", eventID, plan.DiscardedEvents)
+		if n := len(plan.ReappliedSignals); n > 0 {
+			result.Summary += fmt.Sprintf(" %d signals would be reapplied.", n)
+		}
+		if n := len(plan.DroppedSignals); n > 0 {
+			result.Summary += fmt.Sprintf(" %d signals would be dropped.", n)
+		}
+		return result, nil
+	}
+
+	reset, err := temporalClient.ResetWorkflowExecution(ctx, resetRequest(args.Namespace, args.WorkflowID, runID, eventID, identity, args.SkipSignalReapply))
+	if err != nil {
+		return ResetWorkflowResponse{}, fmt.Errorf("failed to reset workflow: %w", err)
+	}
+	result.NewRunID = reset.GetRunId()
+	result.Identity = identity
+
+	after := mutationResult(ctx, temporalClient, args.WorkflowID, result.NewRunID, "reset", identity)
+	result.State = after.State
+	result.Summary = fmt.Sprintf("Reset to event %d, discarding %d events; the new run is %s. %s", eventID, plan.DiscardedEvents, result.NewRunID, after.Summary)
+	return result, nil
+}
+
+// readHistory reads every event of a run.
+func readHistory(ctx context.Context, temporalClient client.Client, workflowID, runID string) ([]*history.HistoryEvent, error) {
+	var events []*history.HistoryEvent
+	iter := temporalClient.GetWorkflowHistory(ctx, workflowID, runID, false, enums.HISTORY_EVENT_FILTER_TYPE_ALL_EVENT)
+	for iter.HasNext() {
+		evt, err := iter.Next()
+		if err != nil {
+			return nil, fmt.Errorf("failed reading history: %w", err)
+		}
+		events = append(events, evt)
+	}
+	return events, nil
+}
+
+// resetRequest resets a run to before eventID, reapplying later signals
+// unless told to skip them. The request ID lets the server recognise a retry
+// of the same reset rather than resetting the run again.
+func resetRequest(namespace, workflowID, runID string, eventID int64, identity string, skipSignalReapply bool) *workflowservice.ResetWorkflowExecutionRequest {
+	reapply := enums.RESET_REAPPLY_TYPE_SIGNAL
+	if skipSignalReapply {
+		reapply = enums.RESET_REAPPLY_TYPE_NONE
+	}
+	return &workflowservice.ResetWorkflowExecutionRequest{
+		Namespace:                 namespace,
+		WorkflowExecution:         &common.WorkflowExecution{WorkflowId: workflowID, RunId: runID},
+		Reason:                    identity,
+		WorkflowTaskFinishEventId: eventID,
+		ResetReapplyType:          reapply,
+		RequestId:                 uuid.NewString(),
+	}
+}
+
+// resetPoints returns the first and last completed workflow tasks and the
+// first one completed by each build.
+func resetPoints(events []*history.HistoryEvent) []ResetPoint {
+	total := len(events)
+	var first, last *ResetPoint
+	var builds []ResetPoint
+	seen := make(map[string]bool)
+
+	for i, evt := range events {
+		attrs := evt.GetWorkflowTaskCompletedEventAttributes()
+		if attrs == nil {
+			continue
+		}
+		point := ResetPoint{
+			EventID:  evt.GetEventId(),
+			BuildID:  workflowTaskBuildID(attrs),
+			Time:     formatTime(evt.GetEventTime()),
+			Discards: total - i,
+		}
+		if first == nil {
+			p := point
+			p.Type = ResetTypeFirstWorkflowTask
+			p.Description = "Rerun the workflow from its first workflow task, keeping only its start and input."
+			first = &p
+		}
+		p := point
+		p.Type = ResetTypeLastWorkflowTask
+		p.Description = "Rerun the most recent workflow task."
+		last = &p
+
+		if point.BuildID != "" && !seen[point.BuildID] {
+			seen[point.BuildID] = true
+			point.Type = ResetTypeBuildID
+			point.Description = fmt.Sprintf("Discard everything build %s did to the workflow.", point.BuildID)
+			builds = append(builds, point)
+		}
+	}
+
+	if first == nil {
+		return nil
+	}
+	points := []ResetPoint{*first}
+	if last.EventID != first.EventID {
+		points = append(points, *last)
+	}
+	// A single build adds nothing over the first workflow task.
+	if len(builds) > 1 {
+		points = append(points, builds...)
+	}
+	return points
+}
+
+func workflowTaskBuildID(attrs *history.WorkflowTaskCompletedEventAttributes) string {
+	if id := attrs.GetWorkerVersioningId().GetWorkerBuildId(); id != "" {
+		return id
+	}
+	return attrs.GetBinaryChecksum()
+}
+
+func countWorkflowTasks(events []*history.HistoryEvent) int {
+	n := 0
+	for _, evt := range events {
+		if evt.GetWorkflowTaskCompletedEventAttributes() != nil {
+			n++
+		}
+	}
+	return n
+}
+
+// resolveResetPoint returns the workflow task completed event to reset to.
+// For an event ID that is the last workflow task completed at or before it,
+// which rolls back the decision that led to the event.
+func resolveResetPoint(events []*history.HistoryEvent, points []ResetPoint, args ResetWorkflowArgs) (int64, error) {
+	switch args.ResetType {
+	case ResetTypeFirstWorkflowTask:
+		return points[0].EventID, nil
+	case ResetTypeLastWorkflowTask:
+		for i := len(events) - 1; i >= 0; i-- {
+			if events[i].GetWorkflowTaskCompletedEventAttributes() != nil {
+				return events[i].GetEventId(), nil
+			}
+		}
+	case ResetTypeBuildID:
+		if args.BuildID == "" {
+			return 0, fmt.Errorf("build_id is required with reset_type %s", ResetTypeBuildID)
+		}
+		for _, evt := range events {
+			if attrs := evt.GetWorkflowTaskCompletedEventAttributes(); attrs != nil && workflowTaskBuildID(attrs) == args.BuildID {
+				return evt.GetEventId(), nil
+			}
+		}
+		return 0, fmt.Errorf("build %s did not complete any workflow task of this run", args.BuildID)
+	case ResetTypeEventID:
+		if args.EventID <= 0 {
+			return 0, fmt.Errorf("event_id is required with reset_type %s", ResetTypeEventID)
+		}
+		var target int64
+		for _, evt := range events {
+			if evt.GetEventId() > args.EventID {
+				break
+			}
+			if evt.GetWorkflowTaskCompletedEventAttributes() != nil {
+				target = evt.GetEventId()
+			}
+		}
+		if target == 0 {
+			return 0, fmt.Errorf("no workflow task completed at or before event %d", args.EventID)
+		}
+		return target, nil
+	}
+	return 0, fmt.Errorf("invalid reset_type %q (expected %s, %s, %s or %s)", args.ResetType,
+		ResetTypeFirstWorkflowTask, ResetTypeLastWorkflowTask, ResetTypeBuildID, ResetTypeEventID)
+}
+
+// planReset describes the events discarded by resetting to eventID.
+func planReset(events []*history.HistoryEvent, eventID int64, reapplySignals bool) ResetPlan {
+	plan := ResetPlan{EventID: eventID, DiscardedByType: make(map[string]int)}
+	activities := make(map[int64]string)
+
+	for _, evt := range events {
+		if attrs := evt.GetActivityTaskScheduledEventAttributes(); attrs != nil {
+			activities[evt.GetEventId()] = attrs.GetActivityType().GetName()
+		}
+		if evt.GetEventId() < eventID {
+			continue
+		}
+		plan.DiscardedEvents++
+		plan.DiscardedByType[evt.GetEventType().String()]++
+
+		if attrs := evt.GetWorkflowExecutionSignaledEventAttributes(); attrs != nil {
+			signal := fmt.Sprintf("%s (event %d)", attrs.GetSignalName(), evt.GetEventId())
+			if reapplySignals {
+				plan.ReappliedSignals = append(plan.ReappliedSignals, signal)
+			} else {
+				plan.DroppedSignals = append(plan.DroppedSignals, signal)
+			}
+			continue
+		}
+		if note := discardNote(evt, activities); note != "" && len(plan.Discarded) < maxResetPlanEvents {
+			plan.Discarded = append(plan.Discarded, fmt.Sprintf("event %d: %s", evt.GetEventId(), note))
+		}
+	}
+	return plan
+}
+
+// discardNote describes a discarded event worth calling out, or returns an
+// empty string for bookkeeping events.
+func discardNote(evt *history.HistoryEvent, activities map[int64]string) string {
+	switch {
+	case evt.GetActivityTaskScheduledEventAttributes() != nil:
+		return fmt.Sprintf("activity %s scheduled; it runs again after the reset", evt.GetActivityTaskScheduledEventAttributes().GetActivityType().GetName())
+	case evt.GetActivityTaskCompletedEventAttributes() != nil:
+		return fmt.Sprintf("result of activity %s", activities[evt.GetActivityTaskCompletedEventAttributes().GetScheduledEventId()])
+	case evt.GetActivityTaskFailedEventAttributes() != nil:
+		return fmt.Sprintf("failure of activity %s", activities[evt.GetActivityTaskFailedEventAttributes().GetScheduledEventId()])
+	case evt.GetActivityTaskTimedOutEventAttributes() != nil:
+		return fmt.Sprintf("timeout of activity %s", activities[evt.GetActivityTaskTimedOutEventAttributes().GetScheduledEventId()])
+	case evt.GetStartChildWorkflowExecutionInitiatedEventAttributes() != nil:
+		return fmt.Sprintf("start of child workflow %s; a child already started keeps running", evt.GetStartChildWorkflowExecutionInitiatedEventAttributes().GetWorkflowId())
+	case evt.GetTimerStartedEventAttributes() != nil:
+		return fmt.Sprintf("timer %s", evt.GetTimerStartedEventAttributes().GetTimerId())
+	case evt.GetMarkerRecordedEventAttributes() != nil:
+		return fmt.Sprintf("marker %s", evt.GetMarkerRecordedEventAttributes().GetMarkerName())
+	}
+	if _, closed := nextRunID(evt); closed {
+		return "workflow close as " + strings.TrimPrefix(evt.GetEventType().String(), "WorkflowExecution")
+	}
+	return ""
+}
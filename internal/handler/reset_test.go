@@ -0,0 +1,137 @@
+package handler
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.temporal.io/api/common/v1"
+	"go.temporal.io/api/enums/v1"
+	"go.temporal.io/api/failure/v1"
+	"go.temporal.io/api/history/v1"
+	"go.temporal.io/api/taskqueue/v1"
+)
+
+func workflowTaskEvents(scheduled int64, build string) []*history.HistoryEvent {
+	return []*history.HistoryEvent{
+		{EventId: scheduled, EventType: enums.EVENT_TYPE_WORKFLOW_TASK_SCHEDULED, Attributes: &history.HistoryEvent_WorkflowTaskScheduledEventAttributes{WorkflowTaskScheduledEventAttributes: &history.WorkflowTaskScheduledEventAttributes{}}},
+		{EventId: scheduled + 1, EventType: enums.EVENT_TYPE_WORKFLOW_TASK_STARTED, Attributes: &history.HistoryEvent_WorkflowTaskStartedEventAttributes{WorkflowTaskStartedEventAttributes: &history.WorkflowTaskStartedEventAttributes{}}},
+		{EventId: scheduled + 2, EventType: enums.EVENT_TYPE_WORKFLOW_TASK_COMPLETED, Attributes: &history.HistoryEvent_WorkflowTaskCompletedEventAttributes{WorkflowTaskCompletedEventAttributes: &history.WorkflowTaskCompletedEventAttributes{
+			WorkerVersioningId: &taskqueue.VersionId{WorkerBuildId: build},
+		}}},
+	}
+}
+
+// resetTestEvents is a run whose first workflow task ran on build v1 and
+// scheduled an activity, after which build v2 took over and failed it.
+func resetTestEvents() []*history.HistoryEvent {
+	events := []*history.HistoryEvent{
+		{EventId: 1, EventType: enums.EVENT_TYPE_WORKFLOW_EXECUTION_STARTED, Attributes: &history.HistoryEvent_WorkflowExecutionStartedEventAttributes{WorkflowExecutionStartedEventAttributes: &history.WorkflowExecutionStartedEventAttributes{}}},
+	}
+	events = append(events, workflowTaskEvents(2, "v1")...)
+	events = append(events,
+		&history.HistoryEvent{EventId: 5, EventType: enums.EVENT_TYPE_ACTIVITY_TASK_SCHEDULED, Attributes: &history.HistoryEvent_ActivityTaskScheduledEventAttributes{ActivityTaskScheduledEventAttributes: &history.ActivityTaskScheduledEventAttributes{
+			ActivityType: &common.ActivityType{Name: "ChargeCard"},
+		}}},
+		&history.HistoryEvent{EventId: 6, EventType: enums.EVENT_TYPE_ACTIVITY_TASK_STARTED, Attributes: &history.HistoryEvent_ActivityTaskStartedEventAttributes{ActivityTaskStartedEventAttributes: &history.ActivityTaskStartedEventAttributes{ScheduledEventId: 5}}},
+		&history.HistoryEvent{EventId: 7, EventType: enums.EVENT_TYPE_ACTIVITY_TASK_COMPLETED, Attributes: &history.HistoryEvent_ActivityTaskCompletedEventAttributes{ActivityTaskCompletedEventAttributes: &history.ActivityTaskCompletedEventAttributes{ScheduledEventId: 5}}},
+	)
+	events = append(events, workflowTaskEvents(8, "v2")...)
+	events = append(events, &history.HistoryEvent{EventId: 11, EventType: enums.EVENT_TYPE_WORKFLOW_EXECUTION_SIGNALED, Attributes: &history.HistoryEvent_WorkflowExecutionSignaledEventAttributes{WorkflowExecutionSignaledEventAttributes: &history.WorkflowExecutionSignaledEventAttributes{
+		SignalName: "approve",
+	}}})
+	events = append(events, workflowTaskEvents(12, "v2")...)
+	return append(events, &history.HistoryEvent{EventId: 15, EventType: enums.EVENT_TYPE_WORKFLOW_EXECUTION_FAILED, Attributes: &history.HistoryEvent_WorkflowExecutionFailedEventAttributes{WorkflowExecutionFailedEventAttributes: &history.WorkflowExecutionFailedEventAttributes{
+		Failure: &failure.Failure{Message: "boom"},
+	}}})
+}
+
+func TestResetWorkflowHandler_ListPoints(t *testing.T) {
+	result, err := ResetWorkflowHandler(context.Background(), newFakeClient(t, &fakeRun{workflowID: "wf-1", runID: "run-1", events: resetTestEvents()}), ResetWorkflowArgs{WorkflowID: "wf-1"})
+
+	require.NoError(t, err)
+	require.Len(t, result.ResetPoints, 4)
+	assert.Equal(t, ResetPoint{Type: ResetTypeFirstWorkflowTask, EventID: 4, BuildID: "v1", Discards: 12,
+		Description: "Rerun the workflow from its first workflow task, keeping only its start and input."}, result.ResetPoints[0])
+	assert.Equal(t, ResetTypeLastWorkflowTask, result.ResetPoints[1].Type)
+	assert.Equal(t, int64(14), result.ResetPoints[1].EventID)
+	assert.Equal(t, 2, result.ResetPoints[1].Discards)
+	assert.Equal(t, []string{"v1", "v2"}, []string{result.ResetPoints[2].BuildID, result.ResetPoints[3].BuildID})
+	assert.Equal(t, int64(10), result.ResetPoints[3].EventID)
+	assert.Nil(t, result.Plan)
+	assert.Contains(t, result.Summary, "any of 3 completed workflow tasks")
+}
+
+func TestResetWorkflowHandler_DryRun(t *testing.T) {
+	c := newFakeClient(t, &fakeRun{workflowID: "wf-1", runID: "run-1", events: resetTestEvents()})
+
+	result, err := ResetWorkflowHandler(context.Background(), c, ResetWorkflowArgs{WorkflowID: "wf-1", ResetType: ResetTypeEventID, EventID: 7, DryRun: true})
+
+	require.NoError(t, err)
+	assert.Empty(t, c.resets)
+	require.NotNil(t, result.Plan)
+	assert.Equal(t, int64(4), result.Plan.EventID, "resolves to the workflow task completed before the event")
+	assert.Equal(t, 12, result.Plan.DiscardedEvents)
+	assert.Equal(t, 1, result.Plan.DiscardedByType["ActivityTaskScheduled"])
+	assert.Equal(t, []string{
+		"event 5: activity ChargeCard scheduled; it runs again after the reset",
+		"event 7: result of activity ChargeCard",
+		"event 15: workflow close as Failed",
+	}, result.Plan.Discarded)
+	assert.Equal(t, []string{"approve (event 11)"}, result.Plan.ReappliedSignals)
+	assert.Equal(t, "Dry run: resetting to event 4 would discard 12 events and run the workflow task again from there. 1 signals would be reapplied.", result.Summary)
+}
+
+func TestResetWorkflowHandler_Reset(t *testing.T) {
+	c := newFakeClient(t, &fakeRun{workflowID: "wf-1", runID: "run-1", events: resetTestEvents()})
+
+	result, err := ResetWorkflowHandler(context.Background(), c, ResetWorkflowArgs{
+		WorkflowID:        "wf-1",
+		ResetType:         ResetTypeBuildID,
+		BuildID:           "v2",
+		SkipSignalReapply: true,
+		Reason:            "roll back bad deploy",
+		Namespace:         "orders",
+	})
+
+	require.NoError(t, err)
+	require.Len(t, c.resets, 1)
+	reset := c.resets[0]
+	assert.Equal(t, "orders", reset.GetNamespace())
+	assert.Equal(t, "run-1", reset.GetWorkflowExecution().GetRunId())
+	assert.Equal(t, int64(10), reset.GetWorkflowTaskFinishEventId())
+	assert.Equal(t, enums.RESET_REAPPLY_TYPE_NONE, reset.GetResetReapplyType())
+	assert.Equal(t, "mcp-temporal-server: roll back bad deploy", reset.GetReason())
+	assert.NotEmpty(t, reset.GetRequestId())
+
+	assert.Equal(t, "run-2", result.NewRunID)
+	assert.Equal(t, []string{"approve (event 11)"}, result.Plan.DroppedSignals)
+	require.NotNil(t, result.State)
+	assert.Equal(t, "run-2", result.State.RunID)
+	assert.Contains(t, result.Summary, "Reset to event 10, discarding 6 events; the new run is run-2.")
+}
+
+func TestResetWorkflowHandler_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		args ResetWorkflowArgs
+		want string
+	}{
+		{"reason required", ResetWorkflowArgs{ResetType: ResetTypeLastWorkflowTask}, "a reason is required"},
+		{"unknown type", ResetWorkflowArgs{ResetType: "latest", Reason: "r"}, "invalid reset_type"},
+		{"unknown build", ResetWorkflowArgs{ResetType: ResetTypeBuildID, BuildID: "v9", Reason: "r"}, "build v9 did not complete"},
+		{"event before first task", ResetWorkflowArgs{ResetType: ResetTypeEventID, EventID: 2, Reason: "r"}, "no workflow task completed at or before event 2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newFakeClient(t, &fakeRun{workflowID: "wf-1", runID: "run-1", events: resetTestEvents()})
+			tt.args.WorkflowID = "wf-1"
+
+			_, err := ResetWorkflowHandler(context.Background(), c, tt.args)
+
+			assert.ErrorContains(t, err, tt.want)
+			assert.Empty(t, c.resets)
+		})
+	}
+}
@@ -0,0 +1,195 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+
+	"go.temporal.io/api/enums/v1"
+	"go.temporal.io/api/history/v1"
+	"go.temporal.io/sdk/client"
+)
+
+const defaultMaxRuns = 10
+
+// RunSummary describes one run of a continue-as-new (or retry or cron) chain.
+type RunSummary struct {
+	RunID              string `json:"run_id"`
+	Status             string `json:"status"`
+	StartTime          string `json:"start_time,omitempty"`
+	CloseTime          string `json:"close_time,omitempty"`
+	EventCount         int64  `json:"event_count"`
+	ContinuedFromRunID string `json:"continued_from_run_id,omitempty"`
+	ContinuedToRunID   string `json:"continued_to_run_id,omitempty"`
+}
+
+// scanRun summarizes a run and finds the runs linked before and after it
+// without reading its whole history: the description gives the status, size
+// and times, the first event the previous run and the close event the next.
+func scanRun(ctx context.Context, temporalClient client.Client, workflowID, runID string) (RunSummary, error) {
+	resp, err := temporalClient.DescribeWorkflowExecution(ctx, workflowID, runID)
+	if err != nil {
+		return RunSummary{}, fmt.Errorf("failed to describe run %s: %w", runID, err)
+	}
+	info := resp.GetWorkflowExecutionInfo()
+	summary := RunSummary{
+		RunID:      runID,
+		Status:     enumName(info.GetStatus()),
+		StartTime:  formatTime(info.GetStartTime()),
+		CloseTime:  formatTime(info.GetCloseTime()),
+		EventCount: info.GetHistoryLength(),
+	}
+
+	first, err := firstEvent(ctx, temporalClient, workflowID, runID, enums.HISTORY_EVENT_FILTER_TYPE_ALL_EVENT)
+	if err != nil {
+		return RunSummary{}, err
+	}
+	summary.ContinuedFromRunID = first.GetWorkflowExecutionStartedEventAttributes().GetContinuedExecutionRunId()
+
+	if info.GetStatus() != enums.WORKFLOW_EXECUTION_STATUS_RUNNING {
+		closeEvent, err := firstEvent(ctx, temporalClient, workflowID, runID, enums.HISTORY_EVENT_FILTER_TYPE_CLOSE_EVENT)
+		if err != nil {
+			return RunSummary{}, err
+		}
+		summary.ContinuedToRunID, _ = nextRunID(closeEvent)
+	}
+	return summary, nil
+}
+
+// firstEvent returns the first event of a run's history matching the filter
+// type, or nil if there is none.
+func firstEvent(ctx context.Context, temporalClient client.Client, workflowID, runID string, filterType enums.HistoryEventFilterType) (*history.HistoryEvent, error) {
+	iter := temporalClient.GetWorkflowHistory(ctx, workflowID, runID, false, filterType)
+	if !iter.HasNext() {
+		return nil, nil
+	}
+	evt, err := iter.Next()
+	if err != nil {
+		return nil, fmt.Errorf("failed reading history of run %s: %w", runID, err)
+	}
+	return evt, nil
+}
+
+// nextRunID reports whether e closes the run and, if the workflow carried on
+// in a new run through continue-as-new, retry or cron, that run's ID.
+func nextRunID(e *history.HistoryEvent) (string, bool) {
+	switch {
+	case e.GetWorkflowExecutionContinuedAsNewEventAttributes() != nil:
+		return e.GetWorkflowExecutionContinuedAsNewEventAttributes().GetNewExecutionRunId(), true
+	case e.GetWorkflowExecutionCompletedEventAttributes() != nil:
+		return e.GetWorkflowExecutionCompletedEventAttributes().GetNewExecutionRunId(), true
+	case e.GetWorkflowExecutionFailedEventAttributes() != nil:
+		return e.GetWorkflowExecutionFailedEventAttributes().GetNewExecutionRunId(), true
+	case e.GetWorkflowExecutionTimedOutEventAttributes() != nil:
+		return e.GetWorkflowExecutionTimedOutEventAttributes().GetNewExecutionRunId(), true
+	case e.GetWorkflowExecutionCanceledEventAttributes() != nil,
+		e.GetWorkflowExecutionTerminatedEventAttributes() != nil:
+		return "", true
+	}
+	return "", false
+}
+
+// discoverRunChain returns the runs linked to runID in chronological order,
+// up to maxRuns. Earlier runs are collected before later ones, so when the
+// chain is longer than the cap the runs leading up to the requested one are
+// kept. truncated reports whether the cap cut the chain short.
+func discoverRunChain(ctx context.Context, temporalClient client.Client, workflowID, runID string, maxRuns int) (runs []RunSummary, truncated bool, err error) {
+	if runID == "" {
+		if runID, err = currentRunID(ctx, temporalClient, workflowID); err != nil {
+			return nil, false, err
+		}
+	}
+
+	anchor, err := scanRun(ctx, temporalClient, workflowID, runID)
+	if err != nil {
+		return nil, false, err
+	}
+	runs = []RunSummary{anchor}
+
+	for prev := anchor.ContinuedFromRunID; prev != ""; {
+		if len(runs) >= maxRuns {
+			return runs, true, nil
+		}
+		run, err := scanRun(ctx, temporalClient, workflowID, prev)
+		if err != nil {
+			return nil, false, err
+		}
+		runs = append([]RunSummary{run}, runs...)
+		prev = run.ContinuedFromRunID
+	}
+
+	for next := anchor.ContinuedToRunID; next != ""; {
+		if len(runs) >= maxRuns {
+			return runs, true, nil
+		}
+		run, err := scanRun(ctx, temporalClient, workflowID, next)
+		if err != nil {
+			return nil, false, err
+		}
+		runs = append(runs, run)
+		next = run.ContinuedToRunID
+	}
+	return runs, false, nil
+}
+
+// runChainIterator streams the events of several runs back to back. Each
+// event's position is its event ID offset by the events of earlier runs, so
+// positions increase across the whole chain.
+type runChainIterator struct {
+	ctx            context.Context
+	temporalClient client.Client
+	workflowID     string
+	runIDs         []string
+	offsets        []int64
+
+	idx  int
+	iter client.HistoryEventIterator
+}
+
+func newRunChainIterator(ctx context.Context, temporalClient client.Client, workflowID string, runs []RunSummary) *runChainIterator {
+	it := &runChainIterator{ctx: ctx, temporalClient: temporalClient, workflowID: workflowID}
+	var offset int64
+	for _, r := range runs {
+		it.runIDs = append(it.runIDs, r.RunID)
+		it.offsets = append(it.offsets, offset)
+		offset += r.EventCount
+	}
+	return it
+}
+
+func (it *runChainIterator) HasNext() bool {
+	for it.idx < len(it.runIDs) {
+		if it.iter == nil {
+			it.iter = it.temporalClient.GetWorkflowHistory(it.ctx, it.workflowID, it.runIDs[it.idx], false, enums.HISTORY_EVENT_FILTER_TYPE_ALL_EVENT)
+		}
+		if it.iter.HasNext() {
+			return true
+		}
+		it.idx++
+		it.iter = nil
+	}
+	return false
+}
+
+func (it *runChainIterator) Next() (*history.HistoryEvent, error) {
+	return it.iter.Next()
+}
+
+// runID returns the run of the event last returned by Next.
+func (it *runChainIterator) runID() string {
+	return it.runIDs[it.idx]
+}
+
+func (it *runChainIterator) position(e *history.HistoryEvent) int64 {
+	return it.offsets[it.idx] + e.GetEventId()
+}
+
+// positionOf converts a run ID and event ID, as stored in a cursor, into a
+// position in the chain.
+func (it *runChainIterator) positionOf(runID string, eventID int64) (int64, bool) {
+	for i, id := range it.runIDs {
+		if id == runID {
+			return it.offsets[i] + eventID, true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,100 @@
+package handler
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.temporal.io/api/enums/v1"
+	"go.temporal.io/api/history/v1"
+)
+
+// runEvents builds a run that started from prev and, if next is set,
+// continued as new into it.
+func runEvents(prev, next string, signals int) []*history.HistoryEvent {
+	now := time.Date(2024, 1, 2, 3, 0, 0, 0, time.UTC)
+	events := []*history.HistoryEvent{{
+		EventId: 1, EventTime: &now, EventType: enums.EVENT_TYPE_WORKFLOW_EXECUTION_STARTED,
+		Attributes: &history.HistoryEvent_WorkflowExecutionStartedEventAttributes{WorkflowExecutionStartedEventAttributes: &history.WorkflowExecutionStartedEventAttributes{
+			ContinuedExecutionRunId: prev,
+			FirstExecutionRunId:     "run-1",
+		}},
+	}}
+	for i := 0; i < signals; i++ {
+		events = append(events, &history.HistoryEvent{
+			EventId: int64(len(events) + 1), EventTime: &now, EventType: enums.EVENT_TYPE_WORKFLOW_EXECUTION_SIGNALED,
+			Attributes: &history.HistoryEvent_WorkflowExecutionSignaledEventAttributes{WorkflowExecutionSignaledEventAttributes: &history.WorkflowExecutionSignaledEventAttributes{SignalName: "tick"}},
+		})
+	}
+	if next != "" {
+		events = append(events, &history.HistoryEvent{
+			EventId: int64(len(events) + 1), EventTime: &now, EventType: enums.EVENT_TYPE_WORKFLOW_EXECUTION_CONTINUED_AS_NEW,
+			Attributes: &history.HistoryEvent_WorkflowExecutionContinuedAsNewEventAttributes{WorkflowExecutionContinuedAsNewEventAttributes: &history.WorkflowExecutionContinuedAsNewEventAttributes{NewExecutionRunId: next}},
+		})
+	}
+	return events
+}
+
+// newChainClient serves a workflow that continued as new twice.
+func newChainClient(t *testing.T) *fakeClient {
+	return newFakeClient(t,
+		&fakeRun{workflowID: "wf-1", runID: "run-1", events: runEvents("", "run-2", 1)},
+		&fakeRun{workflowID: "wf-1", runID: "run-2", events: runEvents("run-1", "run-3", 0)},
+		&fakeRun{workflowID: "wf-1", runID: "run-3", events: runEvents("run-2", "", 2)},
+	)
+}
+
+func TestGetWorkflowHistoryHandler_FollowContinueAsNew(t *testing.T) {
+	c := newChainClient(t)
+	result, err := GetWorkflowHistoryHandler(context.Background(), c, WorkflowHistoryArgs{WorkflowID: "wf-1", FollowContinueAsNew: true})
+
+	require.NoError(t, err)
+	assert.Equal(t, "run-3", result.RunID)
+	assert.Equal(t, []RunSummary{
+		{RunID: "run-1", Status: "ContinuedAsNew", StartTime: "2024-01-02T03:00:00Z", CloseTime: "2024-01-02T03:00:00Z", EventCount: 3, ContinuedToRunID: "run-2"},
+		{RunID: "run-2", Status: "ContinuedAsNew", StartTime: "2024-01-02T03:00:00Z", CloseTime: "2024-01-02T03:00:00Z", EventCount: 2, ContinuedFromRunID: "run-1", ContinuedToRunID: "run-3"},
+		{RunID: "run-3", Status: "Running", StartTime: "2024-01-02T03:00:00Z", EventCount: 3, ContinuedFromRunID: "run-2"},
+	}, result.Runs)
+
+	require.Len(t, result.Events, 8)
+	var tags []string
+	for _, e := range result.Events {
+		tags = append(tags, e.RunID)
+	}
+	assert.Equal(t, []string{"run-1", "run-1", "run-1", "run-2", "run-2", "run-3", "run-3", "run-3"}, tags)
+	assert.Equal(t, "Followed 3 runs with 8 events in total; events are tagged with their run_id. Workflow has 8 events. We are examining 8 events.", result.Summary)
+	// Besides the events returned, discovery reads only each run's started
+	// event and the close events of the two closed runs.
+	assert.Equal(t, 8+3+2, c.served)
+}
+
+func TestGetWorkflowHistoryHandler_FollowContinueAsNewPagingAndCap(t *testing.T) {
+	c := newChainClient(t)
+	ctx := context.Background()
+
+	// Starting from the middle run walks both directions.
+	page1, err := GetWorkflowHistoryHandler(ctx, c, WorkflowHistoryArgs{WorkflowID: "wf-1", RunID: "run-2", FollowContinueAsNew: true, MaxEvents: 4})
+	require.NoError(t, err)
+	require.Len(t, page1.Runs, 3)
+	require.Len(t, page1.Events, 4)
+	assert.Equal(t, "run-2", page1.Events[3].RunID)
+	assert.Equal(t, int64(1), page1.Events[3].EventID)
+
+	page2, err := GetWorkflowHistoryHandler(ctx, c, WorkflowHistoryArgs{WorkflowID: "wf-1", RunID: "run-2", FollowContinueAsNew: true, MaxEvents: 4, Cursor: page1.NextCursor})
+	require.NoError(t, err)
+	require.Len(t, page2.Events, 4)
+	assert.Equal(t, "run-2", page2.Events[0].RunID)
+	assert.Equal(t, int64(2), page2.Events[0].EventID)
+	assert.Empty(t, page2.NextCursor)
+
+	_, err = GetWorkflowHistoryHandler(ctx, c, WorkflowHistoryArgs{WorkflowID: "wf-1", Cursor: page1.NextCursor})
+	assert.EqualError(t, err, "cursor does not match follow_continue_as_new; pass the same arguments as the previous call")
+
+	capped, err := GetWorkflowHistoryHandler(ctx, c, WorkflowHistoryArgs{WorkflowID: "wf-1", FollowContinueAsNew: true, MaxRuns: 2})
+	require.NoError(t, err)
+	require.Len(t, capped.Runs, 2)
+	assert.Equal(t, "run-2", capped.Runs[0].RunID)
+	assert.Contains(t, capped.Summary, "runs beyond the cap were not read")
+}
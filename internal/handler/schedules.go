@@ -0,0 +1,459 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.temporal.io/api/common/v1"
+	"go.temporal.io/api/enums/v1"
+	"go.temporal.io/sdk/client"
+)
+
+const (
+	ScheduleActionPause    = "pause"
+	ScheduleActionUnpause  = "unpause"
+	ScheduleActionTrigger  = "trigger"
+	ScheduleActionBackfill = "backfill"
+)
+
+// overlapPolicies maps the overlap argument to the server's policy. An empty
+// argument leaves the schedule's own policy in effect.
+var overlapPolicies = map[string]enums.ScheduleOverlapPolicy{
+	"skip":            enums.SCHEDULE_OVERLAP_POLICY_SKIP,
+	"buffer_one":      enums.SCHEDULE_OVERLAP_POLICY_BUFFER_ONE,
+	"buffer_all":      enums.SCHEDULE_OVERLAP_POLICY_BUFFER_ALL,
+	"cancel_other":    enums.SCHEDULE_OVERLAP_POLICY_CANCEL_OTHER,
+	"terminate_other": enums.SCHEDULE_OVERLAP_POLICY_TERMINATE_OTHER,
+	"allow_all":       enums.SCHEDULE_OVERLAP_POLICY_ALLOW_ALL,
+}
+
+type ListSchedulesArgs struct {
+	PageSize int `json:"page_size,omitempty" jsonschema:"description=Maximum number of schedules to return"`
+}
+
+type DescribeScheduleArgs struct {
+	ScheduleID string `json:"schedule_id" jsonschema:"required,description=The ID of the schedule to describe"`
+}
+
+type ScheduleActionArgs struct {
+	ScheduleID string `json:"schedule_id" jsonschema:"required,description=The ID of the schedule"`
+	Action     string `json:"action" jsonschema:"required,enum=pause,enum=unpause,enum=trigger,enum=backfill,description=What to do to the schedule"`
+	Reason     string `json:"reason" jsonschema:"required,description=Why the change is being made, recorded as the schedule's note when pausing or unpausing"`
+	Overlap    string `json:"overlap,omitempty" jsonschema:"enum=skip,enum=buffer_one,enum=buffer_all,enum=cancel_other,enum=terminate_other,enum=allow_all,description=With trigger or backfill, the overlap policy to use instead of the schedule's own"`
+	StartTime  string `json:"start_time,omitempty" jsonschema:"description=With backfill, the start of the time range in RFC3339 format"`
+	EndTime    string `json:"end_time,omitempty" jsonschema:"description=With backfill, the end of the time range in RFC3339 format"`
+}
+
+type ScheduleSummary struct {
+	ScheduleID     string `json:"schedule_id"`
+	WorkflowType   string `json:"workflow_type,omitempty"`
+	Spec           string `json:"spec,omitempty"`
+	Paused         bool   `json:"paused"`
+	Note           string `json:"note,omitempty"`
+	LastActionTime string `json:"last_action_time,omitempty"`
+	NextActionTime string `json:"next_action_time,omitempty"`
+}
+
+type ListSchedulesResponse struct {
+	Schedules []ScheduleSummary `json:"schedules"`
+	Truncated bool              `json:"truncated,omitempty"`
+	Summary   string            `json:"summary"`
+}
+
+type ScheduleSpecInfo struct {
+	CronExpressions []string `json:"cron_expressions,omitempty"`
+	Calendars       []string `json:"calendars,omitempty"`
+	Intervals       []string `json:"intervals,omitempty"`
+	Skip            []string `json:"skip,omitempty"`
+	StartAt         string   `json:"start_at,omitempty"`
+	EndAt           string   `json:"end_at,omitempty"`
+	Jitter          string   `json:"jitter,omitempty"`
+	TimeZone        string   `json:"time_zone,omitempty"`
+}
+
+type SchedulePolicies struct {
+	Overlap        string `json:"overlap,omitempty"`
+	CatchupWindow  string `json:"catchup_window,omitempty"`
+	PauseOnFailure bool   `json:"pause_on_failure,omitempty"`
+}
+
+type ScheduleWorkflowAction struct {
+	WorkflowType string        `json:"workflow_type"`
+	WorkflowID   string        `json:"workflow_id,omitempty"`
+	TaskQueue    string        `json:"task_queue,omitempty"`
+	Args         []interface{} `json:"args,omitempty"`
+}
+
+// ScheduleRun is a workflow started by a schedule. The times are set for
+// recent actions only.
+type ScheduleRun struct {
+	WorkflowID   string `json:"workflow_id,omitempty"`
+	RunID        string `json:"run_id,omitempty"`
+	ScheduleTime string `json:"schedule_time,omitempty"`
+	ActualTime   string `json:"actual_time,omitempty"`
+}
+
+type ScheduleDescription struct {
+	ScheduleID          string                  `json:"schedule_id"`
+	Action              *ScheduleWorkflowAction `json:"action,omitempty"`
+	Spec                ScheduleSpecInfo        `json:"spec"`
+	Policies            SchedulePolicies        `json:"policies"`
+	Paused              bool                    `json:"paused"`
+	Note                string                  `json:"note,omitempty"`
+	RemainingActions    *int                    `json:"remaining_actions,omitempty"`
+	NumActions          int                     `json:"num_actions"`
+	MissedCatchupWindow int                     `json:"missed_catchup_window,omitempty"`
+	SkippedOverlap      int                     `json:"skipped_overlap,omitempty"`
+	RunningWorkflows    []ScheduleRun           `json:"running_workflows,omitempty"`
+	RecentActions       []ScheduleRun           `json:"recent_actions,omitempty"`
+	NextActionTimes     []string                `json:"next_action_times,omitempty"`
+	CreatedAt           string                  `json:"created_at,omitempty"`
+	LastUpdateAt        string                  `json:"last_update_at,omitempty"`
+	Summary             string                  `json:"summary"`
+}
+
+// ScheduleActionResponse reports a change made to a schedule and its state
+// afterwards.
+type ScheduleActionResponse struct {
+	ScheduleID string               `json:"schedule_id"`
+	Action     string               `json:"action"`
+	Note       string               `json:"note,omitempty"`
+	State      *ScheduleDescription `json:"state,omitempty"`
+	Summary    string               `json:"summary"`
+}
+
+// ListSchedulesHandler lists the schedules in the namespace. The SDK iterator
+// does not expose a page token, so the list stops at page_size schedules.
+func ListSchedulesHandler(ctx context.Context, temporalClient client.Client, args ListSchedulesArgs) (ListSchedulesResponse, error) {
+	pageSize := args.PageSize
+	if pageSize <= 0 {
+		pageSize = defaultListPageSize
+	}
+	if pageSize > maxListPageSize {
+		pageSize = maxListPageSize
+	}
+
+	iter, err := temporalClient.ScheduleClient().List(ctx, client.ScheduleListOptions{PageSize: pageSize})
+	if err != nil {
+		return ListSchedulesResponse{}, fmt.Errorf("failed to list schedules: %w", err)
+	}
+
+	result := ListSchedulesResponse{Schedules: []ScheduleSummary{}}
+	paused := 0
+	for iter.HasNext() {
+		if len(result.Schedules) == pageSize {
+			result.Truncated = true
+			break
+		}
+		entry, err := iter.Next()
+		if err != nil {
+			return ListSchedulesResponse{}, fmt.Errorf("failed to list schedules: %w", err)
+		}
+		summary := ScheduleSummary{
+			ScheduleID:   entry.ID,
+			WorkflowType: entry.WorkflowType.Name,
+			Spec:         strings.Join(describeScheduleSpec(entry.Spec), "; "),
+			Paused:       entry.Paused,
+			Note:         entry.Note,
+		}
+		if n := len(entry.RecentActions); n > 0 {
+			summary.LastActionTime = formatTime(&entry.RecentActions[n-1].ActualTime)
+		}
+		if len(entry.NextActionTimes) > 0 {
+			summary.NextActionTime = formatTime(&entry.NextActionTimes[0])
+		}
+		if entry.Paused {
+			paused++
+		}
+		result.Schedules = append(result.Schedules, summary)
+	}
+
+	result.Summary = fmt.Sprintf("Found %d schedules, %d paused.", len(result.Schedules), paused)
+	if result.Truncated {
+		result.Summary += fmt.Sprintf(" Only the first %d are shown; raise page_size to see more.", pageSize)
+	}
+	return result, nil
+}
+
+// DescribeScheduleHandler returns a schedule's spec, policies, state and
+// recent and upcoming actions.
+func DescribeScheduleHandler(ctx context.Context, temporalClient client.Client, args DescribeScheduleArgs) (ScheduleDescription, error) {
+	if args.ScheduleID == "" {
+		return ScheduleDescription{}, fmt.Errorf("schedule_id is required")
+	}
+	desc, err := temporalClient.ScheduleClient().GetHandle(ctx, args.ScheduleID).Describe(ctx)
+	if err != nil {
+		return ScheduleDescription{}, fmt.Errorf("failed to describe schedule: %w", err)
+	}
+	return newScheduleDescription(args.ScheduleID, desc), nil
+}
+
+// ScheduleActionHandler pauses, unpauses, triggers or backfills a schedule
+// and returns its state afterwards.
+func ScheduleActionHandler(ctx context.Context, temporalClient client.Client, args ScheduleActionArgs) (ScheduleActionResponse, error) {
+	note, err := mutationIdentity(args.Reason)
+	if err != nil {
+		return ScheduleActionResponse{}, err
+	}
+	if args.ScheduleID == "" {
+		return ScheduleActionResponse{}, fmt.Errorf("schedule_id is required")
+	}
+	overlap := enums.SCHEDULE_OVERLAP_POLICY_UNSPECIFIED
+	if args.Overlap != "" {
+		var ok bool
+		if overlap, ok = overlapPolicies[args.Overlap]; !ok {
+			return ScheduleActionResponse{}, fmt.Errorf("invalid overlap %q", args.Overlap)
+		}
+	}
+
+	handle := temporalClient.ScheduleClient().GetHandle(ctx, args.ScheduleID)
+	result := ScheduleActionResponse{ScheduleID: args.ScheduleID, Action: args.Action}
+	var done string
+
+	switch args.Action {
+	case ScheduleActionPause:
+		if err := handle.Pause(ctx, client.SchedulePauseOptions{Note: note}); err != nil {
+			return ScheduleActionResponse{}, fmt.Errorf("failed to pause schedule: %w", err)
+		}
+		result.Note = note
+		done = "Paused the schedule."
+	case ScheduleActionUnpause:
+		if err := handle.Unpause(ctx, client.ScheduleUnpauseOptions{Note: note}); err != nil {
+			return ScheduleActionResponse{}, fmt.Errorf("failed to unpause schedule: %w", err)
+		}
+		result.Note = note
+		done = "Unpaused the schedule."
+	case ScheduleActionTrigger:
+		if err := handle.Trigger(ctx, client.ScheduleTriggerOptions{Overlap: overlap}); err != nil {
+			return ScheduleActionResponse{}, fmt.Errorf("failed to trigger schedule: %w", err)
+		}
+		done = "Triggered the schedule's action."
+	case ScheduleActionBackfill:
+		start, end, err := backfillRange(args.StartTime, args.EndTime)
+		if err != nil {
+			return ScheduleActionResponse{}, err
+		}
+		err = handle.Backfill(ctx, client.ScheduleBackfillOptions{
+			Backfill: []client.ScheduleBackfill{{Start: start, End: end, Overlap: overlap}},
+		})
+		if err != nil {
+			return ScheduleActionResponse{}, fmt.Errorf("failed to backfill schedule: %w", err)
+		}
+		done = fmt.Sprintf("Requested a backfill from %s to %s.", formatTime(&start), formatTime(&end))
+	default:
+		return ScheduleActionResponse{}, fmt.Errorf("invalid action %q", args.Action)
+	}
+
+	// The change has already been made, so failing to describe the schedule
+	// is reported in the summary rather than as an error.
+	state, err := DescribeScheduleHandler(ctx, temporalClient, DescribeScheduleArgs{ScheduleID: args.ScheduleID})
+	if err != nil {
+		result.Summary = fmt.Sprintf("%s Could not read the schedule afterwards: %v", done, err)
+		return result, nil
+	}
+	result.State = &state
+	result.Summary = done + " " + state.Summary
+	return result, nil
+}
+
+func backfillRange(startTime, endTime string) (time.Time, time.Time, error) {
+	if startTime == "" || endTime == "" {
+		return time.Time{}, time.Time{}, fmt.Errorf("start_time and end_time are required to backfill")
+	}
+	start, err := time.Parse(time.RFC3339, startTime)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid start_time: %w", err)
+	}
+	end, err := time.Parse(time.RFC3339, endTime)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid end_time: %w", err)
+	}
+	if !end.After(start) {
+		return time.Time{}, time.Time{}, fmt.Errorf("end_time must be after start_time")
+	}
+	return start, end, nil
+}
+
+func newScheduleDescription(scheduleID string, desc *client.ScheduleDescription) ScheduleDescription {
+	result := ScheduleDescription{
+		ScheduleID:          scheduleID,
+		NumActions:          desc.Info.NumActions,
+		MissedCatchupWindow: desc.Info.NumActionsMissedCatchupWindow,
+		SkippedOverlap:      desc.Info.NumActionsSkippedOverlap,
+		CreatedAt:           formatTime(&desc.Info.CreatedAt),
+		LastUpdateAt:        formatTime(&desc.Info.LastUpdateAt),
+	}
+
+	if action, ok := desc.Schedule.Action.(*client.ScheduleWorkflowAction); ok {
+		result.Action = &ScheduleWorkflowAction{
+			WorkflowType: fmt.Sprint(action.Workflow),
+			WorkflowID:   action.ID,
+			TaskQueue:    action.TaskQueue,
+		}
+		for _, arg := range action.Args {
+			if payload, ok := arg.(*common.Payload); ok {
+				result.Action.Args = append(result.Action.Args, decodePayload(payload))
+			}
+		}
+	}
+	if spec := desc.Schedule.Spec; spec != nil {
+		result.Spec = ScheduleSpecInfo{
+			CronExpressions: spec.CronExpressions,
+			StartAt:         formatTime(&spec.StartAt),
+			EndAt:           formatTime(&spec.EndAt),
+			TimeZone:        spec.TimeZoneName,
+		}
+		for _, calendar := range spec.Calendars {
+			result.Spec.Calendars = append(result.Spec.Calendars, formatCalendar(calendar))
+		}
+		for _, interval := range spec.Intervals {
+			result.Spec.Intervals = append(result.Spec.Intervals, formatInterval(interval))
+		}
+		for _, calendar := range spec.Skip {
+			result.Spec.Skip = append(result.Spec.Skip, formatCalendar(calendar))
+		}
+		if spec.Jitter > 0 {
+			result.Spec.Jitter = spec.Jitter.String()
+		}
+	}
+	if policy := desc.Schedule.Policy; policy != nil {
+		result.Policies = SchedulePolicies{
+			Overlap:        enumName(policy.Overlap),
+			PauseOnFailure: policy.PauseOnFailure,
+		}
+		if policy.CatchupWindow > 0 {
+			result.Policies.CatchupWindow = policy.CatchupWindow.String()
+		}
+	}
+	if state := desc.Schedule.State; state != nil {
+		result.Paused = state.Paused
+		result.Note = state.Note
+		if state.LimitedActions {
+			remaining := state.RemainingActions
+			result.RemainingActions = &remaining
+		}
+	}
+
+	for _, running := range desc.Info.RunningWorkflows {
+		result.RunningWorkflows = append(result.RunningWorkflows, ScheduleRun{WorkflowID: running.WorkflowID, RunID: running.FirstExecutionRunID})
+	}
+	for _, action := range desc.Info.RecentActions {
+		run := ScheduleRun{ScheduleTime: formatTime(&action.ScheduleTime), ActualTime: formatTime(&action.ActualTime)}
+		if started := action.StartWorkflowResult; started != nil {
+			run.WorkflowID = started.WorkflowID
+			run.RunID = started.FirstExecutionRunID
+		}
+		result.RecentActions = append(result.RecentActions, run)
+	}
+	for i := range desc.Info.NextActionTimes {
+		result.NextActionTimes = append(result.NextActionTimes, formatTime(&desc.Info.NextActionTimes[i]))
+	}
+
+	result.Summary = scheduleSummary(result, describeScheduleSpec(desc.Schedule.Spec))
+	return result
+}
+
+func scheduleSummary(desc ScheduleDescription, spec []string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Schedule %s", desc.ScheduleID)
+	if desc.Action != nil {
+		fmt.Fprintf(&b, " starts %s", desc.Action.WorkflowType)
+	}
+	if len(spec) > 0 {
+		fmt.Fprintf(&b, " (%s)", strings.Join(spec, "; "))
+	}
+	b.WriteString(".")
+	if desc.Paused {
+		b.WriteString(" It is paused")
+		if desc.Note != "" {
+			fmt.Fprintf(&b, ": %s", desc.Note)
+		}
+		b.WriteString(".")
+	} else if len(desc.NextActionTimes) > 0 {
+		fmt.Fprintf(&b, " The next action is at %s.", desc.NextActionTimes[0])
+	}
+	fmt.Fprintf(&b, " It has taken %d actions", desc.NumActions)
+	if n := len(desc.RecentActions); n > 0 {
+		fmt.Fprintf(&b, ", the last at %s", desc.RecentActions[n-1].ActualTime)
+	}
+	b.WriteString(".")
+	if missed := desc.MissedCatchupWindow + desc.SkippedOverlap; missed > 0 {
+		fmt.Fprintf(&b, " %d were missed or skipped.", missed)
+	}
+	if n := len(desc.RunningWorkflows); n > 0 {
+		fmt.Fprintf(&b, " %d workflows it started are running.", n)
+	}
+	return b.String()
+}
+
+// describeScheduleSpec renders each part of a schedule spec as one short
+// line, for summaries and lists.
+func describeScheduleSpec(spec *client.ScheduleSpec) []string {
+	if spec == nil {
+		return nil
+	}
+	var parts []string
+	for _, cron := range spec.CronExpressions {
+		parts = append(parts, "cron "+cron)
+	}
+	for _, calendar := range spec.Calendars {
+		parts = append(parts, "calendar "+formatCalendar(calendar))
+	}
+	for _, interval := range spec.Intervals {
+		parts = append(parts, formatInterval(interval))
+	}
+	return parts
+}
+
+func formatInterval(interval client.ScheduleIntervalSpec) string {
+	s := "every " + interval.Every.String()
+	if interval.Offset > 0 {
+		s += " offset " + interval.Offset.String()
+	}
+	return s
+}
+
+// formatCalendar renders a calendar spec as its set fields, e.g.
+// "minute=0 hour=9 day_of_week=1-5".
+func formatCalendar(calendar client.ScheduleCalendarSpec) string {
+	fields := []struct {
+		name   string
+		ranges []client.ScheduleRange
+	}{
+		{"second", calendar.Second},
+		{"minute", calendar.Minute},
+		{"hour", calendar.Hour},
+		{"day_of_month", calendar.DayOfMonth},
+		{"month", calendar.Month},
+		{"year", calendar.Year},
+		{"day_of_week", calendar.DayOfWeek},
+	}
+	var parts []string
+	for _, field := range fields {
+		if len(field.ranges) == 0 {
+			continue
+		}
+		values := make([]string, len(field.ranges))
+		for i, r := range field.ranges {
+			values[i] = formatScheduleRange(r)
+		}
+		parts = append(parts, field.name+"="+strings.Join(values, ","))
+	}
+	if calendar.Comment != "" {
+		parts = append(parts, fmt.Sprintf("(%s)", calendar.Comment))
+	}
+	return strings.Join(parts, " ")
+}
+
+func formatScheduleRange(r client.ScheduleRange) string {
+	s := fmt.Sprint(r.Start)
+	if r.End > r.Start {
+		s += fmt.Sprintf("-%d", r.End)
+	}
+	if r.Step > 1 {
+		s += fmt.Sprintf("/%d", r.Step)
+	}
+	return s
+}
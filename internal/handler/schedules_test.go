@@ -0,0 +1,177 @@
+package handler
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.temporal.io/api/enums/v1"
+	"go.temporal.io/sdk/client"
+	"go.temporal.io/sdk/converter"
+)
+
+// nightlyReport is the schedule served to the describe and action tests.
+func nightlyReport() *client.ScheduleDescription {
+	arg, _ := converter.GetDefaultDataConverter().ToPayload("eu")
+	desc := &client.ScheduleDescription{
+		Schedule: client.Schedule{
+			Action: &client.ScheduleWorkflowAction{ID: "nightly-report", Workflow: "Report", TaskQueue: "reports", Args: []interface{}{arg}},
+			Spec: &client.ScheduleSpec{
+				Calendars: []client.ScheduleCalendarSpec{{
+					Minute:    []client.ScheduleRange{{Start: 0}},
+					Hour:      []client.ScheduleRange{{Start: 2}},
+					DayOfWeek: []client.ScheduleRange{{Start: 1, End: 5}},
+				}},
+				Intervals: []client.ScheduleIntervalSpec{{Every: time.Hour, Offset: 5 * time.Minute}},
+				Jitter:    30 * time.Second,
+			},
+		},
+	}
+	allocate(&desc.Schedule.Policy)
+	desc.Schedule.Policy.Overlap = enums.SCHEDULE_OVERLAP_POLICY_SKIP
+	desc.Schedule.Policy.CatchupWindow = time.Minute
+	allocate(&desc.Schedule.State)
+	desc.Info.NumActions = 12
+	desc.Info.NumActionsSkippedOverlap = 1
+	desc.Info.RunningWorkflows = []client.ScheduleWorkflowExecution{{WorkflowID: "nightly-report-2", FirstExecutionRunID: "run-2"}}
+	desc.Info.RecentActions = []client.ScheduleActionResult{{
+		ScheduleTime:        time.Date(2024, 1, 2, 2, 0, 0, 0, time.UTC),
+		ActualTime:          time.Date(2024, 1, 2, 2, 0, 1, 0, time.UTC),
+		StartWorkflowResult: &client.ScheduleWorkflowExecution{WorkflowID: "nightly-report-2", FirstExecutionRunID: "run-2"},
+	}}
+	desc.Info.NextActionTimes = []time.Time{time.Date(2024, 1, 3, 2, 0, 0, 0, time.UTC)}
+	desc.Info.CreatedAt = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	return desc
+}
+
+// allocate sets a nil struct pointer to a new zero value. The SDK does not
+// export the schedule policy and state types, so they cannot be built as
+// literals.
+func allocate(ptr interface{}) {
+	v := reflect.ValueOf(ptr).Elem()
+	v.Set(reflect.New(v.Type().Elem()))
+}
+
+func TestListSchedulesHandler(t *testing.T) {
+	next := time.Date(2024, 1, 3, 2, 0, 0, 0, time.UTC)
+	c := newFakeClient(t)
+	c.schedules = []*client.ScheduleListEntry{
+		{ID: "nightly-report", Spec: &client.ScheduleSpec{CronExpressions: []string{"0 2 * * *"}}, NextActionTimes: []time.Time{next}},
+		{ID: "cleanup", Paused: true, Note: "waiting on migration"},
+		{ID: "hourly"},
+	}
+	c.schedules[0].WorkflowType.Name = "Report"
+
+	t.Run("Lists schedules", func(t *testing.T) {
+		result, err := ListSchedulesHandler(context.Background(), c, ListSchedulesArgs{})
+
+		require.NoError(t, err)
+		require.Len(t, result.Schedules, 3)
+		assert.Equal(t, ScheduleSummary{ScheduleID: "nightly-report", WorkflowType: "Report", Spec: "cron 0 2 * * *", NextActionTime: "2024-01-03T02:00:00Z"}, result.Schedules[0])
+		assert.True(t, result.Schedules[1].Paused)
+		assert.Equal(t, "Found 3 schedules, 1 paused.", result.Summary)
+	})
+
+	t.Run("Stops at page size", func(t *testing.T) {
+		result, err := ListSchedulesHandler(context.Background(), c, ListSchedulesArgs{PageSize: 2})
+
+		require.NoError(t, err)
+		assert.Len(t, result.Schedules, 2)
+		assert.True(t, result.Truncated)
+	})
+}
+
+func TestDescribeScheduleHandler(t *testing.T) {
+	c := newFakeClient(t)
+	c.schedule = nightlyReport()
+
+	result, err := DescribeScheduleHandler(context.Background(), c, DescribeScheduleArgs{ScheduleID: "nightly-report"})
+
+	require.NoError(t, err)
+	assert.Equal(t, &ScheduleWorkflowAction{WorkflowType: "Report", WorkflowID: "nightly-report", TaskQueue: "reports", Args: []interface{}{"eu"}}, result.Action)
+	assert.Equal(t, []string{"minute=0 hour=2 day_of_week=1-5"}, result.Spec.Calendars)
+	assert.Equal(t, []string{"every 1h0m0s offset 5m0s"}, result.Spec.Intervals)
+	assert.Equal(t, "30s", result.Spec.Jitter)
+	assert.Equal(t, SchedulePolicies{Overlap: "Skip", CatchupWindow: "1m0s"}, result.Policies)
+	assert.Equal(t, []ScheduleRun{{WorkflowID: "nightly-report-2", RunID: "run-2"}}, result.RunningWorkflows)
+	assert.Equal(t, "2024-01-02T02:00:01Z", result.RecentActions[0].ActualTime)
+	assert.Equal(t, []string{"2024-01-03T02:00:00Z"}, result.NextActionTimes)
+	assert.Equal(t, "Schedule nightly-report starts Report (calendar minute=0 hour=2 day_of_week=1-5; every 1h0m0s offset 5m0s). "+
+		"The next action is at 2024-01-03T02:00:00Z. It has taken 12 actions, the last at 2024-01-02T02:00:01Z. "+
+		"1 were missed or skipped. 1 workflows it started are running.", result.Summary)
+}
+
+func TestScheduleActionHandler(t *testing.T) {
+	t.Run("Pause records the reason as the note", func(t *testing.T) {
+		c := newFakeClient(t)
+		c.schedule = nightlyReport()
+
+		result, err := ScheduleActionHandler(context.Background(), c, ScheduleActionArgs{ScheduleID: "nightly-report", Action: ScheduleActionPause, Reason: "INC-42"})
+
+		require.NoError(t, err)
+		require.NotNil(t, c.paused)
+		assert.Equal(t, "mcp-temporal-server: INC-42", c.paused.Note)
+		require.NotNil(t, result.State)
+		assert.True(t, result.State.Paused)
+		assert.Contains(t, result.Summary, "Paused the schedule. Schedule nightly-report starts Report")
+		assert.Contains(t, result.Summary, "It is paused: mcp-temporal-server: INC-42.")
+	})
+
+	t.Run("Trigger with overlap policy", func(t *testing.T) {
+		c := newFakeClient(t)
+		c.schedule = nightlyReport()
+
+		_, err := ScheduleActionHandler(context.Background(), c, ScheduleActionArgs{ScheduleID: "nightly-report", Action: ScheduleActionTrigger, Reason: "rerun", Overlap: "allow_all"})
+
+		require.NoError(t, err)
+		require.NotNil(t, c.trigger)
+		assert.Equal(t, enums.SCHEDULE_OVERLAP_POLICY_ALLOW_ALL, c.trigger.Overlap)
+	})
+
+	t.Run("Backfill a time range", func(t *testing.T) {
+		c := newFakeClient(t)
+		c.schedule = nightlyReport()
+
+		result, err := ScheduleActionHandler(context.Background(), c, ScheduleActionArgs{
+			ScheduleID: "nightly-report", Action: ScheduleActionBackfill, Reason: "outage",
+			StartTime: "2024-01-01T00:00:00Z", EndTime: "2024-01-02T00:00:00Z",
+		})
+
+		require.NoError(t, err)
+		require.NotNil(t, c.backfill)
+		require.Len(t, c.backfill.Backfill, 1)
+		assert.Equal(t, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), c.backfill.Backfill[0].Start)
+		assert.Contains(t, result.Summary, "Requested a backfill from 2024-01-01T00:00:00Z to 2024-01-02T00:00:00Z.")
+	})
+
+	t.Run("Invalid requests", func(t *testing.T) {
+		tests := []struct {
+			name string
+			args ScheduleActionArgs
+			want string
+		}{
+			{"reason required", ScheduleActionArgs{Action: ScheduleActionPause}, "a reason is required"},
+			{"unknown action", ScheduleActionArgs{Action: "delete", Reason: "r"}, "invalid action"},
+			{"unknown overlap", ScheduleActionArgs{Action: ScheduleActionTrigger, Overlap: "never", Reason: "r"}, "invalid overlap"},
+			{"backfill without range", ScheduleActionArgs{Action: ScheduleActionBackfill, Reason: "r"}, "start_time and end_time are required"},
+			{"backfill backwards", ScheduleActionArgs{Action: ScheduleActionBackfill, Reason: "r", StartTime: "2024-01-02T00:00:00Z", EndTime: "2024-01-01T00:00:00Z"}, "end_time must be after start_time"},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				c := newFakeClient(t)
+				c.schedule = nightlyReport()
+				tt.args.ScheduleID = "nightly-report"
+
+				_, err := ScheduleActionHandler(context.Background(), c, tt.args)
+
+				assert.ErrorContains(t, err, tt.want)
+				assert.Nil(t, c.paused)
+				assert.Nil(t, c.trigger)
+				assert.Nil(t, c.backfill)
+			})
+		}
+	})
+}
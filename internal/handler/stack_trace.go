@@ -0,0 +1,81 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"go.temporal.io/sdk/client"
+)
+
+type WorkflowStackTraceArgs struct {
+	WorkflowID string `json:"workflow_id" jsonschema:"required,description=The ID of the workflow"`
+	RunID      string `json:"run_id,omitempty" jsonschema:"description=Optional run ID of the workflow"`
+}
+
+type WorkflowStackTrace struct {
+	WorkflowID string `json:"workflow_id"`
+	RunID      string `json:"run_id"`
+	Status     string `json:"status"`
+	TaskQueue  string `json:"task_queue"`
+	Pollers    int    `json:"pollers"`
+	StackTrace string `json:"stack_trace,omitempty"`
+	Summary    string `json:"summary"`
+}
+
+// WorkflowStackTraceHandler asks a worker for the workflow's coroutine stacks
+// using the built-in __stack_trace query. The query can only be answered by a
+// worker polling the workflow's task queue, so the pollers are checked first
+// and their absence is reported instead of waiting for the query to time out.
+func WorkflowStackTraceHandler(ctx context.Context, temporalClient client.Client, args WorkflowStackTraceArgs) (WorkflowStackTrace, error) {
+	target, err := describeQueryTarget(ctx, temporalClient, args.WorkflowID, args.RunID)
+	if err != nil {
+		return WorkflowStackTrace{}, err
+	}
+	result := WorkflowStackTrace{
+		WorkflowID: args.WorkflowID,
+		RunID:      target.RunID,
+		Status:     target.Status,
+		TaskQueue:  target.TaskQueue,
+		Pollers:    target.Pollers,
+	}
+	if target.Pollers == 0 {
+		result.Summary = target.noPollerSummary()
+		return result, nil
+	}
+
+	queryCtx, cancel := context.WithTimeout(ctx, queryTimeout)
+	defer cancel()
+	value, err := temporalClient.QueryWorkflow(queryCtx, args.WorkflowID, result.RunID, client.QueryTypeStackTrace)
+	if err != nil {
+		if isQueryUnanswered(err) {
+			result.Summary = target.unansweredSummary()
+			return result, nil
+		}
+		return WorkflowStackTrace{}, fmt.Errorf("failed to query stack trace: %w", err)
+	}
+	if err := value.Get(&result.StackTrace); err != nil {
+		return WorkflowStackTrace{}, fmt.Errorf("failed to decode stack trace: %w", err)
+	}
+
+	result.Summary = fmt.Sprintf("Workflow is %s.", result.Status)
+	if coroutines := coroutineStates(result.StackTrace); len(coroutines) > 0 {
+		result.Summary += fmt.Sprintf(" Stack trace has %d coroutines: %s.", len(coroutines), strings.Join(coroutines, "; "))
+	} else {
+		result.Summary += " Stack trace returned by the worker is included."
+	}
+	return result, nil
+}
+
+// coroutineStates returns the header of each coroutine in a Go SDK stack
+// trace, e.g. "root [blocked on selector-1.Select]". Stacks from workers in
+// other languages have no such headers.
+func coroutineStates(trace string) []string {
+	var states []string
+	for _, line := range strings.Split(trace, "\n") {
+		if strings.HasPrefix(line, "coroutine ") && strings.HasSuffix(line, "]:") {
+			states = append(states, strings.TrimSuffix(strings.TrimPrefix(line, "coroutine "), ":"))
+		}
+	}
+	return states
+}
@@ -0,0 +1,116 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.temporal.io/api/common/v1"
+	"go.temporal.io/api/enums/v1"
+	"go.temporal.io/api/serviceerror"
+	"go.temporal.io/api/taskqueue/v1"
+	"go.temporal.io/api/workflow/v1"
+	"go.temporal.io/api/workflowservice/v1"
+	"go.temporal.io/sdk/client"
+	"go.temporal.io/sdk/mocks"
+)
+
+const goStackTrace = `coroutine root [blocked on chan-1.Receive]:
+go.temporal.io/sdk/internal.(*decodeFutureImpl).Get(...)
+
+coroutine 1 [blocked on selector-2.Select]:
+go.temporal.io/sdk/internal.(*selectorImpl).Select(...)
+`
+
+// stackTraceClient serves workflow wf-1 on task queue orders, which the
+// given number of workers polled a moment ago.
+func stackTraceClient(pollers int) *mocks.Client {
+	now := time.Now()
+	var infos []*taskqueue.PollerInfo
+	for i := 0; i < pollers; i++ {
+		infos = append(infos, &taskqueue.PollerInfo{Identity: "worker", LastAccessTime: &now})
+	}
+	return stackTraceClientWithPollers(infos)
+}
+
+func stackTraceClientWithPollers(pollers []*taskqueue.PollerInfo) *mocks.Client {
+	mockClient := &mocks.Client{}
+	mockClient.On("DescribeWorkflowExecution", mock.Anything, "wf-1", "").Return(&workflowservice.DescribeWorkflowExecutionResponse{
+		WorkflowExecutionInfo: &workflow.WorkflowExecutionInfo{
+			Execution: &common.WorkflowExecution{WorkflowId: "wf-1", RunId: "run-1"},
+			Status:    enums.WORKFLOW_EXECUTION_STATUS_RUNNING,
+			TaskQueue: "orders",
+		},
+	}, nil)
+
+	resp := &workflowservice.DescribeTaskQueueResponse{Pollers: pollers}
+	mockClient.On("DescribeTaskQueue", mock.Anything, "orders", enums.TASK_QUEUE_TYPE_WORKFLOW).Return(resp, nil)
+	return mockClient
+}
+
+func TestWorkflowStackTraceHandler(t *testing.T) {
+	t.Run("Stack trace from a polling worker", func(t *testing.T) {
+		mockClient := stackTraceClient(1)
+		value := &mocks.Value{}
+		value.On("Get", mock.Anything).Run(func(args mock.Arguments) {
+			*args.Get(0).(*string) = goStackTrace
+		}).Return(nil)
+		mockClient.On("QueryWorkflow", mock.Anything, "wf-1", "run-1", client.QueryTypeStackTrace).Return(value, nil)
+
+		result, err := WorkflowStackTraceHandler(context.Background(), mockClient, WorkflowStackTraceArgs{WorkflowID: "wf-1"})
+
+		require.NoError(t, err)
+		assert.Equal(t, goStackTrace, result.StackTrace)
+		assert.Equal(t, 1, result.Pollers)
+		assert.Contains(t, result.Summary, "2 coroutines: root [blocked on chan-1.Receive]; 1 [blocked on selector-2.Select]")
+	})
+
+	t.Run("No worker polling the task queue", func(t *testing.T) {
+		mockClient := stackTraceClient(0)
+
+		result, err := WorkflowStackTraceHandler(context.Background(), mockClient, WorkflowStackTraceArgs{WorkflowID: "wf-1"})
+
+		require.NoError(t, err)
+		assert.Empty(t, result.StackTrace)
+		assert.Contains(t, result.Summary, "No worker is polling task queue orders")
+		mockClient.AssertNotCalled(t, "QueryWorkflow", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Only workers that have gone away", func(t *testing.T) {
+		lastSeen := time.Now().Add(-10 * time.Minute)
+		mockClient := stackTraceClientWithPollers([]*taskqueue.PollerInfo{{Identity: "dead-worker", LastAccessTime: &lastSeen}})
+
+		result, err := WorkflowStackTraceHandler(context.Background(), mockClient, WorkflowStackTraceArgs{WorkflowID: "wf-1"})
+
+		require.NoError(t, err)
+		assert.Equal(t, 0, result.Pollers)
+		assert.Contains(t, result.Summary, "No worker is polling task queue orders")
+		mockClient.AssertNotCalled(t, "QueryWorkflow", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Query not answered in time", func(t *testing.T) {
+		mockClient := stackTraceClient(2)
+		mockClient.On("QueryWorkflow", mock.Anything, "wf-1", "run-1", client.QueryTypeStackTrace).
+			Return(nil, serviceerror.NewDeadlineExceeded("context deadline exceeded"))
+
+		result, err := WorkflowStackTraceHandler(context.Background(), mockClient, WorkflowStackTraceArgs{WorkflowID: "wf-1"})
+
+		require.NoError(t, err)
+		assert.Contains(t, result.Summary, "No worker answered the query")
+		assert.Contains(t, result.Summary, "orders")
+	})
+
+	t.Run("Query rejected", func(t *testing.T) {
+		mockClient := stackTraceClient(1)
+		mockClient.On("QueryWorkflow", mock.Anything, "wf-1", "run-1", client.QueryTypeStackTrace).
+			Return(nil, errors.New("workflow task failed"))
+
+		_, err := WorkflowStackTraceHandler(context.Background(), mockClient, WorkflowStackTraceArgs{WorkflowID: "wf-1"})
+
+		assert.ErrorContains(t, err, "failed to query stack trace")
+	})
+}
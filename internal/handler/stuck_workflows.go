@@ -0,0 +1,402 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"go.temporal.io/api/common/v1"
+	"go.temporal.io/api/enums/v1"
+	"go.temporal.io/api/failure/v1"
+	"go.temporal.io/api/history/v1"
+	"go.temporal.io/api/workflow/v1"
+	"go.temporal.io/api/workflowservice/v1"
+	"go.temporal.io/sdk/client"
+)
+
+const (
+	StuckActivityRetrying    = "activity_retrying"
+	StuckWorkflowTaskFailing = "workflow_task_failing"
+	StuckNoPollers           = "no_pollers"
+	StuckNoProgress          = "no_progress"
+	StuckLongTimer           = "long_timer"
+
+	defaultStuckMaxWorkflows         = 200
+	defaultStuckIdleFor              = time.Hour
+	defaultStuckTimerHorizon         = 24 * time.Hour
+	defaultStuckActivityAttempts     = 5
+	defaultStuckWorkflowTaskFailures = 3
+
+	// maxStuckHistoryEvents bounds how many of each workflow's most recent
+	// events a scan reads. Timers started before them are not checked.
+	maxStuckHistoryEvents = 1000
+)
+
+type StuckWorkflowsArgs struct {
+	WorkflowType            string `json:"workflow_type,omitempty" jsonschema:"description=Only consider workflows of this type"`
+	Query                   string `json:"query,omitempty" jsonschema:"description=Additional visibility query clause to narrow the running workflows scanned"`
+	MaxWorkflows            int    `json:"max_workflows,omitempty" jsonschema:"description=Maximum number of running workflows to scan"`
+	IdleFor                 string `json:"idle_for,omitempty" jsonschema:"description=Duration such as 1h without new history events after which a workflow counts as making no progress"`
+	TimerHorizon            string `json:"timer_horizon,omitempty" jsonschema:"description=Duration such as 24h; open timers firing later than this are reported"`
+	MinActivityAttempts     int    `json:"min_activity_attempts,omitempty" jsonschema:"description=Report pending activities on this attempt or later"`
+	MinWorkflowTaskFailures int    `json:"min_workflow_task_failures,omitempty" jsonschema:"description=Report workflows whose workflow task has failed this many times in a row"`
+	Concurrency             int    `json:"-"`
+	Namespace               string `json:"-"`
+}
+
+// StuckReason is one heuristic that matched a workflow.
+type StuckReason struct {
+	Kind   string `json:"kind"`
+	Detail string `json:"detail"`
+}
+
+type StuckWorkflow struct {
+	WorkflowID    string        `json:"workflow_id"`
+	RunID         string        `json:"run_id"`
+	Type          string        `json:"type"`
+	TaskQueue     string        `json:"task_queue,omitempty"`
+	StartTime     string        `json:"start_time,omitempty"`
+	LastEventTime string        `json:"last_event_time,omitempty"`
+	Reasons       []StuckReason `json:"reasons"`
+}
+
+type StuckWorkflowsResponse struct {
+	Workflows []StuckWorkflow `json:"workflows"`
+	Counts    map[string]int  `json:"counts,omitempty"`
+	Errors    []string        `json:"errors,omitempty"`
+	Summary   string          `json:"summary"`
+}
+
+// stuckThresholds are the resolved heuristic limits for one scan.
+type stuckThresholds struct {
+	now                 time.Time
+	idleFor             time.Duration
+	timerHorizon        time.Duration
+	activityAttempts    int32
+	workflowTaskFailure int
+}
+
+// pollerCache remembers the recent workflow pollers of each task queue seen
+// during a scan, so each queue is described once.
+type pollerCache struct {
+	now    time.Time
+	mu     sync.Mutex
+	queues map[string]*queuePollers
+}
+
+type queuePollers struct {
+	once   sync.Once
+	recent int
+	err    error
+}
+
+func (c *pollerCache) recentPollers(ctx context.Context, temporalClient client.Client, taskQueue string) (int, error) {
+	c.mu.Lock()
+	queue, ok := c.queues[taskQueue]
+	if !ok {
+		queue = &queuePollers{}
+		c.queues[taskQueue] = queue
+	}
+	c.mu.Unlock()
+
+	queue.once.Do(func() {
+		resp, err := temporalClient.DescribeTaskQueue(ctx, taskQueue, enums.TASK_QUEUE_TYPE_WORKFLOW)
+		if err != nil {
+			queue.err = fmt.Errorf("failed to describe task queue %s: %w", taskQueue, err)
+			return
+		}
+		for _, p := range resp.GetPollers() {
+			if recentPoller(p, c.now) {
+				queue.recent++
+			}
+		}
+	})
+	return queue.recent, queue.err
+}
+
+// StuckWorkflowsHandler scans running workflows for signs that they are not
+// making progress without having failed: activities retrying many times,
+// workflow tasks failing repeatedly or waiting with no worker polling, long
+// stretches without new events, and timers set far in the future. Each
+// workflow is described and its most recent events read, concurrently.
+func StuckWorkflowsHandler(ctx context.Context, temporalClient client.Client, args StuckWorkflowsArgs) (StuckWorkflowsResponse, error) {
+	thresholds := stuckThresholds{
+		now:                 time.Now(),
+		idleFor:             defaultStuckIdleFor,
+		timerHorizon:        defaultStuckTimerHorizon,
+		activityAttempts:    defaultStuckActivityAttempts,
+		workflowTaskFailure: defaultStuckWorkflowTaskFailures,
+	}
+	var err error
+	if args.IdleFor != "" {
+		if thresholds.idleFor, err = time.ParseDuration(args.IdleFor); err != nil {
+			return StuckWorkflowsResponse{}, fmt.Errorf("invalid idle_for: %w", err)
+		}
+	}
+	if args.TimerHorizon != "" {
+		if thresholds.timerHorizon, err = time.ParseDuration(args.TimerHorizon); err != nil {
+			return StuckWorkflowsResponse{}, fmt.Errorf("invalid timer_horizon: %w", err)
+		}
+	}
+	if args.MinActivityAttempts > 0 {
+		thresholds.activityAttempts = int32(args.MinActivityAttempts)
+	}
+	if args.MinWorkflowTaskFailures > 0 {
+		thresholds.workflowTaskFailure = args.MinWorkflowTaskFailures
+	}
+
+	maxWorkflows := args.MaxWorkflows
+	if maxWorkflows <= 0 {
+		maxWorkflows = defaultStuckMaxWorkflows
+	}
+	var typeClause, extraClause string
+	if args.WorkflowType != "" {
+		typeClause = "WorkflowType = " + quoteQueryValue(args.WorkflowType)
+	}
+	if args.Query != "" {
+		extraClause = "(" + args.Query + ")"
+	}
+	candidates, truncated, err := listExecutions(ctx, temporalClient, buildQuery("ExecutionStatus = 'Running'", typeClause, extraClause), maxWorkflows)
+	if err != nil {
+		return StuckWorkflowsResponse{}, fmt.Errorf("failed to list running workflows: %w", err)
+	}
+
+	concurrency := args.Concurrency
+	if concurrency <= 0 {
+		concurrency = defaultFailedConcurrency
+	}
+	if concurrency > maxFailedConcurrency {
+		concurrency = maxFailedConcurrency
+	}
+
+	cache := &pollerCache{now: thresholds.now, queues: make(map[string]*queuePollers)}
+	results := make([]*StuckWorkflow, len(candidates))
+	errs := make([]error, len(candidates))
+	var wg sync.WaitGroup
+	work := make(chan int)
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for idx := range work {
+				sw, err := inspectStuckWorkflow(ctx, temporalClient, args.Namespace, candidates[idx], thresholds, cache)
+				if err != nil {
+					errs[idx] = err
+				} else if len(sw.Reasons) > 0 {
+					results[idx] = &sw
+				}
+			}
+		}()
+	}
+
+dispatch:
+	for i := range candidates {
+		select {
+		case work <- i:
+		case <-ctx.Done():
+			break dispatch
+		}
+	}
+	close(work)
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return StuckWorkflowsResponse{}, fmt.Errorf("stuck workflow scan interrupted: %w", err)
+	}
+
+	result := StuckWorkflowsResponse{Workflows: []StuckWorkflow{}, Counts: make(map[string]int)}
+	for i, sw := range results {
+		if errs[i] != nil {
+			result.Errors = append(result.Errors, fmt.Sprintf("%s: %v", candidates[i].GetExecution().GetWorkflowId(), errs[i]))
+			continue
+		}
+		if sw == nil {
+			continue
+		}
+		result.Workflows = append(result.Workflows, *sw)
+		for _, reason := range sw.Reasons {
+			result.Counts[reason.Kind]++
+		}
+	}
+
+	result.Summary = fmt.Sprintf("Scanned %d running workflows and found %d that look stuck", len(candidates), len(result.Workflows))
+	if len(result.Counts) > 0 {
+		kinds := make([]string, 0, len(result.Counts))
+		for kind := range result.Counts {
+			kinds = append(kinds, kind)
+		}
+		sort.Strings(kinds)
+		for i, kind := range kinds {
+			kinds[i] = fmt.Sprintf("%s %d", kind, result.Counts[kind])
+		}
+		result.Summary += " (" + strings.Join(kinds, ", ") + ")"
+	}
+	result.Summary += "."
+	if len(result.Errors) > 0 {
+		result.Summary += fmt.Sprintf(" %d workflows could not be inspected.", len(result.Errors))
+	}
+	if truncated {
+		result.Summary += fmt.Sprintf(" Stopped after %d workflows; narrow the workflow type or query to see the rest.", maxWorkflows)
+	}
+	return result, nil
+}
+
+// inspectStuckWorkflow applies the heuristics to one running workflow. The
+// pending activities and workflow task come from describing it; the time of
+// the last event, the last workflow task failure and open timers come from
+// its most recent events, read newest first.
+func inspectStuckWorkflow(ctx context.Context, temporalClient client.Client, namespace string, info *workflow.WorkflowExecutionInfo, t stuckThresholds, cache *pollerCache) (StuckWorkflow, error) {
+	workflowID := info.GetExecution().GetWorkflowId()
+	runID := info.GetExecution().GetRunId()
+	sw := StuckWorkflow{
+		WorkflowID: workflowID,
+		RunID:      runID,
+		Type:       info.GetType().GetName(),
+		TaskQueue:  info.GetTaskQueue(),
+		StartTime:  formatTime(info.GetStartTime()),
+		Reasons:    []StuckReason{},
+	}
+
+	desc, err := temporalClient.DescribeWorkflowExecution(ctx, workflowID, runID)
+	if err != nil {
+		return StuckWorkflow{}, fmt.Errorf("failed to describe workflow: %w", err)
+	}
+	if sw.TaskQueue == "" {
+		sw.TaskQueue = desc.GetWorkflowExecutionInfo().GetTaskQueue()
+	}
+
+	events, err := recentEvents(ctx, temporalClient, namespace, workflowID, runID, maxStuckHistoryEvents)
+	if err != nil {
+		return StuckWorkflow{}, err
+	}
+
+	var lastEventTime *time.Time
+	if len(events) > 0 {
+		lastEventTime = events[0].GetEventTime()
+	}
+	sw.LastEventTime = formatTime(lastEventTime)
+
+	var taskFailures int
+	var taskCompleted bool
+	var lastTaskFailure *failure.Failure
+	timers := make(map[int64]string)
+	timerFires := make(map[int64]time.Time)
+	closedTimers := make(map[int64]bool)
+	for _, evt := range events {
+		switch evt.GetEventType() {
+		case enums.EVENT_TYPE_WORKFLOW_TASK_COMPLETED:
+			taskCompleted = true
+		case enums.EVENT_TYPE_WORKFLOW_TASK_FAILED:
+			if !taskCompleted {
+				taskFailures++
+				if lastTaskFailure == nil {
+					lastTaskFailure = evt.GetWorkflowTaskFailedEventAttributes().GetFailure()
+				}
+			}
+		case enums.EVENT_TYPE_WORKFLOW_TASK_TIMED_OUT:
+			if !taskCompleted {
+				taskFailures++
+			}
+		case enums.EVENT_TYPE_TIMER_STARTED:
+			attrs := evt.GetTimerStartedEventAttributes()
+			if !closedTimers[evt.GetEventId()] && evt.GetEventTime() != nil && attrs.GetStartToFireTimeout() != nil {
+				timers[evt.GetEventId()] = attrs.GetTimerId()
+				timerFires[evt.GetEventId()] = evt.GetEventTime().Add(*attrs.GetStartToFireTimeout())
+			}
+		case enums.EVENT_TYPE_TIMER_FIRED:
+			closedTimers[evt.GetTimerFiredEventAttributes().GetStartedEventId()] = true
+		case enums.EVENT_TYPE_TIMER_CANCELED:
+			closedTimers[evt.GetTimerCanceledEventAttributes().GetStartedEventId()] = true
+		}
+	}
+
+	for _, activity := range desc.GetPendingActivities() {
+		if activity.GetAttempt() < t.activityAttempts {
+			continue
+		}
+		detail := fmt.Sprintf("activity %s (%s) is on attempt %d", activity.GetActivityType().GetName(), activity.GetActivityId(), activity.GetAttempt())
+		if activity.GetMaximumAttempts() > 0 {
+			detail += fmt.Sprintf(" of %d", activity.GetMaximumAttempts())
+		}
+		if msg := activity.GetLastFailure().GetMessage(); msg != "" {
+			detail += "; last failure: " + msg
+		}
+		sw.Reasons = append(sw.Reasons, StuckReason{Kind: StuckActivityRetrying, Detail: detail})
+	}
+
+	// After the first failure the server stops recording workflow task
+	// failures in history and counts attempts on the pending task instead.
+	pendingTask := desc.GetPendingWorkflowTask()
+	if attempts := int(pendingTask.GetAttempt()) - 1; attempts > taskFailures {
+		taskFailures = attempts
+	}
+	if taskFailures >= t.workflowTaskFailure {
+		detail := fmt.Sprintf("the workflow task has failed or timed out %d times in a row", taskFailures)
+		if msg := lastTaskFailure.GetMessage(); msg != "" {
+			detail += "; last failure: " + msg
+		}
+		sw.Reasons = append(sw.Reasons, StuckReason{Kind: StuckWorkflowTaskFailing, Detail: detail})
+	}
+
+	if pendingTask != nil && pendingTask.GetState() == enums.PENDING_WORKFLOW_TASK_STATE_SCHEDULED && sw.TaskQueue != "" {
+		pollers, err := cache.recentPollers(ctx, temporalClient, sw.TaskQueue)
+		if err != nil {
+			return StuckWorkflow{}, err
+		}
+		if pollers == 0 {
+			sw.Reasons = append(sw.Reasons, StuckReason{Kind: StuckNoPollers, Detail: fmt.Sprintf(
+				"a workflow task has been waiting since %s but no worker has polled task queue %s in the last %s",
+				formatTime(pendingTask.GetScheduledTime()), sw.TaskQueue, recentPollerWindow)})
+		}
+	}
+
+	if lastEventTime != nil {
+		if idle := t.now.Sub(*lastEventTime); idle >= t.idleFor {
+			sw.Reasons = append(sw.Reasons, StuckReason{Kind: StuckNoProgress, Detail: fmt.Sprintf("no new history events for %s", idle.Round(time.Second))})
+		}
+	}
+
+	eventIDs := make([]int64, 0, len(timers))
+	for id := range timers {
+		eventIDs = append(eventIDs, id)
+	}
+	sort.Slice(eventIDs, func(i, j int) bool { return eventIDs[i] < eventIDs[j] })
+	for _, id := range eventIDs {
+		fires := timerFires[id]
+		if fires.Sub(t.now) > t.timerHorizon {
+			sw.Reasons = append(sw.Reasons, StuckReason{Kind: StuckLongTimer, Detail: fmt.Sprintf(
+				"timer %s (event %d) fires at %s, in %s", timers[id], id, formatTime(&fires), fires.Sub(t.now).Round(time.Minute))})
+		}
+	}
+
+	return sw, nil
+}
+
+// recentEvents reads up to limit of a run's most recent events, newest first.
+func recentEvents(ctx context.Context, temporalClient client.Client, namespace, workflowID, runID string, limit int) ([]*history.HistoryEvent, error) {
+	var events []*history.HistoryEvent
+	var token []byte
+	for len(events) < limit {
+		resp, err := temporalClient.WorkflowService().GetWorkflowExecutionHistoryReverse(ctx, &workflowservice.GetWorkflowExecutionHistoryReverseRequest{
+			Namespace:       namespace,
+			Execution:       &common.WorkflowExecution{WorkflowId: workflowID, RunId: runID},
+			MaximumPageSize: int32(limit - len(events)),
+			NextPageToken:   token,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to read history: %w", err)
+		}
+		events = append(events, resp.GetHistory().GetEvents()...)
+		token = resp.GetNextPageToken()
+		if len(token) == 0 {
+			break
+		}
+	}
+	if len(events) > limit {
+		events = events[:limit]
+	}
+	return events, nil
+}
@@ -0,0 +1,163 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.temporal.io/api/common/v1"
+	"go.temporal.io/api/enums/v1"
+	"go.temporal.io/api/failure/v1"
+	"go.temporal.io/api/history/v1"
+	"go.temporal.io/api/taskqueue/v1"
+	"go.temporal.io/api/workflow/v1"
+	"go.temporal.io/api/workflowservice/v1"
+)
+
+func eventAt(id int64, eventType enums.EventType, at time.Time) *history.HistoryEvent {
+	return &history.HistoryEvent{EventId: id, EventType: eventType, EventTime: &at}
+}
+
+// pollersSeen describes a workflow task queue whose pollers were last seen at
+// the given times.
+func pollersSeen(times ...time.Time) *workflowservice.DescribeTaskQueueResponse {
+	resp := &workflowservice.DescribeTaskQueueResponse{}
+	for _, lastAccess := range times {
+		lastAccess := lastAccess
+		resp.Pollers = append(resp.Pollers, &taskqueue.PollerInfo{Identity: "worker", LastAccessTime: &lastAccess})
+	}
+	return resp
+}
+
+// stuckRun is a running Order workflow on task queue orders.
+func stuckRun(workflowID string, events ...*history.HistoryEvent) *fakeRun {
+	return &fakeRun{workflowID: workflowID, runID: "run-1", workflowType: "Order", taskQueue: "orders", events: events}
+}
+
+// newStuckClient serves running workflows that are each stuck in a different
+// way, one that is healthy and one that is gone by the time it is described.
+func newStuckClient(t *testing.T, now time.Time) *fakeClient {
+	recent := now.Add(-time.Minute)
+	old := now.Add(-3 * time.Hour)
+	week := 7 * 24 * time.Hour
+
+	taskFailed := eventAt(3, enums.EVENT_TYPE_WORKFLOW_TASK_FAILED, recent)
+	taskFailed.Attributes = &history.HistoryEvent_WorkflowTaskFailedEventAttributes{WorkflowTaskFailedEventAttributes: &history.WorkflowTaskFailedEventAttributes{
+		Failure: &failure.Failure{Message: "nondeterministic workflow"},
+	}}
+	timer := eventAt(5, enums.EVENT_TYPE_TIMER_STARTED, old)
+	timer.Attributes = &history.HistoryEvent_TimerStartedEventAttributes{TimerStartedEventAttributes: &history.TimerStartedEventAttributes{
+		TimerId: "reminder", StartToFireTimeout: &week,
+	}}
+	firedTimer := eventAt(6, enums.EVENT_TYPE_TIMER_STARTED, old)
+	firedTimer.Attributes = &history.HistoryEvent_TimerStartedEventAttributes{TimerStartedEventAttributes: &history.TimerStartedEventAttributes{
+		TimerId: "done", StartToFireTimeout: &week,
+	}}
+	fired := eventAt(7, enums.EVENT_TYPE_TIMER_FIRED, now.Add(-2*time.Hour))
+	fired.Attributes = &history.HistoryEvent_TimerFiredEventAttributes{TimerFiredEventAttributes: &history.TimerFiredEventAttributes{StartedEventId: 6}}
+
+	scheduled := now.Add(-10 * time.Minute)
+	retrying := stuckRun("retrying", eventAt(1, enums.EVENT_TYPE_WORKFLOW_EXECUTION_STARTED, recent))
+	retrying.pendingActivities = []*workflow.PendingActivityInfo{
+		{ActivityId: "1", ActivityType: &common.ActivityType{Name: "ChargeCard"}, Attempt: 12, LastFailure: &failure.Failure{Message: "card declined"}},
+		{ActivityId: "2", ActivityType: &common.ActivityType{Name: "SendEmail"}, Attempt: 2},
+	}
+	badDeploy := stuckRun("bad-deploy", eventAt(1, enums.EVENT_TYPE_WORKFLOW_EXECUTION_STARTED, recent), eventAt(2, enums.EVENT_TYPE_WORKFLOW_TASK_SCHEDULED, recent), taskFailed)
+	badDeploy.pendingWorkflowTask = &workflow.PendingWorkflowTaskInfo{State: enums.PENDING_WORKFLOW_TASK_STATE_STARTED, Attempt: 5}
+	noWorker := stuckRun("no-worker", eventAt(1, enums.EVENT_TYPE_WORKFLOW_EXECUTION_STARTED, recent))
+	noWorker.pendingWorkflowTask = &workflow.PendingWorkflowTaskInfo{State: enums.PENDING_WORKFLOW_TASK_STATE_SCHEDULED, ScheduledTime: &scheduled, Attempt: 1}
+	gone := stuckRun("gone")
+	gone.err = errors.New("workflow not found")
+
+	c := newFakeClient(t,
+		retrying,
+		badDeploy,
+		noWorker,
+		stuckRun("sleeping", eventAt(1, enums.EVENT_TYPE_WORKFLOW_EXECUTION_STARTED, old), timer, firedTimer, fired),
+		stuckRun("healthy", eventAt(1, enums.EVENT_TYPE_WORKFLOW_EXECUTION_STARTED, recent)),
+		gone,
+	)
+	c.service.taskQueues[taskQueueKey{"orders", enums.TASK_QUEUE_TYPE_WORKFLOW}] = pollersSeen(now.Add(-10 * time.Minute))
+	return c
+}
+
+func TestStuckWorkflowsHandler(t *testing.T) {
+	now := time.Now()
+	c := newStuckClient(t, now)
+
+	result, err := StuckWorkflowsHandler(context.Background(), c, StuckWorkflowsArgs{WorkflowType: "Order", Query: "CustomerId = 'c-1'"})
+
+	require.NoError(t, err)
+	assert.Equal(t, "ExecutionStatus = 'Running' AND WorkflowType = 'Order' AND (CustomerId = 'c-1')", c.listQuery)
+
+	byID := make(map[string]StuckWorkflow)
+	for _, sw := range result.Workflows {
+		byID[sw.WorkflowID] = sw
+	}
+	require.Len(t, byID, 4)
+	assert.NotContains(t, byID, "healthy")
+
+	assert.Equal(t, []StuckReason{{Kind: StuckActivityRetrying, Detail: "activity ChargeCard (1) is on attempt 12; last failure: card declined"}}, byID["retrying"].Reasons)
+	assert.Equal(t, []StuckReason{{Kind: StuckWorkflowTaskFailing, Detail: "the workflow task has failed or timed out 4 times in a row; last failure: nondeterministic workflow"}}, byID["bad-deploy"].Reasons)
+	require.Len(t, byID["no-worker"].Reasons, 1)
+	assert.Equal(t, StuckNoPollers, byID["no-worker"].Reasons[0].Kind)
+	assert.Contains(t, byID["no-worker"].Reasons[0].Detail, "no worker has polled task queue orders in the last 2m0s", "the only poller was last seen 10 minutes ago")
+
+	sleeping := byID["sleeping"].Reasons
+	require.Len(t, sleeping, 2)
+	assert.Equal(t, StuckReason{Kind: StuckNoProgress, Detail: "no new history events for 2h0m0s"}, sleeping[0])
+	assert.Equal(t, StuckLongTimer, sleeping[1].Kind)
+	assert.Contains(t, sleeping[1].Detail, "timer reminder (event 5) fires at")
+
+	assert.Equal(t, map[string]int{StuckActivityRetrying: 1, StuckWorkflowTaskFailing: 1, StuckNoPollers: 1, StuckNoProgress: 1, StuckLongTimer: 1}, result.Counts)
+	assert.Equal(t, []string{"gone: failed to describe workflow: workflow not found"}, result.Errors)
+	assert.Equal(t, "Scanned 6 running workflows and found 4 that look stuck (activity_retrying 1, long_timer 1, no_pollers 1, no_progress 1, workflow_task_failing 1)."+
+		" 1 workflows could not be inspected.", result.Summary)
+}
+
+func TestStuckWorkflowsHandler_Thresholds(t *testing.T) {
+	now := time.Now()
+	c := newStuckClient(t, now)
+	c.service.taskQueues[taskQueueKey{"orders", enums.TASK_QUEUE_TYPE_WORKFLOW}] = pollersSeen(now.Add(-time.Minute), now.Add(-10*time.Minute))
+
+	result, err := StuckWorkflowsHandler(context.Background(), c, StuckWorkflowsArgs{
+		IdleFor: "4h", TimerHorizon: "720h", MinActivityAttempts: 2, MinWorkflowTaskFailures: 10,
+	})
+
+	require.NoError(t, err)
+	require.Len(t, result.Workflows, 1)
+	assert.Equal(t, "retrying", result.Workflows[0].WorkflowID)
+	assert.Len(t, result.Workflows[0].Reasons, 2, "both activities are at or past attempt 2")
+	assert.Len(t, c.service.taskQueueRequests, 1)
+
+	_, err = StuckWorkflowsHandler(context.Background(), c, StuckWorkflowsArgs{IdleFor: "soon"})
+	assert.ErrorContains(t, err, "invalid idle_for")
+}
+
+func TestStuckWorkflowsHandler_ReadsRecentEvents(t *testing.T) {
+	now := time.Now()
+	c := newStuckClient(t, now)
+	week := 7 * 24 * time.Hour
+	timer := eventAt(2, enums.EVENT_TYPE_TIMER_STARTED, now.Add(-3*time.Hour))
+	timer.Attributes = &history.HistoryEvent_TimerStartedEventAttributes{TimerStartedEventAttributes: &history.TimerStartedEventAttributes{
+		TimerId: "reminder", StartToFireTimeout: &week,
+	}}
+	events := []*history.HistoryEvent{eventAt(1, enums.EVENT_TYPE_WORKFLOW_EXECUTION_STARTED, now.Add(-3*time.Hour)), timer}
+	for id := int64(3); id <= 1500; id++ {
+		events = append(events, eventAt(id, enums.EVENT_TYPE_WORKFLOW_EXECUTION_SIGNALED, now.Add(-time.Minute)))
+	}
+	sleeping, err := c.run("sleeping", "")
+	require.NoError(t, err)
+	sleeping.events = events
+
+	result, err := StuckWorkflowsHandler(context.Background(), c, StuckWorkflowsArgs{})
+
+	require.NoError(t, err)
+	for _, sw := range result.Workflows {
+		assert.NotEqual(t, "sleeping", sw.WorkflowID, "the last event is recent and the timer is older than the events read")
+	}
+	assert.Equal(t, maxStuckHistoryEvents, c.service.eventsRead["sleeping"])
+}
@@ -0,0 +1,149 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.temporal.io/api/enums/v1"
+	"go.temporal.io/api/taskqueue/v1"
+	"go.temporal.io/api/workflowservice/v1"
+	"go.temporal.io/sdk/client"
+)
+
+// recentPollerWindow is how long ago a poller may last have been seen and
+// still count as a running worker. The server keeps pollers in the list for
+// several minutes after they stop.
+const recentPollerWindow = 2 * time.Minute
+
+type TaskQueueStatusArgs struct {
+	TaskQueue  string `json:"task_queue,omitempty" jsonschema:"description=The task queue to check"`
+	WorkflowID string `json:"workflow_id,omitempty" jsonschema:"description=Check the task queue of this workflow instead of naming one"`
+	RunID      string `json:"run_id,omitempty" jsonschema:"description=Optional run ID of the workflow"`
+	Namespace  string `json:"-"`
+}
+
+type Poller struct {
+	Identity       string  `json:"identity"`
+	LastAccessTime string  `json:"last_access_time,omitempty"`
+	RatePerSecond  float64 `json:"rate_per_second,omitempty"`
+	BuildID        string  `json:"build_id,omitempty"`
+	Recent         bool    `json:"recent"`
+}
+
+type TaskQueueInfo struct {
+	Type             string   `json:"type"`
+	Pollers          []Poller `json:"pollers"`
+	RecentPollers    int      `json:"recent_pollers"`
+	BacklogCountHint *int64   `json:"backlog_count_hint,omitempty"`
+	DispatchRate     float64  `json:"dispatch_rate_per_second,omitempty"`
+	NoRecentPollers  bool     `json:"no_recent_pollers,omitempty"`
+}
+
+type TaskQueueStatusResponse struct {
+	TaskQueue  string          `json:"task_queue"`
+	WorkflowID string          `json:"workflow_id,omitempty"`
+	RunID      string          `json:"run_id,omitempty"`
+	Status     string          `json:"status,omitempty"`
+	Queues     []TaskQueueInfo `json:"queues"`
+	Summary    string          `json:"summary"`
+}
+
+// TaskQueueStatusHandler describes the workflow and activity task queues of
+// a task queue name, listing their pollers and the backlog when the server
+// reports it. Given a workflow instead of a name it checks the workflow's
+// task queue.
+func TaskQueueStatusHandler(ctx context.Context, temporalClient client.Client, args TaskQueueStatusArgs) (TaskQueueStatusResponse, error) {
+	result := TaskQueueStatusResponse{TaskQueue: args.TaskQueue, WorkflowID: args.WorkflowID}
+	if args.WorkflowID != "" {
+		resp, err := temporalClient.DescribeWorkflowExecution(ctx, args.WorkflowID, args.RunID)
+		if err != nil {
+			return TaskQueueStatusResponse{}, fmt.Errorf("failed to describe workflow: %w", err)
+		}
+		info := resp.GetWorkflowExecutionInfo()
+		result.RunID = info.GetExecution().GetRunId()
+		result.Status = info.GetStatus().String()
+		if result.TaskQueue == "" {
+			result.TaskQueue = info.GetTaskQueue()
+		}
+	}
+	if result.TaskQueue == "" {
+		return TaskQueueStatusResponse{}, fmt.Errorf("task_queue or workflow_id is required")
+	}
+
+	now := time.Now()
+	for _, queueType := range []enums.TaskQueueType{enums.TASK_QUEUE_TYPE_WORKFLOW, enums.TASK_QUEUE_TYPE_ACTIVITY} {
+		resp, err := temporalClient.WorkflowService().DescribeTaskQueue(ctx, &workflowservice.DescribeTaskQueueRequest{
+			Namespace:              args.Namespace,
+			TaskQueue:              &taskqueue.TaskQueue{Name: result.TaskQueue, Kind: enums.TASK_QUEUE_KIND_NORMAL},
+			TaskQueueType:          queueType,
+			IncludeTaskQueueStatus: true,
+		})
+		if err != nil {
+			return TaskQueueStatusResponse{}, fmt.Errorf("failed to describe %s task queue %s: %w", strings.ToLower(queueType.String()), result.TaskQueue, err)
+		}
+		result.Queues = append(result.Queues, newTaskQueueInfo(queueType, resp, now))
+	}
+
+	result.Summary = taskQueueSummary(result)
+	return result, nil
+}
+
+func newTaskQueueInfo(queueType enums.TaskQueueType, resp *workflowservice.DescribeTaskQueueResponse, now time.Time) TaskQueueInfo {
+	info := TaskQueueInfo{Type: queueType.String(), Pollers: []Poller{}}
+	for _, p := range resp.GetPollers() {
+		poller := Poller{
+			Identity:       p.GetIdentity(),
+			LastAccessTime: formatTime(p.GetLastAccessTime()),
+			RatePerSecond:  p.GetRatePerSecond(),
+			BuildID:        p.GetWorkerVersioningId().GetWorkerBuildId(),
+		}
+		if recentPoller(p, now) {
+			poller.Recent = true
+			info.RecentPollers++
+		}
+		info.Pollers = append(info.Pollers, poller)
+	}
+	if status := resp.GetTaskQueueStatus(); status != nil {
+		backlog := status.GetBacklogCountHint()
+		info.BacklogCountHint = &backlog
+		info.DispatchRate = status.GetRatePerSecond()
+	}
+	info.NoRecentPollers = info.RecentPollers == 0
+	return info
+}
+
+// recentPoller reports whether p polled within recentPollerWindow of now.
+func recentPoller(p *taskqueue.PollerInfo, now time.Time) bool {
+	t := p.GetLastAccessTime()
+	return t != nil && now.Sub(*t) <= recentPollerWindow
+}
+
+func taskQueueSummary(result TaskQueueStatusResponse) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Task queue %s", result.TaskQueue)
+	if result.WorkflowID != "" {
+		fmt.Fprintf(&b, " (used by %s workflow %s)", result.Status, result.WorkflowID)
+	}
+	b.WriteString(":")
+	var idle []string
+	for _, queue := range result.Queues {
+		fmt.Fprintf(&b, " %s queue has %d recent pollers", strings.ToLower(queue.Type), queue.RecentPollers)
+		if stale := len(queue.Pollers) - queue.RecentPollers; stale > 0 {
+			fmt.Fprintf(&b, " and %d not seen for over %s", stale, recentPollerWindow)
+		}
+		if queue.BacklogCountHint != nil {
+			fmt.Fprintf(&b, ", backlog about %d", *queue.BacklogCountHint)
+		}
+		b.WriteString(";")
+		if queue.NoRecentPollers {
+			idle = append(idle, strings.ToLower(queue.Type))
+		}
+	}
+	summary := strings.TrimSuffix(b.String(), ";") + "."
+	if len(idle) > 0 {
+		summary += fmt.Sprintf(" No worker is polling for %s tasks, so they wait until one starts.", strings.Join(idle, " or "))
+	}
+	return summary
+}
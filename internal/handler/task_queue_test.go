@@ -0,0 +1,100 @@
+package handler
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.temporal.io/api/common/v1"
+	"go.temporal.io/api/enums/v1"
+	"go.temporal.io/api/taskqueue/v1"
+	"go.temporal.io/api/workflow/v1"
+	"go.temporal.io/api/workflowservice/v1"
+	"go.temporal.io/sdk/mocks"
+)
+
+// taskQueueService serves task queue orders with one recent and one stale
+// workflow poller and an activity queue that nobody polls.
+func taskQueueService(t *testing.T) *fakeService {
+	recent := time.Now().Add(-10 * time.Second)
+	stale := time.Now().Add(-10 * time.Minute)
+	service := newFakeService(t)
+	service.taskQueues[taskQueueKey{"orders", enums.TASK_QUEUE_TYPE_WORKFLOW}] = &workflowservice.DescribeTaskQueueResponse{
+		Pollers: []*taskqueue.PollerInfo{
+			{Identity: "worker-1", LastAccessTime: &recent, RatePerSecond: 100000, WorkerVersioningId: &taskqueue.VersionId{WorkerBuildId: "v2"}},
+			{Identity: "worker-0", LastAccessTime: &stale},
+		},
+		TaskQueueStatus: &taskqueue.TaskQueueStatus{BacklogCountHint: 0, RatePerSecond: 12.5},
+	}
+	service.taskQueues[taskQueueKey{"orders", enums.TASK_QUEUE_TYPE_ACTIVITY}] = &workflowservice.DescribeTaskQueueResponse{
+		TaskQueueStatus: &taskqueue.TaskQueueStatus{BacklogCountHint: 37},
+	}
+	return service
+}
+
+func TestTaskQueueStatusHandler(t *testing.T) {
+	t.Run("Derives the task queue from a workflow", func(t *testing.T) {
+		service := taskQueueService(t)
+		mockClient := &mocks.Client{}
+		mockClient.On("WorkflowService").Return(service)
+		mockClient.On("DescribeWorkflowExecution", mock.Anything, "wf-1", "").Return(&workflowservice.DescribeWorkflowExecutionResponse{
+			WorkflowExecutionInfo: &workflow.WorkflowExecutionInfo{
+				Execution: &common.WorkflowExecution{WorkflowId: "wf-1", RunId: "run-1"},
+				Status:    enums.WORKFLOW_EXECUTION_STATUS_RUNNING,
+				TaskQueue: "orders",
+			},
+		}, nil)
+
+		result, err := TaskQueueStatusHandler(context.Background(), mockClient, TaskQueueStatusArgs{WorkflowID: "wf-1", Namespace: "shop"})
+
+		require.NoError(t, err)
+		require.Len(t, service.taskQueueRequests, 2)
+		assert.Equal(t, "shop", service.taskQueueRequests[0].GetNamespace())
+		assert.Equal(t, "orders", service.taskQueueRequests[0].GetTaskQueue().GetName())
+		assert.True(t, service.taskQueueRequests[0].GetIncludeTaskQueueStatus())
+		assert.Equal(t, "orders", result.TaskQueue)
+		assert.Equal(t, "run-1", result.RunID)
+
+		require.Len(t, result.Queues, 2)
+		workflowQueue := result.Queues[0]
+		assert.Equal(t, "Workflow", workflowQueue.Type)
+		assert.Equal(t, 1, workflowQueue.RecentPollers)
+		assert.False(t, workflowQueue.NoRecentPollers)
+		assert.Equal(t, "worker-1", workflowQueue.Pollers[0].Identity)
+		assert.Equal(t, "v2", workflowQueue.Pollers[0].BuildID)
+		assert.True(t, workflowQueue.Pollers[0].Recent)
+		assert.False(t, workflowQueue.Pollers[1].Recent)
+		assert.Equal(t, 12.5, workflowQueue.DispatchRate)
+
+		activityQueue := result.Queues[1]
+		assert.True(t, activityQueue.NoRecentPollers)
+		require.NotNil(t, activityQueue.BacklogCountHint)
+		assert.Equal(t, int64(37), *activityQueue.BacklogCountHint)
+
+		assert.Equal(t, "Task queue orders (used by Running workflow wf-1): workflow queue has 1 recent pollers and 1 not seen for over 2m0s, backlog about 0;"+
+			" activity queue has 0 recent pollers, backlog about 37. No worker is polling for activity tasks, so they wait until one starts.", result.Summary)
+	})
+
+	t.Run("Named task queue", func(t *testing.T) {
+		service := taskQueueService(t)
+		mockClient := &mocks.Client{}
+		mockClient.On("WorkflowService").Return(service)
+
+		result, err := TaskQueueStatusHandler(context.Background(), mockClient, TaskQueueStatusArgs{TaskQueue: "payments"})
+
+		require.NoError(t, err)
+		assert.Equal(t, "payments", service.taskQueueRequests[1].GetTaskQueue().GetName())
+		assert.Equal(t, enums.TASK_QUEUE_TYPE_ACTIVITY, service.taskQueueRequests[1].GetTaskQueueType())
+		assert.Empty(t, result.WorkflowID)
+		mockClient.AssertNotCalled(t, "DescribeWorkflowExecution", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Needs a task queue or workflow", func(t *testing.T) {
+		_, err := TaskQueueStatusHandler(context.Background(), &mocks.Client{}, TaskQueueStatusArgs{})
+
+		assert.ErrorContains(t, err, "task_queue or workflow_id is required")
+	})
+}
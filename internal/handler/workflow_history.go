@@ -2,16 +2,40 @@ package handler
 
 import (
 	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"strings"
 
-	"go.temporal.io/api/enums/v1"
-	"go.temporal.io/api/history/v1"
 	"go.temporal.io/sdk/client"
 )
 
+const (
+	defaultHistoryMaxEvents = 200
+	defaultHistoryMaxBytes  = 100000
+)
+
 type WorkflowHistoryArgs struct {
-	WorkflowID string `json:"workflow_id" jsonschema:"required,description=The ID of the workflow to retrieve"`
-	RunID      string `json:"run_id,omitempty" jsonschema:"description=Optional run ID of the workflow"`
+	WorkflowID   string `json:"workflow_id" jsonschema:"required,description=The ID of the workflow to retrieve"`
+	RunID        string `json:"run_id,omitempty" jsonschema:"description=Optional run ID of the workflow"`
+	MaxEvents    int    `json:"max_events,omitempty" jsonschema:"description=Maximum number of events to return (default 200)"`
+	MaxBytes     int    `json:"max_bytes,omitempty" jsonschema:"description=Maximum size in bytes of the returned events (default 100000)"`
+	Cursor       string `json:"cursor,omitempty" jsonschema:"description=next_cursor or prev_cursor from a previous response"`
+	StartEventID int64  `json:"start_event_id,omitempty" jsonschema:"description=Only return events with this ID or later"`
+	EndEventID   int64  `json:"end_event_id,omitempty" jsonschema:"description=Only return events with this ID or earlier"`
+
+	IncludeEventTypes []string `json:"include_event_types,omitempty" jsonschema:"description=Only return these event types or categories (activity, signal, timer, child_workflow, workflow_task, update, marker)"`
+	ExcludeEventTypes []string `json:"exclude_event_types,omitempty" jsonschema:"description=Leave out these event types or categories"`
+	StartTime         string   `json:"start_time,omitempty" jsonschema:"description=RFC3339 time; only return events at or after it"`
+	EndTime           string   `json:"end_time,omitempty" jsonschema:"description=RFC3339 time; only return events at or before it"`
+	ActivityType      string   `json:"activity_type,omitempty" jsonschema:"description=Only return lifecycle events of activities of this type"`
+	SignalName        string   `json:"signal_name,omitempty" jsonschema:"description=Only return events for signals with this name"`
+
+	View string `json:"view,omitempty" jsonschema:"enum=events,enum=activities,description=events (default) or activities to fold each activity's lifecycle into one record"`
+
+	FollowContinueAsNew bool `json:"follow_continue_as_new,omitempty" jsonschema:"description=Read every run of the continue-as-new chain and merge their events"`
+	MaxRuns             int  `json:"max_runs,omitempty" jsonschema:"description=Maximum number of runs to read when following continue-as-new (default 10)"`
 }
 
 type HistoryResponse struct {
@@ -20,39 +44,328 @@ type HistoryResponse struct {
 	Summary      string  `json:"summary"`
 	Instructions string  `json:"instructionsForReadingEvents"`
 	Events       []Event `json:"events"`
+	NextCursor   string  `json:"next_cursor,omitempty"`
+	PrevCursor   string  `json:"prev_cursor,omitempty"`
+
+	Activities []ActivityExecution `json:"activities,omitempty"`
+	Runs       []RunSummary        `json:"runs,omitempty"`
+}
+
+// historyCursor marks a position in a run's history. A forward cursor returns
+// events after After; a backward cursor returns the events just before Before.
+type historyCursor struct {
+	WorkflowID string `json:"w"`
+	RunID      string `json:"r,omitempty"`
+	View       string `json:"v,omitempty"`
+	Chain      bool   `json:"c,omitempty"`
+	After      int64  `json:"a,omitempty"`
+	Before     int64  `json:"b,omitempty"`
+}
+
+func encodeCursor(c historyCursor) string {
+	b, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func decodeCursor(s string) (historyCursor, error) {
+	var c historyCursor
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return c, errors.New("invalid cursor")
+	}
+	if err := json.Unmarshal(b, &c); err != nil {
+		return c, errors.New("invalid cursor")
+	}
+	return c, nil
+}
+
+// historyPage accumulates the items returned for one request within the
+// event and byte budgets. Items are events, or activity executions in the
+// activity view.
+type historyPage[T any] struct {
+	maxEvents int
+	maxBytes  int
+	items     []T
+	sizes     []int
+	bytes     int
+}
+
+func newHistoryPage[T any](args WorkflowHistoryArgs) *historyPage[T] {
+	page := &historyPage[T]{maxEvents: args.MaxEvents, maxBytes: args.MaxBytes}
+	if page.maxEvents <= 0 {
+		page.maxEvents = defaultHistoryMaxEvents
+	}
+	if page.maxBytes <= 0 {
+		page.maxBytes = defaultHistoryMaxBytes
+	}
+	return page
+}
+
+func (p *historyPage[T]) fits(size int) bool {
+	// Always return at least one item, even if it alone exceeds max_bytes.
+	return len(p.items) == 0 || (len(p.items) < p.maxEvents && p.bytes+size <= p.maxBytes)
+}
+
+func (p *historyPage[T]) push(item T, size int) {
+	p.items = append(p.items, item)
+	p.sizes = append(p.sizes, size)
+	p.bytes += size
+}
+
+// shift drops the oldest item to make room at the end of the page.
+func (p *historyPage[T]) shift() {
+	p.bytes -= p.sizes[0]
+	p.items = p.items[1:]
+	p.sizes = p.sizes[1:]
 }
 
 func GetWorkflowHistoryHandler(ctx context.Context, temporalClient client.Client, args WorkflowHistoryArgs) (HistoryResponse, error) {
 	runID := args.RunID
-	iter := temporalClient.GetWorkflowHistory(ctx, args.WorkflowID, runID, false, enums.HISTORY_EVENT_FILTER_TYPE_ALL_EVENT)
+	view := args.View
+	switch view {
+	case "":
+		view = HistoryViewEvents
+	case HistoryViewEvents, HistoryViewActivities:
+	default:
+		return HistoryResponse{}, fmt.Errorf("invalid view %q (expected %s or %s)", view, HistoryViewEvents, HistoryViewActivities)
+	}
+
+	var cursor historyCursor
+	if args.Cursor != "" {
+		var err error
+		if cursor, err = decodeCursor(args.Cursor); err != nil {
+			return HistoryResponse{}, err
+		}
+		if cursor.WorkflowID != args.WorkflowID {
+			return HistoryResponse{}, fmt.Errorf("cursor belongs to workflow %s, not %s", cursor.WorkflowID, args.WorkflowID)
+		}
+		if cursor.View == "" {
+			cursor.View = HistoryViewEvents
+		}
+		if cursor.View != view {
+			return HistoryResponse{}, fmt.Errorf("cursor was issued for the %s view, not %s", cursor.View, view)
+		}
+		if cursor.Chain != args.FollowContinueAsNew {
+			return HistoryResponse{}, errors.New("cursor does not match follow_continue_as_new; pass the same arguments as the previous call")
+		}
+		if runID == "" && !cursor.Chain {
+			runID = cursor.RunID
+		}
+	}
 
-	var rawEvents []*history.HistoryEvent
-	for iter.HasNext() {
-		evt, err := iter.Next()
+	filter, err := newHistoryFilter(args)
+	if err != nil {
+		return HistoryResponse{}, err
+	}
+	if args.FollowContinueAsNew && (view == HistoryViewActivities || args.StartEventID > 0 || args.EndEventID > 0) {
+		return HistoryResponse{}, errors.New("follow_continue_as_new cannot be combined with the activities view or an event ID range")
+	}
+	if runID == "" && !args.FollowContinueAsNew {
+		// Pin the current run so that the response and its cursors name the
+		// run that was actually read.
+		if runID, err = currentRunID(ctx, temporalClient, args.WorkflowID); err != nil {
+			return HistoryResponse{}, err
+		}
+	}
+	if view == HistoryViewActivities {
+		return getActivityView(ctx, temporalClient, args, runID, cursor, filter)
+	}
+
+	page := newHistoryPage[Event](args)
+
+	// Events are addressed by position: their event ID, or when following a
+	// chain of runs, their event ID offset by the events of earlier runs.
+	runs := []RunSummary{{RunID: runID}}
+	truncatedChain := false
+	if args.FollowContinueAsNew {
+		maxRuns := args.MaxRuns
+		if maxRuns <= 0 {
+			maxRuns = defaultMaxRuns
+		}
+		if runs, truncatedChain, err = discoverRunChain(ctx, temporalClient, args.WorkflowID, args.RunID, maxRuns); err != nil {
+			return HistoryResponse{}, err
+		}
+	}
+	chain := newRunChainIterator(ctx, temporalClient, args.WorkflowID, runs)
+	after, before := cursor.After, cursor.Before
+	if cursor.Chain {
+		var ok bool
+		if after > 0 {
+			after, ok = chain.positionOf(cursor.RunID, after)
+		} else {
+			before, ok = chain.positionOf(cursor.RunID, before)
+		}
+		if !ok {
+			return HistoryResponse{}, fmt.Errorf("cursor refers to run %s, which is no longer within the followed runs", cursor.RunID)
+		}
+	}
+
+	// [lo, hi] is the range of positions this page may draw from; [first,
+	// last] is the range the caller asked for, used to tell whether anything
+	// before or after the page was elided.
+	first, last := args.StartEventID, args.EndEventID
+	if first < 1 {
+		first = 1
+	}
+	lo, hi := first, last
+	if after >= lo {
+		lo = after + 1
+	}
+	if before > 0 && (hi == 0 || before-1 < hi) {
+		hi = before - 1
+	}
+	backward := before > 0
+
+	var (
+		read         int
+		elidedBefore int
+		moreAfter    bool
+		complete     = true
+	)
+	for chain.HasNext() {
+		evt, err := chain.Next()
 		if err != nil {
 			return HistoryResponse{}, fmt.Errorf("failed reading history: %w", err)
 		}
-		rawEvents = append(rawEvents, evt)
+		read++
+		filter.observe(evt)
+
+		id := chain.position(evt)
+		if (last > 0 && id > last) || filter.afterWindow(evt) {
+			complete = false
+			break
+		}
+		if id < first || !filter.match(evt) {
+			continue
+		}
+		if id < lo {
+			elidedBefore++
+			continue
+		}
+		if hi > 0 && id > hi {
+			// Only reachable when paging backward: the events from the cursor
+			// onwards were already returned.
+			moreAfter = true
+			complete = false
+			break
+		}
+
+		formatted, keep := FormatEvent(evt, false)
+		if !keep {
+			continue
+		}
+		if args.FollowContinueAsNew {
+			formatted.RunID = chain.runID()
+		}
+		size := jsonSize(formatted)
+
+		if backward {
+			for !page.fits(size) {
+				page.shift()
+				elidedBefore++
+			}
+			page.push(formatted, size)
+			continue
+		}
+		if !page.fits(size) {
+			moreAfter = true
+			complete = false
+			break
+		}
+		page.push(formatted, size)
+	}
+
+	resp := HistoryResponse{
+		WorkflowID: args.WorkflowID,
+		RunID:      runID,
+		Events:     page.items,
+	}
+	if resp.Events == nil {
+		resp.Events = []Event{}
+	}
+	if args.FollowContinueAsNew {
+		resp.RunID = runs[len(runs)-1].RunID
+		resp.Runs = runs
 	}
 
-	formattedList := make([]Event, 0, len(rawEvents))
-	for _, e := range rawEvents {
-		if formatted, keep := FormatEvent(e, false); keep {
-			formattedList = append(formattedList, formatted)
+	if n := len(page.items); n > 0 {
+		if elidedBefore > 0 {
+			firstEvent := page.items[0]
+			resp.PrevCursor = encodeCursor(historyCursor{WorkflowID: args.WorkflowID, RunID: cursorRunID(firstEvent, runID), Chain: args.FollowContinueAsNew, Before: firstEvent.EventID})
+		}
+		if moreAfter {
+			lastEvent := page.items[n-1]
+			resp.NextCursor = encodeCursor(historyCursor{WorkflowID: args.WorkflowID, RunID: cursorRunID(lastEvent, runID), Chain: args.FollowContinueAsNew, After: lastEvent.EventID})
 		}
 	}
 
-	finalRunID := runID
-	if len(rawEvents) > 0 && rawEvents[0].GetWorkflowExecutionStartedEventAttributes() != nil {
-		finalRunID = rawEvents[0].GetWorkflowExecutionStartedEventAttributes().GetOriginalExecutionRunId()
+	resp.Summary = historySummary(read, complete, page.items, elidedBefore, moreAfter)
+	if args.FollowContinueAsNew {
+		resp.Summary = chainSummary(runs, truncatedChain) + " " + resp.Summary
 	}
+	if filter.active() {
+		resp.Summary += " Only events matching the requested filters are included; pass the same filters with a cursor."
+	}
+	return resp, nil
+}
 
-	return HistoryResponse{
-		WorkflowID: args.WorkflowID,
-		RunID:      finalRunID,
-		Summary:    fmt.Sprintf("Workflow has %d events. We are examining %d events.", len(rawEvents), len(formattedList)),
-		Events:     formattedList,
-	}, nil
+// currentRunID returns the ID of the workflow's current run.
+func currentRunID(ctx context.Context, temporalClient client.Client, workflowID string) (string, error) {
+	resp, err := temporalClient.DescribeWorkflowExecution(ctx, workflowID, "")
+	if err != nil {
+		return "", fmt.Errorf("failed to describe workflow: %w", err)
+	}
+	return resp.GetWorkflowExecutionInfo().GetExecution().GetRunId(), nil
+}
+
+// cursorRunID is the run a cursor positioned at e refers to: the event's own
+// run when following a chain, otherwise the run being read.
+func cursorRunID(e Event, runID string) string {
+	if e.RunID != "" {
+		return e.RunID
+	}
+	return runID
+}
+
+func chainSummary(runs []RunSummary, truncated bool) string {
+	var total int64
+	for _, r := range runs {
+		total += r.EventCount
+	}
+	s := fmt.Sprintf("Followed %d runs with %d events in total; events are tagged with their run_id.", len(runs), total)
+	if truncated {
+		s += " The chain has more runs than max_runs; runs beyond the cap were not read."
+	}
+	return s
 }
 
+func jsonSize(v interface{}) int {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return 0
+	}
+	return len(b)
+}
 
+func historySummary(read int, complete bool, events []Event, elidedBefore int, moreAfter bool) string {
+	var b strings.Builder
+	if complete {
+		fmt.Fprintf(&b, "Workflow has %d events. We are examining %d events.", read, len(events))
+	} else {
+		fmt.Fprintf(&b, "Workflow has at least %d events. We are examining %d events.", read, len(events))
+	}
+	if len(events) == 0 || (elidedBefore == 0 && !moreAfter) {
+		return b.String()
+	}
+
+	fmt.Fprintf(&b, " Returned events %d-%d to stay within the size budget;", events[0].EventID, events[len(events)-1].EventID)
+	var elided []string
+	if elidedBefore > 0 {
+		elided = append(elided, fmt.Sprintf("%d earlier events were elided (use prev_cursor)", elidedBefore))
+	}
+	if moreAfter {
+		elided = append(elided, "later events were elided (use next_cursor)")
+	}
+	b.WriteString(" " + strings.Join(elided, " and ") + ".")
+	return b.String()
+}
@@ -8,8 +8,11 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"go.temporal.io/api/common/v1"
 	"go.temporal.io/api/enums/v1"
 	"go.temporal.io/api/history/v1"
+	"go.temporal.io/api/workflow/v1"
+	"go.temporal.io/api/workflowservice/v1"
 	"go.temporal.io/sdk/client"
 )
 
@@ -19,6 +22,11 @@ type MockTemporalClient struct {
 	mock.Mock
 }
 
+func (m *MockTemporalClient) DescribeWorkflowExecution(ctx context.Context, workflowID, runID string) (*workflowservice.DescribeWorkflowExecutionResponse, error) {
+	args := m.Called(ctx, workflowID, runID)
+	return args.Get(0).(*workflowservice.DescribeWorkflowExecutionResponse), args.Error(1)
+}
+
 func (m *MockTemporalClient) GetWorkflowHistory(ctx context.Context, workflowID string, runID string, isLongPoll bool, filterType enums.HistoryEventFilterType) client.HistoryEventIterator {
 	args := m.Called(ctx, workflowID, runID, isLongPoll, filterType)
 	return args.Get(0).(client.HistoryEventIterator)
@@ -86,7 +94,7 @@ func TestGetWorkflowHistoryHandler(t *testing.T) {
 
 		assert.NoError(t, err)
 		assert.Equal(t, workflowID, result.WorkflowID)
-		assert.Equal(t, originalRunID, result.RunID)
+		assert.Equal(t, runID, result.RunID)
 		assert.Len(t, result.Events, 2)
 		assert.Equal(t, int64(1), result.Events[0].EventID)
 		assert.Equal(t, "WorkflowExecutionStarted", result.Events[0].Type)
@@ -158,6 +166,7 @@ func TestGetWorkflowHistoryHandler(t *testing.T) {
 		mockIterator := new(MockHistoryEventIterator)
 
 		workflowID := "test-workflow-id"
+		runID := "current-run-id"
 		originalRunID := "original-run-id"
 
 		now := time.Now()
@@ -176,7 +185,10 @@ func TestGetWorkflowHistoryHandler(t *testing.T) {
 		mockIterator.On("Next").Return(event1, nil).Once()
 		mockIterator.On("HasNext").Return(false).Once()
 
-		mockClient.On("GetWorkflowHistory", mock.Anything, workflowID, "", false, enums.HISTORY_EVENT_FILTER_TYPE_ALL_EVENT).Return(mockIterator)
+		mockClient.On("DescribeWorkflowExecution", mock.Anything, workflowID, "").Return(&workflowservice.DescribeWorkflowExecutionResponse{
+			WorkflowExecutionInfo: &workflow.WorkflowExecutionInfo{Execution: &common.WorkflowExecution{WorkflowId: workflowID, RunId: runID}},
+		}, nil)
+		mockClient.On("GetWorkflowHistory", mock.Anything, workflowID, runID, false, enums.HISTORY_EVENT_FILTER_TYPE_ALL_EVENT).Return(mockIterator)
 
 		args := WorkflowHistoryArgs{
 			WorkflowID: workflowID,
@@ -186,7 +198,7 @@ func TestGetWorkflowHistoryHandler(t *testing.T) {
 
 		assert.NoError(t, err)
 		assert.Equal(t, workflowID, result.WorkflowID)
-		assert.Equal(t, originalRunID, result.RunID)
+		assert.Equal(t, runID, result.RunID, "the run that was read, not the run it was reset from")
 		assert.Len(t, result.Events, 1)
 		assert.Equal(t, "Workflow has 1 events. We are examining 1 events.", result.Summary)
 
@@ -194,3 +206,115 @@ func TestGetWorkflowHistoryHandler(t *testing.T) {
 		mockIterator.AssertExpectations(t)
 	})
 }
+
+func signalEvents(n int) []*history.HistoryEvent {
+	now := time.Now()
+	events := []*history.HistoryEvent{{
+		EventId:   1,
+		EventTime: &now,
+		EventType: enums.EVENT_TYPE_WORKFLOW_EXECUTION_STARTED,
+		Attributes: &history.HistoryEvent_WorkflowExecutionStartedEventAttributes{
+			WorkflowExecutionStartedEventAttributes: &history.WorkflowExecutionStartedEventAttributes{OriginalExecutionRunId: "run-1"},
+		},
+	}}
+	for i := 2; i <= n; i++ {
+		events = append(events, &history.HistoryEvent{
+			EventId:   int64(i),
+			EventTime: &now,
+			EventType: enums.EVENT_TYPE_WORKFLOW_EXECUTION_SIGNALED,
+			Attributes: &history.HistoryEvent_WorkflowExecutionSignaledEventAttributes{
+				WorkflowExecutionSignaledEventAttributes: &history.WorkflowExecutionSignaledEventAttributes{SignalName: "tick"},
+			},
+		})
+	}
+	return events
+}
+
+func eventIDs(events []Event) []int64 {
+	ids := make([]int64, 0, len(events))
+	for _, e := range events {
+		ids = append(ids, e.EventID)
+	}
+	return ids
+}
+
+func TestGetWorkflowHistoryHandler_Paging(t *testing.T) {
+	mockClient := newFakeClient(t, &fakeRun{workflowID: "wf-1", runID: "run-1", events: signalEvents(5)})
+	ctx := context.Background()
+
+	page1, err := GetWorkflowHistoryHandler(ctx, mockClient, WorkflowHistoryArgs{WorkflowID: "wf-1", MaxEvents: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{1, 2}, eventIDs(page1.Events))
+	assert.Empty(t, page1.PrevCursor)
+	assert.NotEmpty(t, page1.NextCursor)
+	assert.Equal(t, "Workflow has at least 3 events. We are examining 2 events. Returned events 1-2 to stay within the size budget; later events were elided (use next_cursor).", page1.Summary)
+
+	page2, err := GetWorkflowHistoryHandler(ctx, mockClient, WorkflowHistoryArgs{WorkflowID: "wf-1", MaxEvents: 2, Cursor: page1.NextCursor})
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{3, 4}, eventIDs(page2.Events))
+	assert.NotEmpty(t, page2.PrevCursor)
+	assert.NotEmpty(t, page2.NextCursor)
+
+	page3, err := GetWorkflowHistoryHandler(ctx, mockClient, WorkflowHistoryArgs{WorkflowID: "wf-1", MaxEvents: 2, Cursor: page2.NextCursor})
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{5}, eventIDs(page3.Events))
+	assert.Empty(t, page3.NextCursor)
+	assert.Equal(t, "Workflow has 5 events. We are examining 1 events. Returned events 5-5 to stay within the size budget; 4 earlier events were elided (use prev_cursor).", page3.Summary)
+
+	back, err := GetWorkflowHistoryHandler(ctx, mockClient, WorkflowHistoryArgs{WorkflowID: "wf-1", MaxEvents: 2, Cursor: page3.PrevCursor})
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{3, 4}, eventIDs(back.Events))
+	assert.NotEmpty(t, back.PrevCursor)
+	assert.NotEmpty(t, back.NextCursor)
+	assert.Equal(t, "run-1", back.RunID)
+}
+
+func TestGetWorkflowHistoryHandler_ResetRun(t *testing.T) {
+	// A reset run's started event names the run it was reset from.
+	events := signalEvents(3)
+	events[0].GetWorkflowExecutionStartedEventAttributes().OriginalExecutionRunId = "base-run"
+	mockClient := newFakeClient(t, &fakeRun{workflowID: "wf-1", runID: "reset-run", events: events})
+	ctx := context.Background()
+
+	page1, err := GetWorkflowHistoryHandler(ctx, mockClient, WorkflowHistoryArgs{WorkflowID: "wf-1", MaxEvents: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, "reset-run", page1.RunID)
+
+	cursor, err := decodeCursor(page1.NextCursor)
+	assert.NoError(t, err)
+	assert.Equal(t, "reset-run", cursor.RunID)
+
+	page2, err := GetWorkflowHistoryHandler(ctx, mockClient, WorkflowHistoryArgs{WorkflowID: "wf-1", MaxEvents: 2, Cursor: page1.NextCursor})
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{3}, eventIDs(page2.Events))
+	assert.Equal(t, "reset-run", page2.RunID)
+	assert.Equal(t, []string{"reset-run", "reset-run"}, mockClient.reads)
+}
+
+func TestGetWorkflowHistoryHandler_EventRangeAndBytes(t *testing.T) {
+	mockClient := newFakeClient(t, &fakeRun{workflowID: "wf-1", runID: "run-1", events: signalEvents(10)})
+	ctx := context.Background()
+
+	result, err := GetWorkflowHistoryHandler(ctx, mockClient, WorkflowHistoryArgs{WorkflowID: "wf-1", StartEventID: 4, EndEventID: 6})
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{4, 5, 6}, eventIDs(result.Events))
+	assert.Empty(t, result.PrevCursor)
+	assert.Empty(t, result.NextCursor)
+
+	// A budget smaller than a single event still returns one event per page.
+	result, err = GetWorkflowHistoryHandler(ctx, mockClient, WorkflowHistoryArgs{WorkflowID: "wf-1", StartEventID: 4, EndEventID: 6, MaxBytes: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{4}, eventIDs(result.Events))
+	assert.NotEmpty(t, result.NextCursor)
+}
+
+func TestGetWorkflowHistoryHandler_InvalidCursor(t *testing.T) {
+	mockClient := newFakeClient(t, &fakeRun{workflowID: "wf-1", runID: "run-1", events: signalEvents(3)})
+
+	_, err := GetWorkflowHistoryHandler(context.Background(), mockClient, WorkflowHistoryArgs{WorkflowID: "wf-1", Cursor: "not a cursor"})
+	assert.EqualError(t, err, "invalid cursor")
+
+	cursor := encodeCursor(historyCursor{WorkflowID: "wf-2", After: 1})
+	_, err = GetWorkflowHistoryHandler(context.Background(), mockClient, WorkflowHistoryArgs{WorkflowID: "wf-1", Cursor: cursor})
+	assert.EqualError(t, err, "cursor belongs to workflow wf-2, not wf-1")
+}
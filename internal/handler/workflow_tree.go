@@ -0,0 +1,287 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"go.temporal.io/api/enums/v1"
+	"go.temporal.io/sdk/client"
+)
+
+const (
+	defaultTreeMaxDepth = 3
+	maxTreeDepth        = 10
+	defaultTreeMaxNodes = 200
+	treeConcurrency     = 10
+)
+
+type WorkflowTreeArgs struct {
+	WorkflowID string `json:"workflow_id" jsonschema:"required,description=The ID of the workflow at the root of the tree"`
+	RunID      string `json:"run_id,omitempty" jsonschema:"description=Optional run ID of the root workflow"`
+	MaxDepth   int    `json:"max_depth,omitempty" jsonschema:"description=How many levels of children to fetch (default 3, max 10)"`
+	MaxNodes   int    `json:"max_nodes,omitempty" jsonschema:"description=Maximum number of workflows to fetch (default 200)"`
+}
+
+// WorkflowTreeNode is one workflow in the tree. Nodes that were not fetched,
+// because of the depth or node limits, carry the status their parent's
+// history recorded and are marked Unexplored.
+type WorkflowTreeNode struct {
+	WorkflowID string              `json:"workflow_id"`
+	RunID      string              `json:"run_id,omitempty"`
+	Type       string              `json:"type,omitempty"`
+	Status     string              `json:"status"`
+	StartTime  string              `json:"start_time,omitempty"`
+	CloseTime  string              `json:"close_time,omitempty"`
+	Failure    *Failure            `json:"failure,omitempty"`
+	Error      string              `json:"error,omitempty"`
+	Unexplored bool                `json:"unexplored,omitempty"`
+	Children   []*WorkflowTreeNode `json:"children,omitempty"`
+}
+
+// FailingLeaf is a failed workflow none of whose children failed, i.e. where
+// a failure in the tree originated.
+type FailingLeaf struct {
+	WorkflowID string   `json:"workflow_id"`
+	RunID      string   `json:"run_id,omitempty"`
+	Type       string   `json:"type,omitempty"`
+	Status     string   `json:"status"`
+	Error      string   `json:"error,omitempty"`
+	Path       []string `json:"path"`
+}
+
+type WorkflowTreeResponse struct {
+	Root          *WorkflowTreeNode `json:"root"`
+	FailingLeaves []FailingLeaf     `json:"failing_leaves,omitempty"`
+	Summary       string            `json:"summary"`
+}
+
+// WorkflowTreeHandler walks a workflow's children level by level, describing
+// each one and reading its history to find its own children.
+func WorkflowTreeHandler(ctx context.Context, temporalClient client.Client, args WorkflowTreeArgs) (WorkflowTreeResponse, error) {
+	maxDepth := args.MaxDepth
+	if maxDepth <= 0 {
+		maxDepth = defaultTreeMaxDepth
+	}
+	if maxDepth > maxTreeDepth {
+		maxDepth = maxTreeDepth
+	}
+	maxNodes := args.MaxNodes
+	if maxNodes <= 0 {
+		maxNodes = defaultTreeMaxNodes
+	}
+
+	root := &WorkflowTreeNode{WorkflowID: args.WorkflowID, RunID: args.RunID}
+	if err := loadTreeNode(ctx, temporalClient, root); err != nil {
+		return WorkflowTreeResponse{}, err
+	}
+
+	fetched, unexplored := 1, 0
+	level := root.Children
+	for depth := 1; len(level) > 0; depth++ {
+		var batch []*WorkflowTreeNode
+		for _, node := range level {
+			if depth <= maxDepth && fetched+len(batch) < maxNodes {
+				batch = append(batch, node)
+			} else {
+				unexplored++
+			}
+		}
+		if err := loadTreeNodes(ctx, temporalClient, batch); err != nil {
+			return WorkflowTreeResponse{}, err
+		}
+		fetched += len(batch)
+
+		level = nil
+		for _, node := range batch {
+			level = append(level, node.Children...)
+		}
+	}
+
+	leaves := failingLeaves(root, nil)
+	summary := fmt.Sprintf("Fetched %d workflows in the tree.", fetched)
+	if unexplored > 0 {
+		summary += fmt.Sprintf(" %d children were not fetched because of the depth or node limit; their status is as recorded by their parent.", unexplored)
+	}
+	if len(leaves) > 0 {
+		var ids []string
+		for _, l := range leaves {
+			ids = append(ids, fmt.Sprintf("%s (%s)", l.WorkflowID, l.Status))
+		}
+		summary += fmt.Sprintf(" Failures originate in %d workflows: %s.", len(leaves), strings.Join(ids, ", "))
+	}
+
+	return WorkflowTreeResponse{Root: root, FailingLeaves: leaves, Summary: summary}, nil
+}
+
+// loadTreeNodes loads a level of the tree with bounded concurrency. Errors
+// loading individual children are recorded on the node rather than failing
+// the whole tree.
+func loadTreeNodes(ctx context.Context, temporalClient client.Client, nodes []*WorkflowTreeNode) error {
+	var wg sync.WaitGroup
+	work := make(chan *WorkflowTreeNode)
+	for i := 0; i < treeConcurrency && i < len(nodes); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for node := range work {
+				if err := loadTreeNode(ctx, temporalClient, node); err != nil {
+					node.Error = err.Error()
+				}
+			}
+		}()
+	}
+
+dispatch:
+	for _, node := range nodes {
+		select {
+		case work <- node:
+		case <-ctx.Done():
+			break dispatch
+		}
+	}
+	close(work)
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("workflow tree walk interrupted: %w", err)
+	}
+	return nil
+}
+
+// loadTreeNode fills in a node from describe and collects its children from
+// the child workflow events in its history, adding any pending children that
+// describe reports but the history does not.
+func loadTreeNode(ctx context.Context, temporalClient client.Client, node *WorkflowTreeNode) error {
+	resp, err := temporalClient.DescribeWorkflowExecution(ctx, node.WorkflowID, node.RunID)
+	if err != nil {
+		return fmt.Errorf("failed to describe workflow %s: %w", node.WorkflowID, err)
+	}
+	info := resp.GetWorkflowExecutionInfo()
+	node.RunID = info.GetExecution().GetRunId()
+	node.Type = info.GetType().GetName()
+	node.Status = info.GetStatus().String()
+	node.StartTime = formatTime(info.GetStartTime())
+	node.CloseTime = formatTime(info.GetCloseTime())
+	node.Unexplored = false
+
+	var children []*WorkflowTreeNode
+	byInitiatedID := make(map[int64]*WorkflowTreeNode)
+	childFor := func(initiatedID int64) *WorkflowTreeNode {
+		if child, ok := byInitiatedID[initiatedID]; ok {
+			return child
+		}
+		// The initiated event was not seen, e.g. a child started before a reset.
+		child := &WorkflowTreeNode{Unexplored: true}
+		byInitiatedID[initiatedID] = child
+		children = append(children, child)
+		return child
+	}
+
+	iter := temporalClient.GetWorkflowHistory(ctx, node.WorkflowID, node.RunID, false, enums.HISTORY_EVENT_FILTER_TYPE_ALL_EVENT)
+	for iter.HasNext() {
+		evt, err := iter.Next()
+		if err != nil {
+			return fmt.Errorf("failed reading history of %s: %w", node.WorkflowID, err)
+		}
+
+		switch {
+		case evt.GetStartChildWorkflowExecutionInitiatedEventAttributes() != nil:
+			attrs := evt.GetStartChildWorkflowExecutionInitiatedEventAttributes()
+			child := &WorkflowTreeNode{
+				WorkflowID: attrs.GetWorkflowId(),
+				Type:       attrs.GetWorkflowType().GetName(),
+				Status:     "Initiated",
+				Unexplored: true,
+			}
+			byInitiatedID[evt.GetEventId()] = child
+			children = append(children, child)
+		case evt.GetStartChildWorkflowExecutionFailedEventAttributes() != nil:
+			attrs := evt.GetStartChildWorkflowExecutionFailedEventAttributes()
+			child := childFor(attrs.GetInitiatedEventId())
+			child.WorkflowID = attrs.GetWorkflowId()
+			child.Status = "StartFailed"
+			child.Error = enumName(attrs.GetCause())
+		case evt.GetChildWorkflowExecutionStartedEventAttributes() != nil:
+			attrs := evt.GetChildWorkflowExecutionStartedEventAttributes()
+			child := childFor(attrs.GetInitiatedEventId())
+			child.WorkflowID = attrs.GetWorkflowExecution().GetWorkflowId()
+			child.RunID = attrs.GetWorkflowExecution().GetRunId()
+			child.Type = attrs.GetWorkflowType().GetName()
+			child.Status = "Running"
+			child.StartTime = formatTime(evt.GetEventTime())
+		case evt.GetChildWorkflowExecutionCompletedEventAttributes() != nil:
+			closeChild(childFor(evt.GetChildWorkflowExecutionCompletedEventAttributes().GetInitiatedEventId()), "Completed", formatTime(evt.GetEventTime()), nil)
+		case evt.GetChildWorkflowExecutionFailedEventAttributes() != nil:
+			attrs := evt.GetChildWorkflowExecutionFailedEventAttributes()
+			closeChild(childFor(attrs.GetInitiatedEventId()), "Failed", formatTime(evt.GetEventTime()), formatFailure(attrs.GetFailure()))
+		case evt.GetChildWorkflowExecutionCanceledEventAttributes() != nil:
+			closeChild(childFor(evt.GetChildWorkflowExecutionCanceledEventAttributes().GetInitiatedEventId()), "Canceled", formatTime(evt.GetEventTime()), nil)
+		case evt.GetChildWorkflowExecutionTimedOutEventAttributes() != nil:
+			closeChild(childFor(evt.GetChildWorkflowExecutionTimedOutEventAttributes().GetInitiatedEventId()), "TimedOut", formatTime(evt.GetEventTime()), nil)
+		case evt.GetChildWorkflowExecutionTerminatedEventAttributes() != nil:
+			closeChild(childFor(evt.GetChildWorkflowExecutionTerminatedEventAttributes().GetInitiatedEventId()), "Terminated", formatTime(evt.GetEventTime()), nil)
+		case evt.GetWorkflowExecutionFailedEventAttributes() != nil:
+			node.Failure = formatFailure(evt.GetWorkflowExecutionFailedEventAttributes().GetFailure())
+		case evt.GetWorkflowExecutionTimedOutEventAttributes() != nil:
+			node.Failure = &Failure{Message: "workflow execution timed out", Type: "timeout"}
+		case evt.GetWorkflowExecutionTerminatedEventAttributes() != nil:
+			node.Failure = &Failure{Message: evt.GetWorkflowExecutionTerminatedEventAttributes().GetReason(), Type: "terminated"}
+		}
+	}
+
+	known := make(map[string]bool, len(children))
+	for _, child := range children {
+		known[child.WorkflowID] = true
+	}
+	for _, pending := range resp.GetPendingChildren() {
+		if known[pending.GetWorkflowId()] {
+			continue
+		}
+		children = append(children, &WorkflowTreeNode{
+			WorkflowID: pending.GetWorkflowId(),
+			RunID:      pending.GetRunId(),
+			Type:       pending.GetWorkflowTypeName(),
+			Status:     "Running",
+			Unexplored: true,
+		})
+	}
+
+	node.Children = children
+	return nil
+}
+
+func closeChild(child *WorkflowTreeNode, status, closeTime string, failure *Failure) {
+	child.Status = status
+	child.CloseTime = closeTime
+	child.Failure = failure
+}
+
+func nodeFailed(n *WorkflowTreeNode) bool {
+	switch n.Status {
+	case "Failed", "TimedOut", "Terminated", "StartFailed":
+		return true
+	}
+	return false
+}
+
+// failingLeaves returns the failed nodes below and including n that have no
+// failed children, along with the path of workflow IDs leading to each.
+func failingLeaves(n *WorkflowTreeNode, path []string) []FailingLeaf {
+	path = append(append([]string(nil), path...), n.WorkflowID)
+
+	var leaves []FailingLeaf
+	for _, child := range n.Children {
+		leaves = append(leaves, failingLeaves(child, path)...)
+	}
+	if len(leaves) > 0 || !nodeFailed(n) {
+		return leaves
+	}
+
+	leaf := FailingLeaf{WorkflowID: n.WorkflowID, RunID: n.RunID, Type: n.Type, Status: n.Status, Error: n.Error, Path: path}
+	if root := n.Failure.rootCause(); root != nil {
+		leaf.Error = root.Message
+	}
+	return []FailingLeaf{leaf}
+}
@@ -0,0 +1,162 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.temporal.io/api/common/v1"
+	"go.temporal.io/api/enums/v1"
+	"go.temporal.io/api/failure/v1"
+	"go.temporal.io/api/history/v1"
+	"go.temporal.io/api/workflow/v1"
+)
+
+// childEvents builds the events of a parent starting each child and, for
+// children listed in failed, the child failing with that message.
+func childEvents(children []string, failed map[string]string) []*history.HistoryEvent {
+	var events []*history.HistoryEvent
+	for _, id := range children {
+		initiated := int64(len(events) + 1)
+		events = append(events,
+			&history.HistoryEvent{
+				EventId: initiated, EventType: enums.EVENT_TYPE_START_CHILD_WORKFLOW_EXECUTION_INITIATED,
+				Attributes: &history.HistoryEvent_StartChildWorkflowExecutionInitiatedEventAttributes{StartChildWorkflowExecutionInitiatedEventAttributes: &history.StartChildWorkflowExecutionInitiatedEventAttributes{
+					WorkflowId: id, WorkflowType: &common.WorkflowType{Name: "Type-" + id},
+				}},
+			},
+			&history.HistoryEvent{
+				EventId: initiated + 1, EventType: enums.EVENT_TYPE_CHILD_WORKFLOW_EXECUTION_STARTED,
+				Attributes: &history.HistoryEvent_ChildWorkflowExecutionStartedEventAttributes{ChildWorkflowExecutionStartedEventAttributes: &history.ChildWorkflowExecutionStartedEventAttributes{
+					InitiatedEventId:  initiated,
+					WorkflowExecution: &common.WorkflowExecution{WorkflowId: id, RunId: id + "-run"},
+					WorkflowType:      &common.WorkflowType{Name: "Type-" + id},
+				}},
+			},
+		)
+		if msg, ok := failed[id]; ok {
+			events = append(events, &history.HistoryEvent{
+				EventId: initiated + 2, EventType: enums.EVENT_TYPE_CHILD_WORKFLOW_EXECUTION_FAILED,
+				Attributes: &history.HistoryEvent_ChildWorkflowExecutionFailedEventAttributes{ChildWorkflowExecutionFailedEventAttributes: &history.ChildWorkflowExecutionFailedEventAttributes{
+					InitiatedEventId: initiated,
+					Failure:          &failure.Failure{Message: msg},
+				}},
+			})
+		}
+	}
+	return events
+}
+
+func workflowFailedEvent(msg string) *history.HistoryEvent {
+	return &history.HistoryEvent{
+		EventId: 100, EventType: enums.EVENT_TYPE_WORKFLOW_EXECUTION_FAILED,
+		Attributes: &history.HistoryEvent_WorkflowExecutionFailedEventAttributes{WorkflowExecutionFailedEventAttributes: &history.WorkflowExecutionFailedEventAttributes{
+			Failure: &failure.Failure{Message: msg},
+		}},
+	}
+}
+
+// treeRun is a workflow of the tree, with its run ID and type named after
+// its workflow ID.
+func treeRun(workflowID string, status enums.WorkflowExecutionStatus, events []*history.HistoryEvent) *fakeRun {
+	return &fakeRun{workflowID: workflowID, runID: workflowID + "-run", workflowType: "Type-" + workflowID, status: status, events: events}
+}
+
+func newTreeClient(t *testing.T) *fakeClient {
+	failed := enums.WORKFLOW_EXECUTION_STATUS_FAILED
+	a := treeRun("a", enums.WORKFLOW_EXECUTION_STATUS_RUNNING, nil)
+	a.pendingChildren = []*workflow.PendingChildExecutionInfo{
+		{WorkflowId: "a1", RunId: "a1-run", WorkflowTypeName: "Type-a1"},
+	}
+	return newFakeClient(t,
+		treeRun("root", failed, append(childEvents([]string{"a", "b"}, map[string]string{"b": "child b failed"}), workflowFailedEvent("child b failed"))),
+		a,
+		treeRun("a1", enums.WORKFLOW_EXECUTION_STATUS_RUNNING, nil),
+		treeRun("b", failed, append(childEvents([]string{"b1", "b2"}, map[string]string{"b2": "charge declined"}), workflowFailedEvent("child b2 failed"))),
+		treeRun("b1", enums.WORKFLOW_EXECUTION_STATUS_COMPLETED, nil),
+		treeRun("b2", failed, []*history.HistoryEvent{workflowFailedEvent("charge declined")}),
+	)
+}
+
+func TestWorkflowTreeHandler(t *testing.T) {
+	result, err := WorkflowTreeHandler(context.Background(), newTreeClient(t), WorkflowTreeArgs{WorkflowID: "root"})
+
+	require.NoError(t, err)
+	root := result.Root
+	assert.Equal(t, "Failed", root.Status)
+	assert.Equal(t, "Type-root", root.Type)
+	require.Len(t, root.Children, 2)
+
+	a := root.Children[0]
+	assert.Equal(t, "a", a.WorkflowID)
+	assert.Equal(t, "Running", a.Status)
+	assert.False(t, a.Unexplored)
+	require.Len(t, a.Children, 1, "pending children from describe are included")
+	assert.Equal(t, "a1", a.Children[0].WorkflowID)
+	assert.Equal(t, "a1-run", a.Children[0].RunID)
+
+	b := root.Children[1]
+	assert.Equal(t, "b-run", b.RunID)
+	require.NotNil(t, b.Failure)
+	assert.Equal(t, "child b2 failed", b.Failure.Message)
+	require.Len(t, b.Children, 2)
+	assert.Equal(t, "Completed", b.Children[0].Status)
+
+	require.Len(t, result.FailingLeaves, 1)
+	leaf := result.FailingLeaves[0]
+	assert.Equal(t, "b2", leaf.WorkflowID)
+	assert.Equal(t, "charge declined", leaf.Error)
+	assert.Equal(t, []string{"root", "b", "b2"}, leaf.Path)
+	assert.Contains(t, result.Summary, "Fetched 6 workflows")
+	assert.Contains(t, result.Summary, "b2 (Failed)")
+}
+
+func TestWorkflowTreeHandler_DepthLimit(t *testing.T) {
+	result, err := WorkflowTreeHandler(context.Background(), newTreeClient(t), WorkflowTreeArgs{WorkflowID: "root", MaxDepth: 1})
+
+	require.NoError(t, err)
+	b := result.Root.Children[1]
+	assert.False(t, b.Unexplored)
+	require.Len(t, b.Children, 2)
+
+	b2 := b.Children[1]
+	assert.True(t, b2.Unexplored)
+	assert.Equal(t, "Failed", b2.Status, "status comes from the parent's history")
+	require.NotNil(t, b2.Failure)
+	assert.Equal(t, "charge declined", b2.Failure.Message)
+	assert.Empty(t, b2.Children)
+
+	assert.Contains(t, result.Summary, "Fetched 3 workflows")
+	assert.Contains(t, result.Summary, "3 children were not fetched")
+}
+
+func TestWorkflowTreeHandler_NodeLimit(t *testing.T) {
+	result, err := WorkflowTreeHandler(context.Background(), newTreeClient(t), WorkflowTreeArgs{WorkflowID: "root", MaxNodes: 2})
+
+	require.NoError(t, err)
+	assert.False(t, result.Root.Children[0].Unexplored)
+	assert.True(t, result.Root.Children[1].Unexplored)
+	assert.Contains(t, result.Summary, "Fetched 2 workflows")
+}
+
+func TestWorkflowTreeHandler_ChildErrorsAreRecorded(t *testing.T) {
+	c := newTreeClient(t)
+	gone, err := c.run("b1", "")
+	require.NoError(t, err)
+	gone.err = errors.New("workflow not found")
+
+	result, err := WorkflowTreeHandler(context.Background(), c, WorkflowTreeArgs{WorkflowID: "root"})
+
+	require.NoError(t, err)
+	b1 := result.Root.Children[1].Children[0]
+	assert.Contains(t, b1.Error, "failed to describe workflow b1")
+	assert.Equal(t, "Running", b1.Status, "status falls back to what the parent recorded")
+}
+
+func TestWorkflowTreeHandler_RootNotFound(t *testing.T) {
+	_, err := WorkflowTreeHandler(context.Background(), newTreeClient(t), WorkflowTreeArgs{WorkflowID: "missing"})
+
+	assert.Error(t, err)
+}
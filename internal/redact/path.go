@@ -0,0 +1,153 @@
+package redact
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// segment is one step of a path. An empty name with index -1 matches every
+// child; recursive segments also match at any depth below the current node.
+type segment struct {
+	name      string
+	index     int
+	recursive bool
+}
+
+func (s segment) wildcard() bool {
+	return s.name == "" && s.index < 0
+}
+
+// path is a parsed JSONPath expression. The supported subset is $, .name,
+// ['name'], [n], .*, [*] and recursive descent with ..name or ..*.
+type path []segment
+
+func parsePath(expr string) (path, error) {
+	if !strings.HasPrefix(expr, "$") {
+		return nil, errors.New("path must start with $")
+	}
+	rest := expr[1:]
+
+	var p path
+	for rest != "" {
+		seg := segment{index: -1}
+		switch {
+		case strings.HasPrefix(rest, ".."):
+			seg.recursive = true
+			rest = rest[2:]
+			if strings.HasPrefix(rest, "[") {
+				var err error
+				if seg, rest, err = parseBracket(rest, true); err != nil {
+					return nil, err
+				}
+				p = append(p, seg)
+				continue
+			}
+			seg.name, rest = parseName(rest)
+		case strings.HasPrefix(rest, "."):
+			seg.name, rest = parseName(rest[1:])
+		case strings.HasPrefix(rest, "["):
+			var err error
+			if seg, rest, err = parseBracket(rest, false); err != nil {
+				return nil, err
+			}
+			p = append(p, seg)
+			continue
+		default:
+			return nil, fmt.Errorf("unexpected %q", rest)
+		}
+
+		if seg.name == "" {
+			return nil, errors.New("empty field name")
+		}
+		if seg.name == "*" {
+			seg.name = ""
+		}
+		p = append(p, seg)
+	}
+
+	if len(p) == 0 {
+		return nil, errors.New("path selects the whole document")
+	}
+	return p, nil
+}
+
+func parseName(s string) (string, string) {
+	end := strings.IndexAny(s, ".[")
+	if end < 0 {
+		return s, ""
+	}
+	return s[:end], s[end:]
+}
+
+func parseBracket(s string, recursive bool) (segment, string, error) {
+	end := strings.Index(s, "]")
+	if end < 0 {
+		return segment{}, "", errors.New("unterminated [")
+	}
+	inner, rest := s[1:end], s[end+1:]
+	seg := segment{index: -1, recursive: recursive}
+
+	switch {
+	case inner == "*":
+	case len(inner) >= 2 && (inner[0] == '\'' || inner[0] == '"') && inner[len(inner)-1] == inner[0]:
+		seg.name = inner[1 : len(inner)-1]
+		if seg.name == "" {
+			return segment{}, "", errors.New("empty field name")
+		}
+	default:
+		i, err := strconv.Atoi(inner)
+		if err != nil || i < 0 {
+			return segment{}, "", fmt.Errorf("invalid index %q", inner)
+		}
+		seg.index = i
+	}
+	return seg, rest, nil
+}
+
+// apply replaces every value selected by the path with Placeholder and returns
+// the updated document and the number of values replaced.
+func (p path) apply(v interface{}) (interface{}, int) {
+	if len(p) == 0 {
+		if s, ok := v.(string); ok && s == Placeholder {
+			return v, 0
+		}
+		return Placeholder, 1
+	}
+
+	seg, next := p[0], p[1:]
+	count := 0
+
+	switch val := v.(type) {
+	case map[string]interface{}:
+		for k, child := range val {
+			if seg.index < 0 && (seg.wildcard() || seg.name == k) {
+				var n int
+				child, n = next.apply(child)
+				val[k] = child
+				count += n
+			}
+			if seg.recursive {
+				var n int
+				val[k], n = p.apply(child)
+				count += n
+			}
+		}
+	case []interface{}:
+		for i, child := range val {
+			if seg.wildcard() || seg.index == i {
+				var n int
+				child, n = next.apply(child)
+				val[i] = child
+				count += n
+			}
+			if seg.recursive {
+				var n int
+				val[i], n = p.apply(child)
+				count += n
+			}
+		}
+	}
+	return v, count
+}
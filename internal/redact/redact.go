@@ -0,0 +1,225 @@
+// Package redact masks sensitive values in tool responses before they are
+// returned to the client.
+package redact
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/robryanx/mcp-temporal-server/internal/config"
+)
+
+// Placeholder replaces values matched by a path rule.
+const Placeholder = "[REDACTED]"
+
+// builtinPatterns are the patterns that can be enabled by name.
+var builtinPatterns = map[string]string{
+	"email": `[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`,
+	"card":  `\b(?:\d[ -]?){12,18}\d\b`,
+	"token": `(?i:bearer\s+[A-Za-z0-9\-._~+/]+=*)` +
+		`|\beyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+` +
+		`|\b(?:sk|pk|rk)_(?:live|test)_[A-Za-z0-9]{10,}\b` +
+		`|\bgh[pousr]_[A-Za-z0-9]{36,}\b` +
+		`|\bxox[abprs]-[A-Za-z0-9-]{10,}\b` +
+		`|\bAKIA[0-9A-Z]{16}\b`,
+}
+
+type pattern struct {
+	name string
+	re   *regexp.Regexp
+}
+
+// Redactor masks values in JSON documents according to path rules and regex
+// patterns. A nil or empty Redactor leaves documents untouched.
+type Redactor struct {
+	paths    []path
+	patterns []pattern
+}
+
+// fileConfig is the format of the optional redaction config file.
+type fileConfig struct {
+	Paths    []string          `json:"paths"`
+	Builtins []string          `json:"builtin_patterns"`
+	Patterns map[string]string `json:"patterns"`
+}
+
+// New builds a Redactor from the configured paths, built-in pattern names and
+// the optional config file.
+func New(cfg config.RedactionConfig) (*Redactor, error) {
+	paths := append([]string(nil), cfg.Paths...)
+	builtins := append([]string(nil), cfg.Patterns...)
+	custom := map[string]string{}
+
+	if cfg.File != "" {
+		b, err := os.ReadFile(cfg.File)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read redaction config: %w", err)
+		}
+		var fc fileConfig
+		if err := json.Unmarshal(b, &fc); err != nil {
+			return nil, fmt.Errorf("invalid redaction config %s: %w", cfg.File, err)
+		}
+		paths = append(paths, fc.Paths...)
+		builtins = append(builtins, fc.Builtins...)
+		custom = fc.Patterns
+	}
+
+	r := &Redactor{}
+	for _, expr := range paths {
+		p, err := parsePath(expr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid redaction path %q: %w", expr, err)
+		}
+		r.paths = append(r.paths, p)
+	}
+
+	for _, name := range builtins {
+		expr, ok := builtinPatterns[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown redaction pattern %q (expected email, card or token)", name)
+		}
+		r.patterns = append(r.patterns, pattern{name: name, re: regexp.MustCompile(expr)})
+	}
+
+	// Sort custom patterns so they are applied in a stable order.
+	names := make([]string, 0, len(custom))
+	for name := range custom {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		re, err := regexp.Compile(custom[name])
+		if err != nil {
+			return nil, fmt.Errorf("invalid redaction pattern %q: %w", name, err)
+		}
+		r.patterns = append(r.patterns, pattern{name: name, re: re})
+	}
+
+	return r, nil
+}
+
+// Enabled reports whether any rules are configured.
+func (r *Redactor) Enabled() bool {
+	return r != nil && (len(r.paths) > 0 || len(r.patterns) > 0)
+}
+
+// Marshal encodes v as JSON with sensitive values masked. When anything was
+// redacted, the count is reported in a top-level "redacted_values" field and
+// appended to the top-level "summary" string, if there is one. Documents that
+// are not objects are wrapped as {"result": ..., "redacted_values": n} so the
+// count is never dropped.
+func (r *Redactor) Marshal(v interface{}) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil || !r.Enabled() {
+		return data, err
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var doc interface{}
+	if err := dec.Decode(&doc); err != nil {
+		return nil, err
+	}
+
+	doc, count := r.Redact(doc)
+	if count == 0 {
+		return json.Marshal(doc)
+	}
+	obj, ok := doc.(map[string]interface{})
+	if !ok {
+		obj = map[string]interface{}{"result": doc}
+	}
+	obj["redacted_values"] = count
+	if summary, ok := obj["summary"].(string); ok {
+		obj["summary"] = strings.TrimSpace(fmt.Sprintf("%s Redacted %d sensitive values.", summary, count))
+	}
+	return json.Marshal(obj)
+}
+
+// Redact masks values in a decoded JSON document and returns it along with
+// the number of values redacted. Path rules run first and replace whole
+// values; patterns then mask matches inside the remaining strings.
+func (r *Redactor) Redact(doc interface{}) (interface{}, int) {
+	if !r.Enabled() {
+		return doc, 0
+	}
+	count := 0
+	for _, p := range r.paths {
+		var n int
+		doc, n = p.apply(doc)
+		count += n
+	}
+	doc, n := r.redactStrings(doc)
+	return doc, count + n
+}
+
+func (r *Redactor) redactStrings(v interface{}) (interface{}, int) {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		count := 0
+		for k, child := range val {
+			var n int
+			val[k], n = r.redactStrings(child)
+			count += n
+		}
+		return val, count
+	case []interface{}:
+		count := 0
+		for i, child := range val {
+			var n int
+			val[i], n = r.redactStrings(child)
+			count += n
+		}
+		return val, count
+	case string:
+		return r.redactString(val)
+	case json.Number:
+		// Card numbers are often stored as numbers rather than strings.
+		if s, n := r.redactString(val.String()); n > 0 {
+			return s, n
+		}
+		return val, 0
+	}
+	return v, 0
+}
+
+func (r *Redactor) redactString(s string) (string, int) {
+	count := 0
+	for _, p := range r.patterns {
+		s = p.re.ReplaceAllStringFunc(s, func(match string) string {
+			if p.name == "card" && !luhnValid(match) {
+				return match
+			}
+			count++
+			return "[REDACTED:" + p.name + "]"
+		})
+	}
+	return s, count
+}
+
+// luhnValid reports whether the digits in s pass the Luhn checksum, which
+// keeps long order numbers and timestamps from being mistaken for cards.
+func luhnValid(s string) bool {
+	sum, digits := 0, 0
+	for i := len(s) - 1; i >= 0; i-- {
+		c := s[i]
+		if c < '0' || c > '9' {
+			continue
+		}
+		d := int(c - '0')
+		if digits%2 == 1 {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		digits++
+	}
+	return digits >= 13 && sum%10 == 0
+}
@@ -0,0 +1,141 @@
+package redact
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/robryanx/mcp-temporal-server/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func decode(t *testing.T, s string) interface{} {
+	t.Helper()
+	var v interface{}
+	require.NoError(t, json.Unmarshal([]byte(s), &v))
+	return v
+}
+
+func TestRedact_Paths(t *testing.T) {
+	r, err := New(config.RedactionConfig{Paths: []string{
+		"$..password",
+		"$.events[*].details.input_part_0.card",
+		"$.events[1].details['api key']",
+	}})
+	require.NoError(t, err)
+
+	doc, count := r.Redact(decode(t, `{
+		"password": "root",
+		"events": [
+			{"details": {"input_part_0": {"card": "4111", "user": {"password": "hunter2"}}}},
+			{"details": {"api key": "k-1", "input_part_0": {"amount": 10}}}
+		]
+	}`))
+
+	assert.Equal(t, 4, count)
+	assert.Equal(t, decode(t, `{
+		"password": "[REDACTED]",
+		"events": [
+			{"details": {"input_part_0": {"card": "[REDACTED]", "user": {"password": "[REDACTED]"}}}},
+			{"details": {"api key": "[REDACTED]", "input_part_0": {"amount": 10}}}
+		]
+	}`), doc)
+}
+
+func TestRedact_BuiltinPatterns(t *testing.T) {
+	r, err := New(config.RedactionConfig{Patterns: []string{"email", "card", "token"}})
+	require.NoError(t, err)
+
+	doc, count := r.Redact(decode(t, `{
+		"contact": "mail jane.doe@example.com or bob@example.org",
+		"card": "4111 1111 1111 1111",
+		"order": "order 1234567890123 shipped",
+		"auth": "Bearer abc.def-123",
+		"ids": ["sk_live_abcdefghijklmnop"]
+	}`))
+
+	assert.Equal(t, 5, count)
+	assert.Equal(t, map[string]interface{}{
+		"contact": "mail [REDACTED:email] or [REDACTED:email]",
+		"card":    "[REDACTED:card]",
+		"order":   "order 1234567890123 shipped",
+		"auth":    "[REDACTED:token]",
+		"ids":     []interface{}{"[REDACTED:token]"},
+	}, doc)
+}
+
+func TestRedact_ConfigFile(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "redaction.json")
+	require.NoError(t, os.WriteFile(file, []byte(`{
+		"paths": ["$..ssn"],
+		"builtin_patterns": ["email"],
+		"patterns": {"phone": "\\+\\d{11}"}
+	}`), 0o600))
+
+	r, err := New(config.RedactionConfig{File: file})
+	require.NoError(t, err)
+
+	doc, count := r.Redact(decode(t, `{"ssn": "123-45-6789", "note": "call +15551234567 or a@b.io"}`))
+
+	assert.Equal(t, 3, count)
+	assert.Equal(t, map[string]interface{}{
+		"ssn":  "[REDACTED]",
+		"note": "call [REDACTED:phone] or [REDACTED:email]",
+	}, doc)
+}
+
+func TestRedactor_Marshal(t *testing.T) {
+	r, err := New(config.RedactionConfig{Patterns: []string{"card"}})
+	require.NoError(t, err)
+
+	data, err := r.Marshal(struct {
+		Card    int64  `json:"card"`
+		Summary string `json:"summary"`
+	}{Card: 4111111111111111, Summary: "Workflow has 3 events."})
+
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"card": "[REDACTED:card]", "summary": "Workflow has 3 events. Redacted 1 sensitive values.", "redacted_values": 1}`, string(data))
+
+	data, err = r.Marshal(struct {
+		Card    int64  `json:"card"`
+		Summary string `json:"summary"`
+	}{Card: 42, Summary: "Workflow has 3 events."})
+
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"card": 42, "summary": "Workflow has 3 events."}`, string(data), "nothing is reported when nothing was redacted")
+}
+
+func TestRedactor_MarshalWithoutSummary(t *testing.T) {
+	r, err := New(config.RedactionConfig{Patterns: []string{"email"}})
+	require.NoError(t, err)
+
+	data, err := r.Marshal(map[string]interface{}{"summary": []string{"mail a@example.com"}})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"summary": ["mail [REDACTED:email]"], "redacted_values": 1}`, string(data))
+
+	data, err = r.Marshal([]string{"a@example.com", "b@example.com"})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"result": ["[REDACTED:email]", "[REDACTED:email]"], "redacted_values": 2}`, string(data))
+}
+
+func TestRedactor_Disabled(t *testing.T) {
+	r, err := New(config.RedactionConfig{})
+	require.NoError(t, err)
+
+	data, err := r.Marshal(map[string]string{"email": "a@b.io", "summary": "ok"})
+	require.NoError(t, err)
+	assert.Equal(t, `{"email":"a@b.io","summary":"ok"}`, string(data))
+}
+
+func TestNew_Errors(t *testing.T) {
+	_, err := New(config.RedactionConfig{Paths: []string{"password"}})
+	assert.EqualError(t, err, `invalid redaction path "password": path must start with $`)
+
+	_, err = New(config.RedactionConfig{Paths: []string{"$.a[x]"}})
+	assert.EqualError(t, err, `invalid redaction path "$.a[x]": invalid index "x"`)
+
+	_, err = New(config.RedactionConfig{Patterns: []string{"ssn"}})
+	assert.EqualError(t, err, `unknown redaction pattern "ssn" (expected email, card or token)`)
+}
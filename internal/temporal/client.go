@@ -1,13 +1,45 @@
 package temporal
 
 import (
+	"fmt"
+
 	"github.com/robryanx/mcp-temporal-server/internal/config"
 	"go.temporal.io/sdk/client"
+	"google.golang.org/grpc"
 )
 
 func NewTemporalClient(cfg config.Config) (client.Client, error) {
-	return client.NewClient(client.Options{
+	options := client.Options{
 		HostPort:  cfg.TemporalAddress,
 		Namespace: cfg.Namespace,
-	})
+	}
+
+	apiKey, err := loadAPIKey(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	tlsConfig, err := newTLSConfig(cfg.TLS, apiKey != "")
+	if err != nil {
+		return nil, err
+	}
+	options.ConnectionOptions.TLS = tlsConfig
+
+	if apiKey != "" {
+		options.HeadersProvider = apiKeyHeadersProvider{apiKey: apiKey, namespace: cfg.Namespace}
+	}
+
+	if cfg.Codec.Endpoint != "" {
+		interceptor, err := newCodecInterceptor(cfg.Codec, cfg.Namespace)
+		if err != nil {
+			return nil, fmt.Errorf("failed to set up remote codec: %w", err)
+		}
+		options.ConnectionOptions.DialOptions = append(options.ConnectionOptions.DialOptions, grpc.WithChainUnaryInterceptor(interceptor))
+	}
+
+	c, err := client.NewClient(options)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to Temporal at %s: %w", cfg.TemporalAddress, err)
+	}
+	return c, nil
 }
@@ -0,0 +1,99 @@
+package temporal
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gogo/protobuf/jsonpb"
+	"github.com/robryanx/mcp-temporal-server/internal/config"
+	"go.temporal.io/api/common/v1"
+	"go.temporal.io/sdk/converter"
+	"google.golang.org/grpc"
+)
+
+const codecTimeout = 30 * time.Second
+
+// remoteCodec is a converter.PayloadCodec backed by a Temporal remote codec
+// server, speaking the same /encode and /decode protocol as the Web UI and CLI.
+type remoteCodec struct {
+	endpoint  string
+	namespace string
+	auth      string
+	headers   map[string]string
+	client    *http.Client
+}
+
+func newRemoteCodec(cfg config.CodecConfig, namespace string) *remoteCodec {
+	return &remoteCodec{
+		endpoint:  strings.TrimSuffix(cfg.Endpoint, "/"),
+		namespace: namespace,
+		auth:      cfg.Auth,
+		headers:   cfg.Headers,
+		client:    &http.Client{Timeout: codecTimeout},
+	}
+}
+
+// newCodecInterceptor returns a gRPC interceptor that runs every payload the
+// client sends or receives through the remote codec, so handlers only ever see
+// decoded payloads.
+func newCodecInterceptor(cfg config.CodecConfig, namespace string) (grpc.UnaryClientInterceptor, error) {
+	return converter.NewPayloadCodecGRPCClientInterceptor(converter.PayloadCodecGRPCClientInterceptorOptions{
+		Codecs: []converter.PayloadCodec{newRemoteCodec(cfg, namespace)},
+	})
+}
+
+func (c *remoteCodec) Encode(payloads []*common.Payload) ([]*common.Payload, error) {
+	return c.call("/encode", payloads)
+}
+
+func (c *remoteCodec) Decode(payloads []*common.Payload) ([]*common.Payload, error) {
+	return c.call("/decode", payloads)
+}
+
+func (c *remoteCodec) call(path string, payloads []*common.Payload) ([]*common.Payload, error) {
+	if len(payloads) == 0 {
+		return payloads, nil
+	}
+
+	var body bytes.Buffer
+	if err := (&jsonpb.Marshaler{}).Marshal(&body, &common.Payloads{Payloads: payloads}); err != nil {
+		return payloads, fmt.Errorf("failed to marshal payloads for codec: %w", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, c.endpoint+path, &body)
+	if err != nil {
+		return payloads, fmt.Errorf("failed to build codec request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Namespace", c.namespace)
+	if c.auth != "" {
+		req.Header.Set("Authorization", c.auth)
+	}
+	for k, v := range c.headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return payloads, fmt.Errorf("codec request to %s failed: %w", c.endpoint+path, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return payloads, fmt.Errorf("codec %s returned %s: %s", path, resp.Status, strings.TrimSpace(string(message)))
+	}
+
+	var result common.Payloads
+	if err := jsonpb.Unmarshal(resp.Body, &result); err != nil {
+		return payloads, fmt.Errorf("failed to parse codec response: %w", err)
+	}
+	if len(result.GetPayloads()) != len(payloads) {
+		return payloads, fmt.Errorf("codec %s returned %d payloads, expected %d", path, len(result.GetPayloads()), len(payloads))
+	}
+	return result.GetPayloads(), nil
+}
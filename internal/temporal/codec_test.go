@@ -0,0 +1,61 @@
+package temporal
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/robryanx/mcp-temporal-server/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.temporal.io/api/common/v1"
+)
+
+func TestRemoteCodec_Decode(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/codec/decode", r.URL.Path)
+		assert.Equal(t, "Bearer codec-token", r.Header.Get("Authorization"))
+		assert.Equal(t, "orders", r.Header.Get("X-Namespace"))
+		assert.Equal(t, "tenant-a", r.Header.Get("X-Tenant"))
+
+		var req struct {
+			Payloads []json.RawMessage `json:"payloads"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		assert.Len(t, req.Payloads, 1)
+
+		w.Header().Set("Content-Type", "application/json")
+		// Payload data is base64 in the proto JSON form: eyJhIjoxfQ== is {"a":1}.
+		_, _ = w.Write([]byte(`{"payloads":[{"metadata":{"encoding":"anNvbi9wbGFpbg=="},"data":"eyJhIjoxfQ=="}]}`))
+	}))
+	defer server.Close()
+
+	codec := newRemoteCodec(config.CodecConfig{
+		Endpoint: server.URL + "/codec/",
+		Auth:     "Bearer codec-token",
+		Headers:  map[string]string{"X-Tenant": "tenant-a"},
+	}, "orders")
+
+	decoded, err := codec.Decode([]*common.Payload{{
+		Metadata: map[string][]byte{"encoding": []byte("binary/encrypted")},
+		Data:     []byte("ciphertext"),
+	}})
+
+	require.NoError(t, err)
+	require.Len(t, decoded, 1)
+	assert.Equal(t, "json/plain", string(decoded[0].GetMetadata()["encoding"]))
+	assert.Equal(t, `{"a":1}`, string(decoded[0].GetData()))
+}
+
+func TestRemoteCodec_Error(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	codec := newRemoteCodec(config.CodecConfig{Endpoint: server.URL}, "default")
+	_, err := codec.Decode([]*common.Payload{{Data: []byte("x")}})
+
+	assert.EqualError(t, err, "codec /decode returned 401 Unauthorized: unauthorized")
+}
@@ -0,0 +1,115 @@
+package temporal
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/robryanx/mcp-temporal-server/internal/config"
+)
+
+// newTLSConfig builds the TLS settings for the Temporal connection. It returns
+// nil when TLS is not requested, either explicitly or implicitly by supplying
+// certificate material or an API key.
+func newTLSConfig(cfg config.TLSConfig, forceTLS bool) (*tls.Config, error) {
+	cert, err := readPEM("TLS client certificate", cfg.CertFile, cfg.CertData)
+	if err != nil {
+		return nil, err
+	}
+	key, err := readPEM("TLS client key", cfg.KeyFile, cfg.KeyData)
+	if err != nil {
+		return nil, err
+	}
+	ca, err := readPEM("TLS CA bundle", cfg.CAFile, cfg.CAData)
+	if err != nil {
+		return nil, err
+	}
+
+	if !cfg.Enabled && !forceTLS && cert == nil && key == nil && ca == nil && cfg.ServerName == "" {
+		return nil, nil
+	}
+
+	tlsConfig := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		ServerName:         cfg.ServerName,
+		InsecureSkipVerify: cfg.DisableHostVerification,
+	}
+
+	switch {
+	case cert != nil && key != nil:
+		pair, err := tls.X509KeyPair(cert, key)
+		if err != nil {
+			return nil, fmt.Errorf("invalid TLS client certificate/key pair: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{pair}
+	case cert != nil:
+		return nil, errors.New("TLS client certificate was provided without a key (set TEMPORAL_TLS_KEY or TEMPORAL_TLS_KEY_DATA)")
+	case key != nil:
+		return nil, errors.New("TLS client key was provided without a certificate (set TEMPORAL_TLS_CERT or TEMPORAL_TLS_CERT_DATA)")
+	}
+
+	if ca != nil {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(ca) {
+			return nil, errors.New("invalid TLS CA bundle: no PEM certificates found")
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	return tlsConfig, nil
+}
+
+// readPEM returns PEM material from either a file or inline data, or nil if
+// neither is set.
+func readPEM(name, path, data string) ([]byte, error) {
+	switch {
+	case path != "" && data != "":
+		return nil, fmt.Errorf("%s is set both as a file and inline data; use only one", name)
+	case path != "":
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", name, err)
+		}
+		return b, nil
+	case data != "":
+		return []byte(data), nil
+	}
+	return nil, nil
+}
+
+func loadAPIKey(cfg config.Config) (string, error) {
+	if cfg.APIKey != "" && cfg.APIKeyFile != "" {
+		return "", errors.New("API key is set both as TEMPORAL_API_KEY and TEMPORAL_API_KEY_FILE; use only one")
+	}
+	if cfg.APIKeyFile == "" {
+		return strings.TrimSpace(cfg.APIKey), nil
+	}
+
+	b, err := os.ReadFile(cfg.APIKeyFile)
+	if err != nil {
+		return "", fmt.Errorf("failed to read API key file: %w", err)
+	}
+	key := strings.TrimSpace(string(b))
+	if key == "" {
+		return "", fmt.Errorf("API key file %s is empty", cfg.APIKeyFile)
+	}
+	return key, nil
+}
+
+// apiKeyHeadersProvider attaches the API key as a bearer token to every
+// outgoing request.
+type apiKeyHeadersProvider struct {
+	apiKey    string
+	namespace string
+}
+
+func (p apiKeyHeadersProvider) GetHeaders(context.Context) (map[string]string, error) {
+	return map[string]string{
+		"authorization":      "Bearer " + p.apiKey,
+		"temporal-namespace": p.namespace,
+	}, nil
+}
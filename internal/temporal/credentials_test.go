@@ -0,0 +1,161 @@
+package temporal
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/robryanx/mcp-temporal-server/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func generateCert(t *testing.T) (certPEM, keyPEM string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "test"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		IsCA:         true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	certPEM = string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+	keyPEM = string(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}))
+	return certPEM, keyPEM
+}
+
+func TestNewTLSConfig_Disabled(t *testing.T) {
+	tlsConfig, err := newTLSConfig(config.TLSConfig{}, false)
+	assert.NoError(t, err)
+	assert.Nil(t, tlsConfig)
+}
+
+func TestNewTLSConfig_ForcedByAPIKey(t *testing.T) {
+	tlsConfig, err := newTLSConfig(config.TLSConfig{}, true)
+	require.NoError(t, err)
+	require.NotNil(t, tlsConfig)
+	assert.Empty(t, tlsConfig.Certificates)
+	assert.Nil(t, tlsConfig.RootCAs)
+}
+
+func TestNewTLSConfig_InlineMaterial(t *testing.T) {
+	certPEM, keyPEM := generateCert(t)
+
+	tlsConfig, err := newTLSConfig(config.TLSConfig{
+		CertData:   certPEM,
+		KeyData:    keyPEM,
+		CAData:     certPEM,
+		ServerName: "example.tmprl.cloud",
+	}, false)
+
+	require.NoError(t, err)
+	require.NotNil(t, tlsConfig)
+	assert.Len(t, tlsConfig.Certificates, 1)
+	assert.NotNil(t, tlsConfig.RootCAs)
+	assert.Equal(t, "example.tmprl.cloud", tlsConfig.ServerName)
+}
+
+func TestNewTLSConfig_FileMaterial(t *testing.T) {
+	certPEM, keyPEM := generateCert(t)
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "client.pem")
+	keyFile := filepath.Join(dir, "client.key")
+	require.NoError(t, os.WriteFile(certFile, []byte(certPEM), 0o600))
+	require.NoError(t, os.WriteFile(keyFile, []byte(keyPEM), 0o600))
+
+	tlsConfig, err := newTLSConfig(config.TLSConfig{CertFile: certFile, KeyFile: keyFile}, false)
+
+	require.NoError(t, err)
+	assert.Len(t, tlsConfig.Certificates, 1)
+}
+
+func TestNewTLSConfig_Errors(t *testing.T) {
+	certPEM, keyPEM := generateCert(t)
+
+	tests := []struct {
+		name    string
+		cfg     config.TLSConfig
+		wantErr string
+	}{
+		{
+			name:    "cert without key",
+			cfg:     config.TLSConfig{CertData: certPEM},
+			wantErr: "TLS client certificate was provided without a key",
+		},
+		{
+			name:    "key without cert",
+			cfg:     config.TLSConfig{KeyData: keyPEM},
+			wantErr: "TLS client key was provided without a certificate",
+		},
+		{
+			name:    "mismatched pair",
+			cfg:     config.TLSConfig{CertData: certPEM, KeyData: "not a key"},
+			wantErr: "invalid TLS client certificate/key pair",
+		},
+		{
+			name:    "invalid CA",
+			cfg:     config.TLSConfig{CAData: "garbage"},
+			wantErr: "invalid TLS CA bundle",
+		},
+		{
+			name:    "file and data both set",
+			cfg:     config.TLSConfig{CAFile: "/tmp/ca.pem", CAData: certPEM},
+			wantErr: "TLS CA bundle is set both as a file and inline data",
+		},
+		{
+			name:    "missing file",
+			cfg:     config.TLSConfig{CertFile: "/nonexistent/client.pem"},
+			wantErr: "failed to read TLS client certificate",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := newTLSConfig(tt.cfg, false)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+func TestLoadAPIKey(t *testing.T) {
+	dir := t.TempDir()
+	keyFile := filepath.Join(dir, "api-key")
+	require.NoError(t, os.WriteFile(keyFile, []byte("secret-key\n"), 0o600))
+
+	key, err := loadAPIKey(config.Config{APIKeyFile: keyFile})
+	assert.NoError(t, err)
+	assert.Equal(t, "secret-key", key)
+
+	key, err = loadAPIKey(config.Config{APIKey: "env-key"})
+	assert.NoError(t, err)
+	assert.Equal(t, "env-key", key)
+
+	_, err = loadAPIKey(config.Config{APIKey: "env-key", APIKeyFile: keyFile})
+	assert.Error(t, err)
+}
+
+func TestAPIKeyHeadersProvider(t *testing.T) {
+	headers, err := apiKeyHeadersProvider{apiKey: "abc", namespace: "ns"}.GetHeaders(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, "Bearer abc", headers["authorization"])
+	assert.Equal(t, "ns", headers["temporal-namespace"])
+}
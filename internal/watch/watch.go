@@ -0,0 +1,271 @@
+// Package watch follows running workflows for resource subscriptions. Each
+// subscription gets a watcher that long-polls the workflow's history and
+// reports when new events arrive.
+package watch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"go.temporal.io/api/enums/v1"
+	"go.temporal.io/sdk/client"
+)
+
+const (
+	// defaultNotifyInterval is the minimum time between two update
+	// notifications for one subscription. Events arriving in between are
+	// reported together by the next notification.
+	defaultNotifyInterval = time.Second
+
+	// defaultRetryDelay is how long a watcher waits before polling again
+	// after the history request failed.
+	defaultRetryDelay = 5 * time.Second
+)
+
+// Target is the workflow run a subscribed resource refers to. An empty RunID
+// follows the run that is current when the subscription starts.
+type Target struct {
+	WorkflowID string
+	RunID      string
+}
+
+// ErrSessionClosed is returned by a NotifyFunc when the session no longer
+// exists. The manager then stops all of the session's watchers.
+var ErrSessionClosed = errors.New("session closed")
+
+// NotifyFunc tells a client session that the resource at uri has changed.
+type NotifyFunc func(sessionID, uri string) error
+
+// Limits bounds the number of watchers running at once. Zero means no limit.
+type Limits struct {
+	MaxWatchers   int
+	MaxPerSession int
+}
+
+type key struct {
+	sessionID string
+	uri       string
+}
+
+type watcher struct {
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// Manager runs one watcher per subscribed resource and client session.
+type Manager struct {
+	client client.Client
+	notify NotifyFunc
+	limits Limits
+
+	notifyInterval time.Duration
+	retryDelay     time.Duration
+
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu       sync.Mutex
+	watchers map[key]*watcher
+}
+
+func NewManager(temporalClient client.Client, notify NotifyFunc, limits Limits) *Manager {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Manager{
+		client:         temporalClient,
+		notify:         notify,
+		limits:         limits,
+		notifyInterval: defaultNotifyInterval,
+		retryDelay:     defaultRetryDelay,
+		ctx:            ctx,
+		cancel:         cancel,
+		watchers:       make(map[key]*watcher),
+	}
+}
+
+// Subscribe starts watching the target's history for the session. Only
+// running workflows can be watched, and events already in the history when
+// the subscription starts are not reported. Subscribing again to the same
+// resource in the same session keeps the existing watcher.
+func (m *Manager) Subscribe(ctx context.Context, sessionID, uri string, target Target) error {
+	k := key{sessionID: sessionID, uri: uri}
+	m.mu.Lock()
+	_, exists := m.watchers[k]
+	m.mu.Unlock()
+	if exists {
+		return nil
+	}
+
+	resp, err := m.client.DescribeWorkflowExecution(ctx, target.WorkflowID, target.RunID)
+	if err != nil {
+		return fmt.Errorf("failed to describe workflow: %w", err)
+	}
+	info := resp.GetWorkflowExecutionInfo()
+	if status := info.GetStatus(); status != enums.WORKFLOW_EXECUTION_STATUS_RUNNING {
+		return fmt.Errorf("workflow %s is %s, so its history will not change", target.WorkflowID, status)
+	}
+	target.RunID = info.GetExecution().GetRunId()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err := m.ctx.Err(); err != nil {
+		return fmt.Errorf("watchers are shutting down")
+	}
+	if _, exists := m.watchers[k]; exists {
+		return nil
+	}
+	if m.limits.MaxWatchers > 0 && len(m.watchers) >= m.limits.MaxWatchers {
+		return fmt.Errorf("too many subscriptions: the server watches at most %d workflows", m.limits.MaxWatchers)
+	}
+	if m.limits.MaxPerSession > 0 && m.sessionWatchers(sessionID) >= m.limits.MaxPerSession {
+		return fmt.Errorf("too many subscriptions: a session can watch at most %d workflows", m.limits.MaxPerSession)
+	}
+
+	watchCtx, cancel := context.WithCancel(m.ctx)
+	w := &watcher{cancel: cancel, done: make(chan struct{})}
+	m.watchers[k] = w
+	go m.run(watchCtx, k, w, target, info.GetHistoryLength())
+	return nil
+}
+
+// Unsubscribe stops the session's watcher for the resource, if any, and
+// waits for it to exit.
+func (m *Manager) Unsubscribe(sessionID, uri string) {
+	k := key{sessionID: sessionID, uri: uri}
+	m.mu.Lock()
+	w, ok := m.watchers[k]
+	delete(m.watchers, k)
+	m.mu.Unlock()
+	if ok {
+		w.cancel()
+		<-w.done
+	}
+}
+
+// CloseSession stops every watcher of a session that has ended.
+func (m *Manager) CloseSession(sessionID string) {
+	m.mu.Lock()
+	var stopped []*watcher
+	for k, w := range m.watchers {
+		if k.sessionID == sessionID {
+			stopped = append(stopped, w)
+			delete(m.watchers, k)
+		}
+	}
+	m.mu.Unlock()
+	stop(stopped)
+}
+
+// Close stops all watchers. Later subscriptions are refused.
+func (m *Manager) Close() {
+	m.mu.Lock()
+	m.cancel()
+	stopped := make([]*watcher, 0, len(m.watchers))
+	for k, w := range m.watchers {
+		stopped = append(stopped, w)
+		delete(m.watchers, k)
+	}
+	m.mu.Unlock()
+	stop(stopped)
+}
+
+func stop(watchers []*watcher) {
+	for _, w := range watchers {
+		w.cancel()
+	}
+	for _, w := range watchers {
+		<-w.done
+	}
+}
+
+func (m *Manager) sessionWatchers(sessionID string) int {
+	n := 0
+	for k := range m.watchers {
+		if k.sessionID == sessionID {
+			n++
+		}
+	}
+	return n
+}
+
+// run long-polls the run's history until it closes or the watcher is
+// stopped, signalling an update for each event after the first seen ones.
+func (m *Manager) run(ctx context.Context, k key, w *watcher, target Target, seen int64) {
+	defer close(w.done)
+	defer m.remove(k, w)
+
+	updates := make(chan struct{}, 1)
+	delivered := make(chan struct{})
+	go func() {
+		defer close(delivered)
+		m.deliver(ctx, k, updates)
+	}()
+	defer func() {
+		close(updates)
+		<-delivered
+	}()
+
+	for {
+		iter := m.client.GetWorkflowHistory(ctx, target.WorkflowID, target.RunID, true, enums.HISTORY_EVENT_FILTER_TYPE_ALL_EVENT)
+		var err error
+		for iter.HasNext() {
+			evt, nextErr := iter.Next()
+			if nextErr != nil {
+				err = nextErr
+				break
+			}
+			if evt.GetEventId() > seen {
+				seen = evt.GetEventId()
+				select {
+				case updates <- struct{}{}:
+				default:
+				}
+			}
+		}
+		if err == nil || ctx.Err() != nil {
+			// The history ends once the run closes.
+			return
+		}
+
+		log.Printf("Watching %s for session %s failed, retrying in %s: %v", k.uri, k.sessionID, m.retryDelay, err)
+		select {
+		case <-time.After(m.retryDelay):
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// deliver sends a notification for each signalled update, at most one per
+// notify interval. It returns once updates is closed and drained, when the
+// watcher is stopped, or when the session has closed.
+func (m *Manager) deliver(ctx context.Context, k key, updates <-chan struct{}) {
+	for range updates {
+		if err := m.notify(k.sessionID, k.uri); errors.Is(err, ErrSessionClosed) {
+			// CloseSession waits for this watcher to exit, so it cannot be
+			// called from here.
+			go m.CloseSession(k.sessionID)
+			return
+		} else if err != nil {
+			log.Printf("Failed to notify session %s of an update to %s: %v", k.sessionID, k.uri, err)
+		}
+		select {
+		case <-time.After(m.notifyInterval):
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// remove forgets a watcher that has exited on its own, unless it was already
+// replaced or removed.
+func (m *Manager) remove(k key, w *watcher) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.watchers[k] == w {
+		delete(m.watchers, k)
+	}
+}
@@ -0,0 +1,289 @@
+package watch
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.temporal.io/api/common/v1"
+	"go.temporal.io/api/enums/v1"
+	"go.temporal.io/api/history/v1"
+	"go.temporal.io/api/workflow/v1"
+	"go.temporal.io/api/workflowservice/v1"
+	"go.temporal.io/sdk/client"
+)
+
+// feedClient serves running workflows whose history is fed by the test.
+// Closing a feed ends the history, as when the run closes.
+type feedClient struct {
+	client.Client
+	status enums.WorkflowExecutionStatus
+
+	mu    sync.Mutex
+	feeds map[string]chan *history.HistoryEvent
+	polls map[string]int
+}
+
+func newFeedClient() *feedClient {
+	return &feedClient{
+		status: enums.WORKFLOW_EXECUTION_STATUS_RUNNING,
+		feeds:  make(map[string]chan *history.HistoryEvent),
+		polls:  make(map[string]int),
+	}
+}
+
+func (c *feedClient) feed(workflowID string) chan *history.HistoryEvent {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.feeds[workflowID]; !ok {
+		c.feeds[workflowID] = make(chan *history.HistoryEvent, 10)
+	}
+	return c.feeds[workflowID]
+}
+
+func (c *feedClient) DescribeWorkflowExecution(_ context.Context, workflowID, _ string) (*workflowservice.DescribeWorkflowExecutionResponse, error) {
+	return &workflowservice.DescribeWorkflowExecutionResponse{
+		WorkflowExecutionInfo: &workflow.WorkflowExecutionInfo{
+			Execution:     &common.WorkflowExecution{WorkflowId: workflowID, RunId: "run-1"},
+			Status:        c.status,
+			HistoryLength: 3,
+		},
+	}, nil
+}
+
+func (c *feedClient) GetWorkflowHistory(ctx context.Context, workflowID, runID string, isLongPoll bool, _ enums.HistoryEventFilterType) client.HistoryEventIterator {
+	c.mu.Lock()
+	c.polls[workflowID+"/"+runID]++
+	c.mu.Unlock()
+	return &feedIterator{ctx: ctx, events: c.feed(workflowID)}
+}
+
+type feedIterator struct {
+	ctx    context.Context
+	events <-chan *history.HistoryEvent
+	next   *history.HistoryEvent
+	err    error
+}
+
+func (it *feedIterator) HasNext() bool {
+	if it.next != nil || it.err != nil {
+		return true
+	}
+	select {
+	case evt, ok := <-it.events:
+		if !ok {
+			return false
+		}
+		it.next = evt
+	case <-it.ctx.Done():
+		it.err = it.ctx.Err()
+	}
+	return true
+}
+
+func (it *feedIterator) Next() (*history.HistoryEvent, error) {
+	if it.err != nil {
+		return nil, it.err
+	}
+	evt := it.next
+	it.next = nil
+	return evt, nil
+}
+
+// notifications records the updates sent to clients.
+type notifications struct {
+	ch chan string
+}
+
+func newNotifications() *notifications {
+	return &notifications{ch: make(chan string, 100)}
+}
+
+func (n *notifications) notify(sessionID, uri string) error {
+	n.ch <- sessionID + " " + uri
+	return nil
+}
+
+func (n *notifications) next(t *testing.T) string {
+	t.Helper()
+	select {
+	case got := <-n.ch:
+		return got
+	case <-time.After(time.Second):
+		t.Fatal("no notification")
+		return ""
+	}
+}
+
+func (n *notifications) none(t *testing.T) {
+	t.Helper()
+	select {
+	case got := <-n.ch:
+		t.Fatalf("unexpected notification %q", got)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func newTestManager(c client.Client, n *notifications, limits Limits) *Manager {
+	m := NewManager(c, n.notify, limits)
+	m.notifyInterval = 20 * time.Millisecond
+	m.retryDelay = 10 * time.Millisecond
+	return m
+}
+
+func event(id int64) *history.HistoryEvent {
+	return &history.HistoryEvent{EventId: id}
+}
+
+func TestManager_NotifiesNewEvents(t *testing.T) {
+	c := newFeedClient()
+	n := newNotifications()
+	m := newTestManager(c, n, Limits{})
+	defer m.Close()
+
+	require.NoError(t, m.Subscribe(context.Background(), "s1", "temporal://default/workflows/wf-1", Target{WorkflowID: "wf-1"}))
+
+	feed := c.feed("wf-1")
+	for id := int64(1); id <= 3; id++ {
+		feed <- event(id)
+	}
+	n.none(t)
+
+	feed <- event(4)
+	assert.Equal(t, "s1 temporal://default/workflows/wf-1", n.next(t))
+
+	c.mu.Lock()
+	assert.Equal(t, 1, c.polls["wf-1/run-1"], "watches the run current at subscription")
+	c.mu.Unlock()
+}
+
+func TestManager_CoalescesBursts(t *testing.T) {
+	c := newFeedClient()
+	n := newNotifications()
+	m := newTestManager(c, n, Limits{})
+	m.notifyInterval = 200 * time.Millisecond
+	defer m.Close()
+
+	require.NoError(t, m.Subscribe(context.Background(), "s1", "uri", Target{WorkflowID: "wf-1"}))
+	feed := c.feed("wf-1")
+	feed <- event(4)
+	n.next(t)
+	for id := int64(5); id <= 9; id++ {
+		feed <- event(id)
+	}
+
+	n.next(t)
+	n.none(t)
+}
+
+func TestManager_StopsWhenRunCloses(t *testing.T) {
+	c := newFeedClient()
+	n := newNotifications()
+	m := newTestManager(c, n, Limits{})
+	defer m.Close()
+
+	require.NoError(t, m.Subscribe(context.Background(), "s1", "uri", Target{WorkflowID: "wf-1"}))
+	feed := c.feed("wf-1")
+	feed <- event(4)
+	close(feed)
+
+	assert.Equal(t, "s1 uri", n.next(t))
+	assert.Eventually(t, func() bool {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		return len(m.watchers) == 0
+	}, time.Second, 5*time.Millisecond)
+}
+
+func TestManager_Unsubscribe(t *testing.T) {
+	c := newFeedClient()
+	n := newNotifications()
+	m := newTestManager(c, n, Limits{})
+	defer m.Close()
+
+	require.NoError(t, m.Subscribe(context.Background(), "s1", "uri-1", Target{WorkflowID: "wf-1"}))
+	require.NoError(t, m.Subscribe(context.Background(), "s1", "uri-2", Target{WorkflowID: "wf-2"}))
+	require.NoError(t, m.Subscribe(context.Background(), "s2", "uri-1", Target{WorkflowID: "wf-1"}))
+
+	m.Unsubscribe("s1", "uri-1")
+	m.mu.Lock()
+	assert.Len(t, m.watchers, 2)
+	m.mu.Unlock()
+
+	m.CloseSession("s1")
+	m.mu.Lock()
+	assert.Equal(t, map[key]bool{{sessionID: "s2", uri: "uri-1"}: true}, keys(m.watchers))
+	m.mu.Unlock()
+
+	m.Close()
+	m.mu.Lock()
+	assert.Empty(t, m.watchers)
+	m.mu.Unlock()
+	assert.ErrorContains(t, m.Subscribe(context.Background(), "s1", "uri-1", Target{WorkflowID: "wf-1"}), "shutting down")
+}
+
+func TestManager_Limits(t *testing.T) {
+	c := newFeedClient()
+	m := newTestManager(c, newNotifications(), Limits{MaxWatchers: 3, MaxPerSession: 2})
+	defer m.Close()
+
+	require.NoError(t, m.Subscribe(context.Background(), "s1", "uri-1", Target{WorkflowID: "wf-1"}))
+	require.NoError(t, m.Subscribe(context.Background(), "s1", "uri-2", Target{WorkflowID: "wf-2"}))
+	require.NoError(t, m.Subscribe(context.Background(), "s1", "uri-2", Target{WorkflowID: "wf-2"}), "resubscribing is a no-op")
+	assert.ErrorContains(t, m.Subscribe(context.Background(), "s1", "uri-3", Target{WorkflowID: "wf-3"}), "a session can watch at most 2")
+
+	require.NoError(t, m.Subscribe(context.Background(), "s2", "uri-1", Target{WorkflowID: "wf-1"}))
+	assert.ErrorContains(t, m.Subscribe(context.Background(), "s3", "uri-1", Target{WorkflowID: "wf-1"}), "the server watches at most 3")
+}
+
+func TestManager_RefusesClosedWorkflows(t *testing.T) {
+	c := newFeedClient()
+	c.status = enums.WORKFLOW_EXECUTION_STATUS_COMPLETED
+	m := newTestManager(c, newNotifications(), Limits{})
+	defer m.Close()
+
+	err := m.Subscribe(context.Background(), "s1", "uri", Target{WorkflowID: "wf-1"})
+
+	assert.EqualError(t, err, "workflow wf-1 is Completed, so its history will not change")
+}
+
+func TestManager_ClosesSessionWhenNotifyFindsItGone(t *testing.T) {
+	c := newFeedClient()
+	notified := make(chan string, 10)
+	m := NewManager(c, func(sessionID, uri string) error {
+		notified <- uri
+		if sessionID == "s1" {
+			return fmt.Errorf("%w: %s", ErrSessionClosed, sessionID)
+		}
+		return nil
+	}, Limits{})
+	m.notifyInterval = 20 * time.Millisecond
+	defer m.Close()
+
+	require.NoError(t, m.Subscribe(context.Background(), "s1", "uri-1", Target{WorkflowID: "wf-1"}))
+	require.NoError(t, m.Subscribe(context.Background(), "s1", "uri-2", Target{WorkflowID: "wf-2"}))
+	require.NoError(t, m.Subscribe(context.Background(), "s2", "uri-1", Target{WorkflowID: "wf-3"}))
+	c.feed("wf-1") <- event(4)
+
+	assert.Equal(t, "uri-1", <-notified)
+	assert.Eventually(t, func() bool {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		return len(m.watchers) == 1
+	}, time.Second, 5*time.Millisecond)
+	m.mu.Lock()
+	assert.Equal(t, map[key]bool{{sessionID: "s2", uri: "uri-1"}: true}, keys(m.watchers))
+	m.mu.Unlock()
+}
+
+func keys(watchers map[key]*watcher) map[key]bool {
+	out := make(map[key]bool)
+	for k := range watchers {
+		out[k] = true
+	}
+	return out
+}